@@ -3,12 +3,16 @@ package main
 import (
 	"bsnack/cmd/middleware"
 	"bsnack/config"
+	"bsnack/internal/domain"
 	"bsnack/internal/handler/http"
+	"bsnack/internal/receipt"
 	"bsnack/internal/repository/postgres"
 	"bsnack/internal/repository/redis"
 	"bsnack/internal/service"
 	"bsnack/pkg/database"
 	"bsnack/pkg/logger"
+	"bsnack/pkg/scheduler"
+	"context"
 	"log"
 	netHttp "net/http"
 	"time"
@@ -41,24 +45,143 @@ func main() {
 	prodRepo := postgres.NewProductRepo(db)
 	custRepo := postgres.NewCustomerRepo(db)
 	transRepo := postgres.NewTransactionRepo(db)
+	pointsRepo := postgres.NewPointsLedgerRepo(db)
+	loyaltyRepo := postgres.NewLoyaltyRuleRepo(db)
+	redemptionRepo := postgres.NewRedemptionRepo(db)
+	promoRepo := postgres.NewPromotionRepo(db)
+	taxRepo := postgres.NewTaxRateRepo(db)
+	shiftRepo := postgres.NewShiftRepo(db)
+	storeRepo := postgres.NewStoreRepo(db)
+	shelfLifeRepo := postgres.NewShelfLifeRepo(db)
+	inventoryRepo := postgres.NewInventoryRepo(db)
+	movementRepo := postgres.NewStockMovementRepo(db)
+	stocktakeRepo := postgres.NewStocktakeRepo(db)
+	reorderRepo := postgres.NewReorderLevelRepo(db)
 	cacheRepo := redis.NewRedisRepo(rdb)
+	uow := postgres.NewUnitOfWork(db)
 
-	prodSvc := service.NewProductService(prodRepo)
-	transSvc := service.NewTransactionService(prodRepo, custRepo, transRepo, cacheRepo)
-	custSvc := service.NewCustomerService(custRepo)
+	pointsExpiry := domain.PointsExpiryPolicy{
+		ValidMonths: cfg.PointsExpiryMonths,
+		WarningDays: cfg.PointsExpiryWarningDays,
+	}
+
+	prodSvc := service.NewProductService(uow, prodRepo, storeRepo, movementRepo)
+	transSvc := service.NewTransactionService(uow, prodRepo, custRepo, transRepo, redemptionRepo, cacheRepo, pointsExpiry)
+	custSvc := service.NewCustomerService(uow, custRepo, pointsRepo, pointsExpiry)
+	loyaltySvc := service.NewLoyaltyService(loyaltyRepo, prodRepo)
+	tierSvc := service.NewTierService(uow, custRepo, transRepo, loyaltyRepo)
+	promoSvc := service.NewPromotionService(promoRepo, prodRepo)
+	taxSvc := service.NewTaxService(taxRepo)
+	shiftSvc := service.NewShiftService(uow, shiftRepo, transRepo)
+	storeSvc := service.NewStoreService(storeRepo)
+	invSvc := service.NewInventoryService(uow, inventoryRepo, shelfLifeRepo, reorderRepo, prodRepo, storeRepo)
+	countSvc := service.NewStocktakeService(uow, stocktakeRepo)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if pointsExpiry.ValidMonths > 0 {
+		go scheduler.Every(ctx, "expire_points", cfg.PointsExpirySweepInterval, func(ctx context.Context) error {
+			expired, err := custSvc.ExpirePoints(ctx, time.Now())
+			if expired > 0 {
+				logger.Info("loyalty points expired", "points", expired)
+			}
+			return err
+		})
+	}
 
-	handler := http.NewHandler(prodSvc, transSvc, custSvc)
+	go scheduler.Daily(ctx, "recalculate_tiers", cfg.TierRecalcAt.Hour(), cfg.TierRecalcAt.Minute(), func(ctx context.Context) error {
+		events, err := tierSvc.RecalculateTiers(ctx, time.Now())
+		for _, e := range events {
+			logger.Info("customer tier changed",
+				"customer_id", e.CustomerID,
+				"from", e.FromTier,
+				"to", e.ToTier)
+		}
+		return err
+	})
+
+	printer := &receipt.Printer{
+		Header: receipt.Header{Name: cfg.StoreName, Address: cfg.StoreAddress, TaxID: cfg.StoreTaxID},
+		Width:  cfg.ReceiptWidth,
+	}
+	handler := http.NewHandler(prodSvc, transSvc, custSvc, loyaltySvc, tierSvc, promoSvc, taxSvc, shiftSvc, storeSvc, invSvc, countSvc, printer)
 
 	mux := netHttp.NewServeMux()
 
 	mux.HandleFunc("GET /customers", handler.ListCustomers)
+	mux.HandleFunc("POST /customers", handler.CreateCustomer)
+	mux.HandleFunc("GET /customers/lookup", handler.LookupCustomer)
+	mux.HandleFunc("GET /customers/{id}", handler.GetCustomer)
+	mux.HandleFunc("PUT /customers/{id}", handler.UpdateCustomer)
+	mux.HandleFunc("DELETE /customers/{id}", handler.DeleteCustomer)
+	mux.HandleFunc("POST /customers/{id}/merge", handler.MergeCustomers)
+	mux.HandleFunc("GET /customers/{id}/points/history", handler.GetPointsHistory)
+	mux.HandleFunc("POST /customers/{id}/points/adjustments", handler.AdjustPoints)
+	mux.HandleFunc("GET /customers/{id}/tier/history", handler.GetTierHistory)
 
 	mux.HandleFunc("POST /products", handler.AddProduct)
 	mux.HandleFunc("GET /products", handler.GetProducts)
+	mux.HandleFunc("GET /products/{id}", handler.GetProduct)
+	mux.HandleFunc("PUT /products/{id}", handler.ReplaceProduct)
+	mux.HandleFunc("PATCH /products/{id}", handler.PatchProduct)
+	mux.HandleFunc("DELETE /products/{id}", handler.DeleteProduct)
+	mux.HandleFunc("GET /products/{id}/batches", handler.ListBatches)
+	mux.HandleFunc("POST /products/{id}/batches", handler.ReceiveBatch)
+	mux.HandleFunc("GET /products/{id}/movements", handler.ListMovements)
+	mux.HandleFunc("POST /products/{id}/movements", handler.MoveStock)
+	mux.HandleFunc("PUT /products/{id}/reorder-level", handler.SetReorderLevel)
+	mux.HandleFunc("DELETE /products/{id}/reorder-level", handler.DeleteReorderLevel)
 
 	mux.HandleFunc("POST /transactions", handler.CreateTransaction)
 	mux.HandleFunc("GET /transactions", handler.GetReport)
+	mux.HandleFunc("GET /transactions/{id}/receipt", handler.GetReceipt)
+	mux.HandleFunc("POST /transactions/{id}/refund", handler.RefundTransaction)
+	mux.HandleFunc("POST /orders", handler.CreateOrder)
 	mux.HandleFunc("POST /redemptions", handler.Redeem)
+	mux.HandleFunc("GET /redemptions", handler.GetRedemptions)
+
+	mux.HandleFunc("GET /loyalty/rules", handler.GetLoyaltyRules)
+	mux.HandleFunc("POST /loyalty/rules/earn", handler.CreateEarnRule)
+	mux.HandleFunc("PUT /loyalty/rules/earn/{id}", handler.UpdateEarnRule)
+	mux.HandleFunc("POST /loyalty/rules/bonus", handler.CreateBonusRule)
+	mux.HandleFunc("PUT /loyalty/rules/bonus/{id}", handler.UpdateBonusRule)
+	mux.HandleFunc("POST /loyalty/rules/redeem", handler.CreateRedeemRule)
+	mux.HandleFunc("PUT /loyalty/rules/redeem/{id}", handler.UpdateRedeemRule)
+	mux.HandleFunc("DELETE /loyalty/rules/{kind}/{id}", handler.DeactivateLoyaltyRule)
+	mux.HandleFunc("PUT /loyalty/tiers/{tier}", handler.UpdateTier)
+
+	mux.HandleFunc("GET /promotions", handler.ListPromotions)
+	mux.HandleFunc("POST /promotions", handler.CreatePromotion)
+	mux.HandleFunc("PUT /promotions/{id}", handler.UpdatePromotion)
+	mux.HandleFunc("DELETE /promotions/{id}", handler.DeactivatePromotion)
+
+	mux.HandleFunc("GET /tax-rates", handler.ListTaxRates)
+	mux.HandleFunc("POST /tax-rates", handler.CreateTaxRate)
+	mux.HandleFunc("PUT /tax-rates/{id}", handler.UpdateTaxRate)
+	mux.HandleFunc("DELETE /tax-rates/{id}", handler.DeactivateTaxRate)
+
+	mux.HandleFunc("GET /stores", handler.ListStores)
+	mux.HandleFunc("POST /stores", handler.CreateStore)
+	mux.HandleFunc("PUT /stores/{id}", handler.UpdateStore)
+
+	mux.HandleFunc("GET /shelf-lives", handler.ListShelfLives)
+	mux.HandleFunc("PUT /shelf-lives/{type}", handler.SetShelfLife)
+	mux.HandleFunc("DELETE /shelf-lives/{type}", handler.DeleteShelfLife)
+	mux.HandleFunc("GET /inventory/expiring", handler.ListExpiring)
+	mux.HandleFunc("GET /inventory/write-offs", handler.ListWriteOffs)
+	mux.HandleFunc("POST /inventory/write-offs", handler.WriteOffExpired)
+	mux.HandleFunc("GET /inventory/low-stock", handler.ListLowStock)
+	mux.HandleFunc("GET /inventory/reorder-suggestions", handler.SuggestReorders)
+
+	mux.HandleFunc("POST /stocktakes", handler.OpenStocktake)
+	mux.HandleFunc("GET /stocktakes/{id}", handler.GetStocktake)
+	mux.HandleFunc("PUT /stocktakes/{id}/counts", handler.SubmitStocktakeCounts)
+	mux.HandleFunc("POST /stocktakes/{id}/finalize", handler.FinalizeStocktake)
+
+	mux.HandleFunc("POST /shifts", handler.OpenShift)
+	mux.HandleFunc("POST /shifts/{id}/close", handler.CloseShift)
+	mux.HandleFunc("GET /shifts/{id}/report", handler.GetShiftReport)
 
 	loggingMiddleware := middleware.RequestLogger(mux)
 
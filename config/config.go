@@ -1,7 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -16,6 +19,22 @@ type Config struct {
 	DBName        string
 	RedisHost     string
 	RedisPassword string
+
+	// PointsExpiryMonths is how long earned points stay spendable; 0 disables expiry
+	PointsExpiryMonths        int
+	PointsExpiryWarningDays   int
+	PointsExpirySweepInterval time.Duration
+
+	// TierRecalcAt is the local time of day of the nightly tier recalculation; only its
+	// hour and minute are set
+	TierRecalcAt time.Time
+
+	// StoreName, StoreAddress and StoreTaxID (NPWP) head every receipt
+	StoreName    string
+	StoreAddress string
+	StoreTaxID   string
+	// ReceiptWidth is the thermal printer's characters per line: 32 for 58 mm paper, 48 for 80 mm
+	ReceiptWidth int
 }
 
 func LoadConfig() (*Config, error) {
@@ -30,6 +49,31 @@ func LoadConfig() (*Config, error) {
 		DBName:        getEnv("DB_NAME", "bsnack_db"),
 		RedisHost:     getEnv("REDIS_HOST", "localhost:6379"),
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
+		StoreName:     getEnv("STORE_NAME", "BSNACK"),
+		StoreAddress:  getEnv("STORE_ADDRESS", ""),
+		StoreTaxID:    getEnv("STORE_TAX_ID", ""),
+	}
+
+	var err error
+	if cfg.PointsExpiryMonths, err = getEnvInt("POINTS_EXPIRY_MONTHS", 12); err != nil {
+		return nil, err
+	}
+	if cfg.PointsExpiryWarningDays, err = getEnvInt("POINTS_EXPIRY_WARNING_DAYS", 30); err != nil {
+		return nil, err
+	}
+	if cfg.PointsExpirySweepInterval, err = getEnvDuration("POINTS_EXPIRY_SWEEP_INTERVAL", time.Hour); err != nil {
+		return nil, err
+	}
+
+	if cfg.ReceiptWidth, err = getEnvInt("RECEIPT_WIDTH", 32); err != nil {
+		return nil, err
+	}
+	if cfg.ReceiptWidth < 24 {
+		return nil, fmt.Errorf("RECEIPT_WIDTH must be at least 24, got %d", cfg.ReceiptWidth)
+	}
+
+	if cfg.TierRecalcAt, err = getEnvClock("TIER_RECALC_AT", "02:00"); err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
@@ -41,3 +85,36 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) (int, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer, got %q", key, value)
+	}
+	return n, nil
+}
+
+func getEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration such as 1h, got %q", key, value)
+	}
+	return d, nil
+}
+
+func getEnvClock(key, fallback string) (time.Time, error) {
+	value := getEnv(key, fallback)
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a time such as 02:00, got %q", key, value)
+	}
+	return t, nil
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Batch is the stock of one production run of a product received at a store
+type Batch struct {
+	ID                int64     `json:"id"`
+	StoreID           int64     `json:"store_id"`
+	ProductID         int64     `json:"product_id"`
+	Lot               string    `json:"lot"`
+	ManufacturingDate string    `json:"manufacturing_date"`    // YYYY-MM-DD
+	ExpiryDate        *string   `json:"expiry_date,omitempty"` // YYYY-MM-DD
+	Quantity          int       `json:"quantity"`
+	ReceivedAt        time.Time `json:"received_at"`
+}
+
+// DefaultLot is the lot of a batch received without one: its manufacturing date as YYYYMMDD
+func DefaultLot(manufacturingDate string) string {
+	return strings.ReplaceAll(manufacturingDate, "-", "")
+}
+
+// AdjustmentLot is the lot that stock found by a correction goes into. It never has an
+// expiry date of its own, so it cannot clash with a run received under the default lot
+// with one.
+func AdjustmentLot(manufacturingDate string) string {
+	return DefaultLot(manufacturingDate) + "-ADJ"
+}
+
+// BatchAllocation is the quantity a transaction line or redemption took from one batch.
+// On refunds it is negative: the quantity put back.
+type BatchAllocation struct {
+	BatchID           int64   `json:"batch_id"`
+	Lot               string  `json:"lot"`
+	ManufacturingDate string  `json:"manufacturing_date"`
+	ExpiryDate        *string `json:"expiry_date,omitempty"`
+	Quantity          int     `json:"quantity"`
+}
+
+// ReturnBatches picks the batches a refund of quantity units puts stock back into,
+// given the allocations of the sale and the units refunded before. The batch taken
+// from last is refilled first, so each refund carries on where the previous one stopped.
+func ReturnBatches(sold []BatchAllocation, refunded, quantity int) []BatchAllocation {
+	var returns []BatchAllocation
+	for i := len(sold) - 1; i >= 0 && quantity > 0; i-- {
+		a := sold[i]
+		skip := min(refunded, a.Quantity)
+		refunded -= skip
+		n := min(a.Quantity-skip, quantity)
+		if n == 0 {
+			continue
+		}
+		a.Quantity = n
+		returns = append(returns, a)
+		quantity -= n
+	}
+	return returns
+}
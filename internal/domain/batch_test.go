@@ -0,0 +1,20 @@
+package domain_test
+
+import (
+	"bsnack/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReturnBatches(t *testing.T) {
+	sold := []domain.BatchAllocation{{BatchID: 1, Quantity: 2}, {BatchID: 2, Quantity: 3}}
+
+	// the batch taken from last is refilled first
+	assert.Equal(t, []domain.BatchAllocation{{BatchID: 2, Quantity: 2}}, domain.ReturnBatches(sold, 0, 2))
+	// a later refund carries on where that one stopped
+	assert.Equal(t, []domain.BatchAllocation{{BatchID: 2, Quantity: 1}, {BatchID: 1, Quantity: 2}},
+		domain.ReturnBatches(sold, 2, 3))
+	assert.Equal(t, []domain.BatchAllocation{{BatchID: 1, Quantity: 1}}, domain.ReturnBatches(sold, 3, 1))
+	assert.Empty(t, domain.ReturnBatches(sold, 5, 1))
+}
@@ -3,9 +3,39 @@ package domain
 import "time"
 
 type Customer struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	Points    int       `json:"points"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           int64           `json:"id"`
+	Name         string          `json:"name"`
+	Phone        *string         `json:"phone,omitempty"`
+	Email        *string         `json:"email,omitempty"`
+	Points       int             `json:"points"`
+	Tier         CustomerTier    `json:"tier"`
+	ExpiringSoon *ExpiringPoints `json:"expiring_soon,omitempty"` // only set when a single customer is fetched
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+type CustomerTier string
+
+const (
+	TierBronze CustomerTier = "Bronze"
+	TierSilver CustomerTier = "Silver"
+	TierGold   CustomerTier = "Gold"
+)
+
+func (t CustomerTier) IsValid() bool {
+	switch t {
+	case TierBronze, TierSilver, TierGold:
+		return true
+	}
+	return false
+}
+
+// TierEvent records a customer moving between tiers and the spend that caused it
+type TierEvent struct {
+	ID           int64        `json:"id"`
+	CustomerID   int64        `json:"customer_id"`
+	FromTier     CustomerTier `json:"from_tier"`
+	ToTier       CustomerTier `json:"to_tier"`
+	RollingSpend Money        `json:"rolling_spend"`
+	CreatedAt    time.Time    `json:"created_at"`
 }
@@ -0,0 +1,35 @@
+package domain
+
+import "errors"
+
+// Sentinel error kinds. Match them with errors.Is; the handler maps each kind
+// to an HTTP status and a machine-readable code.
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrValidation         = errors.New("validation failed")
+	ErrConflict           = errors.New("conflict")
+	ErrInsufficientStock  = errors.New("insufficient stock")
+	ErrExpiredStock       = errors.New("stock past its expiry date cannot be sold")
+	ErrInsufficientPoints = errors.New("insufficient points")
+)
+
+// Error carries a caller-facing message while unwrapping to one of the sentinel kinds
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string { return e.Message }
+func (e *Error) Unwrap() error { return e.Kind }
+
+func NewNotFoundError(message string) error {
+	return &Error{Kind: ErrNotFound, Message: message}
+}
+
+func NewValidationError(message string) error {
+	return &Error{Kind: ErrValidation, Message: message}
+}
+
+func NewConflictError(message string) error {
+	return &Error{Kind: ErrConflict, Message: message}
+}
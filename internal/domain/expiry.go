@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+// ShelfLife is how many days snacks of a product type keep after they are made
+type ShelfLife struct {
+	ProductType string    `json:"product_type"`
+	Days        int       `json:"days"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ExpiringBatch is a batch in stock that expires within the window asked for, or
+// already has
+type ExpiringBatch struct {
+	Batch
+	ProductName   string      `json:"product_name"`
+	ProductType   string      `json:"product_type"`
+	ProductFlavor string      `json:"product_flavor"`
+	ProductSize   ProductSize `json:"product_size"`
+	DaysLeft      int         `json:"days_left"` // 0 on the expiry date, negative once expired
+}
+
+// ExpiryFilter narrows GET /inventory/expiring; a nil StoreID covers every store
+type ExpiryFilter struct {
+	WithinDays int
+	StoreID    *int64
+}
+
+// WriteOff is the audit record of expired units taken out of a batch
+type WriteOff struct {
+	ID         int64     `json:"id"`
+	BatchID    int64     `json:"batch_id"`
+	StoreID    int64     `json:"store_id"`
+	ProductID  int64     `json:"product_id"`
+	Lot        string    `json:"lot"`
+	ExpiryDate string    `json:"expiry_date"`
+	Quantity   int       `json:"quantity"`
+	Reason     string    `json:"reason"`
+	Actor      string    `json:"actor"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WriteOffFilter narrows the write-off listing; zero values mean "no filter"
+type WriteOffFilter struct {
+	StartDate string // YYYY-MM-DD, inclusive
+	EndDate   string // YYYY-MM-DD, inclusive
+	StoreID   int64
+}
@@ -0,0 +1,214 @@
+package domain
+
+import (
+	"math/big"
+	"time"
+)
+
+// PointFractions is the number of accrual units in one loyalty point. Sales accrue
+// fractional points so lines earning at different rates are rounded down only once,
+// and refunds can claw back against what was accrued rather than today's rules.
+// At the default 1 point per Rp 1,000 one sen is 10 units, so no spend is lost.
+const PointFractions = 1_000_000
+
+// PointsFromUnits converts accrued units into whole points, rounding down
+func PointsFromUnits(units int64) int {
+	if units < 0 {
+		return -int((-units + PointFractions - 1) / PointFractions)
+	}
+	return int(units / PointFractions)
+}
+
+type LoyaltyRuleKind string
+
+const (
+	RuleEarn   LoyaltyRuleKind = "earn"
+	RuleBonus  LoyaltyRuleKind = "bonus"
+	RuleRedeem LoyaltyRuleKind = "redeem"
+)
+
+func (k LoyaltyRuleKind) IsValid() bool {
+	switch k {
+	case RuleEarn, RuleBonus, RuleRedeem:
+		return true
+	}
+	return false
+}
+
+// EarnRule sets how much spend earns one point. A nil ProductType is the default
+// for product types without a rule of their own.
+type EarnRule struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	ProductType   *string   `json:"product_type,omitempty"`
+	SpendPerPoint Money     `json:"spend_per_point"`
+	Active        bool      `json:"active"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// BonusRule multiplies earned points between two dates (inclusive), optionally only on
+// some weekdays and for one product type. When several apply the highest one wins.
+type BonusRule struct {
+	ID                int64          `json:"id"`
+	Name              string         `json:"name"`
+	ProductType       *string        `json:"product_type,omitempty"`
+	MultiplierPercent int            `json:"multiplier_percent"` // 200 doubles the points
+	StartDate         string         `json:"start_date"`         // YYYY-MM-DD
+	EndDate           string         `json:"end_date"`           // YYYY-MM-DD
+	Weekdays          []time.Weekday `json:"weekdays,omitempty"` // 0 = Sunday; empty means every day
+	Active            bool           `json:"active"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+}
+
+func (b *BonusRule) appliesOn(at time.Time) bool {
+	day := at.Format("2006-01-02")
+	if day < b.StartDate || day > b.EndDate {
+		return false
+	}
+	if len(b.Weekdays) == 0 {
+		return true
+	}
+	for _, wd := range b.Weekdays {
+		if wd == at.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// RedeemRule prices a redemption either for one product or for every product of a size.
+// Exactly one of ProductID and ProductSize is set; a product rule overrides its size rule.
+type RedeemRule struct {
+	ID          int64        `json:"id"`
+	Name        string       `json:"name"`
+	ProductID   *int64       `json:"product_id,omitempty"`
+	ProductSize *ProductSize `json:"product_size,omitempty"`
+	Points      int          `json:"points"`
+	Active      bool         `json:"active"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// TierRule is the rolling 12-month spend that qualifies for a tier and its earn multiplier
+type TierRule struct {
+	Tier              CustomerTier `json:"tier"`
+	MinSpend          Money        `json:"min_spend"`
+	MultiplierPercent int          `json:"multiplier_percent"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+}
+
+// LoyaltyRules is a rule set as read at transaction time
+type LoyaltyRules struct {
+	Earn   []EarnRule   `json:"earn"`
+	Bonus  []BonusRule  `json:"bonus"`
+	Redeem []RedeemRule `json:"redeem"`
+	Tiers  []TierRule   `json:"tiers"`
+}
+
+// Accrual is what a sale earned and which rules applied
+type Accrual struct {
+	Units       int64
+	EarnRuleID  *int64
+	BonusRuleID *int64
+}
+
+// Accrue applies the earn rule for the product type, falling back to the default rule,
+// the best bonus in force at the given time and the customer's tier multiplier.
+// No matching earn rule earns nothing.
+func (r *LoyaltyRules) Accrue(total Money, productType string, tier CustomerTier, at time.Time) Accrual {
+	earn := r.earnRuleFor(productType)
+	if earn == nil || total <= 0 {
+		return Accrual{}
+	}
+
+	acc := Accrual{EarnRuleID: &earn.ID}
+	bonusPercent := int64(100)
+	if bonus := r.bonusFor(productType, at); bonus != nil {
+		acc.BonusRuleID = &bonus.ID
+		bonusPercent = int64(bonus.MultiplierPercent)
+	}
+	tierPercent := int64(100)
+	if t := r.tierRule(tier); t != nil {
+		tierPercent = int64(t.MultiplierPercent)
+	}
+
+	// total * bonus/100 * tier/100 * PointFractions / spend can exceed int64 on large sales
+	units := new(big.Int).Mul(big.NewInt(int64(total)), big.NewInt(bonusPercent*tierPercent*PointFractions))
+	units.Quo(units, big.NewInt(int64(earn.SpendPerPoint)*100*100))
+	acc.Units = units.Int64()
+	return acc
+}
+
+// TierFor returns the highest tier the spend qualifies for; Bronze when none is configured
+func (r *LoyaltyRules) TierFor(spend Money) CustomerTier {
+	tier := TierBronze
+	var best Money = -1
+	for _, t := range r.Tiers {
+		if spend >= t.MinSpend && t.MinSpend > best {
+			tier, best = t.Tier, t.MinSpend
+		}
+	}
+	return tier
+}
+
+func (r *LoyaltyRules) tierRule(tier CustomerTier) *TierRule {
+	for i := range r.Tiers {
+		if r.Tiers[i].Tier == tier {
+			return &r.Tiers[i]
+		}
+	}
+	return nil
+}
+
+func (r *LoyaltyRules) earnRuleFor(productType string) *EarnRule {
+	var fallback *EarnRule
+	for i := range r.Earn {
+		rule := &r.Earn[i]
+		if !rule.Active {
+			continue
+		}
+		if rule.ProductType == nil {
+			fallback = rule
+		} else if *rule.ProductType == productType {
+			return rule
+		}
+	}
+	return fallback
+}
+
+func (r *LoyaltyRules) bonusFor(productType string, at time.Time) *BonusRule {
+	var best *BonusRule
+	for i := range r.Bonus {
+		rule := &r.Bonus[i]
+		if !rule.Active || !rule.appliesOn(at) {
+			continue
+		}
+		if rule.ProductType != nil && *rule.ProductType != productType {
+			continue
+		}
+		if best == nil || rule.MultiplierPercent > best.MultiplierPercent {
+			best = rule
+		}
+	}
+	return best
+}
+
+// RedeemRuleFor returns the rule pricing a redemption of p, or nil if it cannot be redeemed
+func (r *LoyaltyRules) RedeemRuleFor(p *Product) *RedeemRule {
+	var bySize *RedeemRule
+	for i := range r.Redeem {
+		rule := &r.Redeem[i]
+		if !rule.Active {
+			continue
+		}
+		if rule.ProductID != nil && *rule.ProductID == p.ID {
+			return rule
+		}
+		if rule.ProductSize != nil && *rule.ProductSize == p.Size {
+			bySize = rule
+		}
+	}
+	return bySize
+}
@@ -0,0 +1,118 @@
+package domain_test
+
+import (
+	"bsnack/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func strPtr(s string) *string { return &s }
+
+func testRules() *domain.LoyaltyRules {
+	small := domain.SizeSmall
+	giftID := int64(42)
+	return &domain.LoyaltyRules{
+		Earn: []domain.EarnRule{
+			{ID: 1, SpendPerPoint: domain.NewMoney(1000), Active: true},
+			{ID: 2, ProductType: strPtr("Keripik Pangsit"), SpendPerPoint: domain.NewMoney(500), Active: true},
+			{ID: 3, ProductType: strPtr("Makaroni"), SpendPerPoint: domain.NewMoney(100), Active: false},
+		},
+		Bonus: []domain.BonusRule{
+			{ID: 10, MultiplierPercent: 200, StartDate: "2025-12-01", EndDate: "2025-12-31",
+				Weekdays: []time.Weekday{time.Saturday, time.Sunday}, Active: true},
+			{ID: 11, ProductType: strPtr("Keripik Pangsit"), MultiplierPercent: 300,
+				StartDate: "2025-12-25", EndDate: "2025-12-25", Active: true},
+		},
+		Redeem: []domain.RedeemRule{
+			{ID: 20, ProductSize: &small, Points: 200, Active: true},
+			{ID: 21, ProductID: &giftID, Points: 50, Active: true},
+		},
+	}
+}
+
+func TestAccrue_EarnRulePerType(t *testing.T) {
+	rules := testRules()
+	weekday := time.Date(2025, 11, 5, 10, 0, 0, 0, time.UTC)
+
+	acc := rules.Accrue(domain.NewMoney(4500), "Makaroni", domain.TierBronze, weekday)
+	assert.Equal(t, 4, domain.PointsFromUnits(acc.Units), "inactive type rule falls back to the default")
+	assert.Equal(t, int64(1), *acc.EarnRuleID)
+	assert.Nil(t, acc.BonusRuleID)
+
+	acc = rules.Accrue(domain.NewMoney(4500), "Keripik Pangsit", domain.TierBronze, weekday)
+	assert.Equal(t, 9, domain.PointsFromUnits(acc.Units))
+	assert.Equal(t, int64(2), *acc.EarnRuleID)
+}
+
+func TestAccrue_BonusMultipliers(t *testing.T) {
+	rules := testRules()
+	saturday := time.Date(2025, 12, 6, 10, 0, 0, 0, time.UTC)
+	christmas := time.Date(2025, 12, 25, 10, 0, 0, 0, time.UTC) // a Thursday
+
+	acc := rules.Accrue(domain.NewMoney(1000), "Makaroni", domain.TierBronze, saturday)
+	assert.Equal(t, 2, domain.PointsFromUnits(acc.Units))
+	assert.Equal(t, int64(10), *acc.BonusRuleID)
+
+	acc = rules.Accrue(domain.NewMoney(1000), "Makaroni", domain.TierBronze, christmas)
+	assert.Equal(t, 1, domain.PointsFromUnits(acc.Units), "weekend bonus does not apply on a Thursday")
+
+	acc = rules.Accrue(domain.NewMoney(1000), "Keripik Pangsit", domain.TierBronze, christmas)
+	assert.Equal(t, 6, domain.PointsFromUnits(acc.Units))
+	assert.Equal(t, int64(11), *acc.BonusRuleID)
+}
+
+func TestAccrue_NoEarnRule(t *testing.T) {
+	acc := (&domain.LoyaltyRules{}).Accrue(domain.NewMoney(50000), "Makaroni", domain.TierBronze, time.Now())
+	assert.Zero(t, acc.Units)
+	assert.Nil(t, acc.EarnRuleID)
+}
+
+func testTiers() []domain.TierRule {
+	return []domain.TierRule{
+		{Tier: domain.TierBronze, MinSpend: 0, MultiplierPercent: 100},
+		{Tier: domain.TierSilver, MinSpend: domain.NewMoney(1_000_000), MultiplierPercent: 125},
+		{Tier: domain.TierGold, MinSpend: domain.NewMoney(5_000_000), MultiplierPercent: 150},
+	}
+}
+
+func TestAccrue_TierMultiplier(t *testing.T) {
+	rules := testRules()
+	rules.Tiers = testTiers()
+	saturday := time.Date(2025, 12, 6, 10, 0, 0, 0, time.UTC)
+
+	acc := rules.Accrue(domain.NewMoney(10000), "Makaroni", domain.TierGold, saturday)
+	assert.Equal(t, 30, domain.PointsFromUnits(acc.Units), "tier stacks on top of the bonus")
+
+	acc = rules.Accrue(domain.NewMoney(10000), "Makaroni", domain.CustomerTier("Platinum"), saturday)
+	assert.Equal(t, 20, domain.PointsFromUnits(acc.Units), "unknown tier earns at the base rate")
+}
+
+func TestTierFor(t *testing.T) {
+	rules := &domain.LoyaltyRules{Tiers: testTiers()}
+
+	assert.Equal(t, domain.TierBronze, rules.TierFor(0))
+	assert.Equal(t, domain.TierBronze, rules.TierFor(domain.NewMoney(999_999)))
+	assert.Equal(t, domain.TierSilver, rules.TierFor(domain.NewMoney(1_000_000)))
+	assert.Equal(t, domain.TierGold, rules.TierFor(domain.NewMoney(7_500_000)))
+	assert.Equal(t, domain.TierBronze, (&domain.LoyaltyRules{}).TierFor(domain.NewMoney(7_500_000)))
+}
+
+func TestRedeemRuleFor(t *testing.T) {
+	rules := testRules()
+
+	rule := rules.RedeemRuleFor(&domain.Product{ID: 42, Size: domain.SizeSmall})
+	assert.Equal(t, 50, rule.Points, "product rule overrides the size default")
+
+	rule = rules.RedeemRuleFor(&domain.Product{ID: 7, Size: domain.SizeSmall})
+	assert.Equal(t, 200, rule.Points)
+
+	assert.Nil(t, rules.RedeemRuleFor(&domain.Product{ID: 7, Size: domain.SizeLarge}))
+}
+
+func TestPointsFromUnits(t *testing.T) {
+	assert.Equal(t, 0, domain.PointsFromUnits(999_999))
+	assert.Equal(t, 1, domain.PointsFromUnits(1_000_000))
+	assert.Equal(t, -1, domain.PointsFromUnits(-1))
+}
@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount in minor units (1/100 Rupiah), matching NUMERIC(15, 2).
+// It is encoded in JSON as a decimal string such as "15000.00".
+type Money int64
+
+const moneyScale = 100
+
+// NewMoney builds Money from whole Rupiah
+func NewMoney(rupiah int64) Money {
+	return Money(rupiah * moneyScale)
+}
+
+// ParseMoney parses a decimal string with at most two fractional digits
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("empty money value")
+	}
+
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid money value %q", s)
+	}
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("money value %q has more than 2 decimal places", s)
+	}
+	for _, part := range []string{whole, frac} {
+		for _, r := range part {
+			if r < '0' || r > '9' {
+				return 0, fmt.Errorf("invalid money value %q", s)
+			}
+		}
+	}
+
+	var units int64
+	if whole != "" {
+		w, err := strconv.ParseInt(whole, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid money value %q: %w", s, err)
+		}
+		units = w * moneyScale
+	}
+	if frac != "" {
+		f, _ := strconv.ParseInt((frac + "0")[:2], 10, 64)
+		units += f
+	}
+
+	if neg {
+		units = -units
+	}
+	return Money(units), nil
+}
+
+// Mul multiplies the amount by a quantity
+func (m Money) Mul(qty int) Money {
+	return m * Money(qty)
+}
+
+// MulDiv returns m * num / den rounded half away from zero, used for pro-rata amounts
+func (m Money) MulDiv(num, den int) Money {
+	p := int64(m) * int64(num)
+	d := int64(den)
+	if d < 0 {
+		p, d = -p, -d
+	}
+	q, r := p/d, p%d
+	if r < 0 {
+		r = -r
+	}
+	if 2*r >= d {
+		if p < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return Money(q)
+}
+
+// FloorDiv returns how many whole units fit into m, rounding towards negative infinity
+func (m Money) FloorDiv(unit Money) int64 {
+	q := int64(m) / int64(unit)
+	if (m%unit != 0) && ((m < 0) != (unit < 0)) {
+		q--
+	}
+	return q
+}
+
+func (m Money) String() string {
+	sign := ""
+	units := int64(m)
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, units/moneyScale, units%moneyScale)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON accepts both a decimal string and a bare JSON number.
+// Numbers are parsed from their literal text so no float rounding happens.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	v, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Scan reads a Postgres NUMERIC, which lib/pq returns as text
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	case int64:
+		*m = NewMoney(v)
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+}
+
+func (m *Money) scanString(s string) error {
+	v, err := ParseMoney(trimTrailingZeros(s))
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Value writes the amount as a decimal literal for NUMERIC columns
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// trimTrailingZeros drops insignificant fractional zeros, e.g. a SUM with scale 4
+func trimTrailingZeros(s string) string {
+	if !strings.Contains(s, ".") {
+		return s
+	}
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
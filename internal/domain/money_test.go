@@ -0,0 +1,97 @@
+package domain_test
+
+import (
+	"bsnack/internal/domain"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := []struct {
+		in   string
+		want domain.Money
+	}{
+		{"15000", 1500000},
+		{"15000.5", 1500050},
+		{"15000.05", 1500005},
+		{"0.01", 1},
+		{".5", 50},
+		{"-1500.10", -150010},
+	}
+	for _, tc := range cases {
+		got, err := domain.ParseMoney(tc.in)
+		assert.NoError(t, err, tc.in)
+		assert.Equal(t, tc.want, got, tc.in)
+	}
+
+	for _, bad := range []string{"", "-", "1.005", "1,000", "abc", "1e3"} {
+		_, err := domain.ParseMoney(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "0.00", domain.Money(0).String())
+	assert.Equal(t, "0.05", domain.Money(5).String())
+	assert.Equal(t, "15000.00", domain.NewMoney(15000).String())
+	assert.Equal(t, "-0.50", domain.Money(-50).String())
+}
+
+func TestMoney_MulDivRoundsHalfAwayFromZero(t *testing.T) {
+	// 1 of 3 units of 10.00 is 3.333.. -> 3.33
+	assert.Equal(t, domain.Money(333), domain.NewMoney(10).MulDiv(1, 3))
+	// 2 of 3 units is 6.666.. -> 6.67
+	assert.Equal(t, domain.Money(667), domain.NewMoney(10).MulDiv(2, 3))
+	// exact half rounds away from zero
+	assert.Equal(t, domain.Money(1), domain.Money(1).MulDiv(1, 2))
+	assert.Equal(t, domain.Money(-1), domain.Money(-1).MulDiv(1, 2))
+	assert.Equal(t, domain.Money(-667), domain.NewMoney(-10).MulDiv(2, 3))
+}
+
+func TestMoney_FloorDiv(t *testing.T) {
+	unit := domain.NewMoney(1000)
+	assert.Equal(t, int64(0), domain.Money(99999).FloorDiv(unit))
+	assert.Equal(t, int64(1), domain.Money(100000).FloorDiv(unit))
+	assert.Equal(t, int64(1), domain.Money(199999).FloorDiv(unit))
+	assert.Equal(t, int64(-1), domain.Money(-1).FloorDiv(unit))
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(domain.Product{Price: domain.Money(1050)})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"price":"10.50"`)
+
+	var p domain.Product
+	assert.NoError(t, json.Unmarshal([]byte(`{"price": 10000}`), &p))
+	assert.Equal(t, domain.NewMoney(10000), p.Price)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"price": "999.99"}`), &p))
+	assert.Equal(t, domain.Money(99999), p.Price)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"price": 0.001}`), &p))
+
+	// cache round trip must be lossless
+	report := domain.SalesReport{TotalIncome: domain.Money(123456789)}
+	data, err = json.Marshal(report)
+	assert.NoError(t, err)
+	var decoded domain.SalesReport
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, report.TotalIncome, decoded.TotalIncome)
+}
+
+func TestMoney_Scan(t *testing.T) {
+	var m domain.Money
+	assert.NoError(t, m.Scan([]byte("4500.00")))
+	assert.Equal(t, domain.NewMoney(4500), m)
+
+	// aggregates may come back with a wider scale
+	assert.NoError(t, m.Scan([]byte("1500.1000")))
+	assert.Equal(t, domain.Money(150010), m)
+
+	assert.NoError(t, m.Scan([]byte("0")))
+	assert.Equal(t, domain.Money(0), m)
+
+	assert.Error(t, m.Scan([]byte("1.005")))
+}
@@ -0,0 +1,59 @@
+package domain
+
+import "time"
+
+// MovementKind is what changed a product's stock
+type MovementKind string
+
+const (
+	MovementOpening    MovementKind = "opening" // stock held before movements were recorded
+	MovementSale       MovementKind = "sale"
+	MovementRedemption MovementKind = "redemption"
+	MovementRefund     MovementKind = "refund"
+	MovementRestock    MovementKind = "restock"
+	MovementAdjustment MovementKind = "adjustment"
+	MovementWriteOff   MovementKind = "write_off"
+)
+
+// StockMovement is one change to the stock of a batch. Quantity is positive for stock
+// added and negative for stock taken; the movements of a product at a store add up to
+// its quantity there.
+type StockMovement struct {
+	ID        int64        `json:"id"`
+	StoreID   int64        `json:"store_id"`
+	ProductID int64        `json:"product_id"`
+	BatchID   int64        `json:"batch_id"`
+	Lot       string       `json:"lot"`
+	Kind      MovementKind `json:"kind"`
+	Quantity  int          `json:"quantity"`
+	Balance   int          `json:"balance"` // stock of the product at the store after the movement
+	Reason    string       `json:"reason,omitempty"`
+	Actor     string       `json:"actor,omitempty"`
+	Reference string       `json:"reference,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// MovementFilter narrows a product's movements; the zero value of each field matches all
+type MovementFilter struct {
+	ProductID int64
+	StoreID   *int64
+	Kind      MovementKind
+	StartDate string
+	EndDate   string
+}
+
+// TakenMovements records the stock a sale, redemption or adjustment took from each
+// batch. Refund allocations are negative, so they come out as stock put back.
+func TakenMovements(kind MovementKind, batches []BatchAllocation, reference string) []StockMovement {
+	movements := make([]StockMovement, 0, len(batches))
+	for _, a := range batches {
+		movements = append(movements, StockMovement{
+			BatchID:   a.BatchID,
+			Lot:       a.Lot,
+			Kind:      kind,
+			Quantity:  -a.Quantity,
+			Reference: reference,
+		})
+	}
+	return movements
+}
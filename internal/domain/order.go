@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Order is the header of a multi-line checkout; each line is a Transaction
+type Order struct {
+	ID            uuid.UUID     `json:"id"`
+	CustomerID    int64         `json:"customer_id"`
+	CustomerName  string        `json:"customer_name"`
+	TotalQuantity int           `json:"total_quantity"`
+	Subtotal      Money         `json:"subtotal"`
+	DiscountTotal Money         `json:"discount_total"`
+	NetAmount     Money         `json:"net_amount"`
+	TaxAmount     Money         `json:"tax_amount"`
+	TotalPrice    Money         `json:"total_price"` // gross paid
+	Payments      []Payment     `json:"payments,omitempty"`
+	PointsEarned  int           `json:"points_earned"`
+	PointsBalance *int          `json:"points_balance,omitempty"` // the customer's balance right after the sale
+	StoreID       int64         `json:"store_id"`
+	ShiftID       *int64        `json:"shift_id,omitempty"`
+	Cashier       string        `json:"cashier,omitempty"`
+	OrderDate     time.Time     `json:"order_date"`
+	Lines         []Transaction `json:"lines"`
+}
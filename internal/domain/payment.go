@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PaymentMethod string
+
+const (
+	PaymentCash    PaymentMethod = "cash"
+	PaymentCard    PaymentMethod = "card"
+	PaymentQRIS    PaymentMethod = "qris"
+	PaymentEWallet PaymentMethod = "ewallet"
+)
+
+func (m PaymentMethod) IsValid() bool {
+	switch m {
+	case PaymentCash, PaymentCard, PaymentQRIS, PaymentEWallet:
+		return true
+	}
+	return false
+}
+
+// NeedsReference reports whether the method must carry the provider's reference
+func (m PaymentMethod) NeedsReference() bool {
+	return m == PaymentQRIS || m == PaymentEWallet
+}
+
+// Payment is one tender against a purchase or an order. Amount is what it pays
+// towards the sale; for cash, Tendered is what was handed over and Change what was
+// given back. Refunds are paid out as negative cash payments.
+type Payment struct {
+	ID            int64         `json:"id"`
+	TransactionID *uuid.UUID    `json:"transaction_id,omitempty"`
+	OrderID       *uuid.UUID    `json:"order_id,omitempty"`
+	Method        PaymentMethod `json:"method"`
+	Amount        Money         `json:"amount"`
+	Tendered      Money         `json:"tendered"`
+	Change        Money         `json:"change"`
+	Reference     string        `json:"reference,omitempty"` // card approval code, QRIS or e-wallet reference
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// PaymentTotal sums a report's payments by method, for reconciling the till
+type PaymentTotal struct {
+	Method   PaymentMethod `json:"method"`
+	Count    int           `json:"count"`
+	Amount   Money         `json:"amount"`
+	Tendered Money         `json:"tendered"`
+	Change   Money         `json:"change"`
+}
+
+// SettlePayments checks the tenders cover total and works out the change. Card, QRIS
+// and e-wallet payments are charged exactly, so only cash can go over what is due;
+// change comes out of the last cash tenders first.
+func SettlePayments(total Money, tenders []Payment) ([]Payment, error) {
+	var cash, other Money
+	for _, p := range tenders {
+		if p.Method == PaymentCash {
+			cash += p.Tendered
+		} else {
+			other += p.Tendered
+		}
+	}
+	if other > total {
+		return nil, NewValidationError("card, QRIS and e-wallet payments must not exceed the total")
+	}
+	if cash+other < total {
+		return nil, NewValidationError("payments do not cover the total")
+	}
+
+	payments := make([]Payment, len(tenders))
+	change := cash + other - total
+	for i := len(tenders) - 1; i >= 0; i-- {
+		p := tenders[i]
+		p.Amount = p.Tendered
+		if p.Method == PaymentCash {
+			p.Change = min(change, p.Tendered)
+			p.Amount -= p.Change
+			change -= p.Change
+		}
+		payments[i] = p
+	}
+	return payments, nil
+}
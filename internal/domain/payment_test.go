@@ -0,0 +1,41 @@
+package domain_test
+
+import (
+	"bsnack/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettlePayments_SplitWithChange(t *testing.T) {
+	payments, err := domain.SettlePayments(domain.NewMoney(48000), []domain.Payment{
+		{Method: domain.PaymentQRIS, Tendered: domain.NewMoney(20000), Reference: "QR-1"},
+		{Method: domain.PaymentCash, Tendered: domain.NewMoney(50000)},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(20000), payments[0].Amount)
+	assert.Equal(t, domain.NewMoney(28000), payments[1].Amount)
+	assert.Equal(t, domain.NewMoney(22000), payments[1].Change)
+}
+
+func TestSettlePayments_ChangeFromLastCashFirst(t *testing.T) {
+	payments, err := domain.SettlePayments(domain.NewMoney(12000), []domain.Payment{
+		{Method: domain.PaymentCash, Tendered: domain.NewMoney(10000)},
+		{Method: domain.PaymentCash, Tendered: domain.NewMoney(5000)},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(10000), payments[0].Amount)
+	assert.Equal(t, domain.NewMoney(2000), payments[1].Amount)
+	assert.Equal(t, domain.NewMoney(3000), payments[1].Change)
+}
+
+func TestSettlePayments_Rejects(t *testing.T) {
+	_, err := domain.SettlePayments(domain.NewMoney(10000), []domain.Payment{{Method: domain.PaymentCash, Tendered: domain.NewMoney(5000)}})
+	assert.EqualError(t, err, "payments do not cover the total")
+
+	// a card is charged exactly, so it cannot leave change
+	_, err = domain.SettlePayments(domain.NewMoney(10000), []domain.Payment{{Method: domain.PaymentCard, Tendered: domain.NewMoney(15000)}})
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PointsEntryType string
+
+const (
+	PointsEarn       PointsEntryType = "earn"
+	PointsRedeem     PointsEntryType = "redeem"
+	PointsRefund     PointsEntryType = "refund"
+	PointsAdjustment PointsEntryType = "adjustment"
+	PointsExpire     PointsEntryType = "expire"
+)
+
+// PointsEntry is one signed movement in a customer's loyalty points ledger.
+// Positive entries are lots: Remaining is what has not been spent or expired yet,
+// and negative entries consume lots oldest-expiry first.
+type PointsEntry struct {
+	ID            int64           `json:"id"`
+	CustomerID    int64           `json:"customer_id"`
+	Type          PointsEntryType `json:"type"`
+	Points        int             `json:"points"`
+	TransactionID *uuid.UUID      `json:"transaction_id,omitempty"`
+	OrderID       *uuid.UUID      `json:"order_id,omitempty"`
+	RedeemRuleID  *int64          `json:"redeem_rule_id,omitempty"`
+	Reason        string          `json:"reason,omitempty"`
+	Remaining     int             `json:"remaining,omitempty"`
+	ExpiresAt     *time.Time      `json:"expires_at,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// PointsHistory compares the stored balance with the one derived from the ledger
+type PointsHistory struct {
+	CustomerID    int64           `json:"customer_id"`
+	Balance       int             `json:"balance"`
+	LedgerBalance int             `json:"ledger_balance"`
+	Consistent    bool            `json:"consistent"`
+	ExpiringSoon  *ExpiringPoints `json:"expiring_soon,omitempty"`
+	Entries       []PointsEntry   `json:"entries"`
+}
+
+// PointsExpiryPolicy controls how long earned points stay spendable.
+// A zero ValidMonths means points never expire.
+type PointsExpiryPolicy struct {
+	ValidMonths int
+	WarningDays int
+}
+
+// ExpiresAt returns when points earned at earnedAt lapse, or nil if they never do
+func (p PointsExpiryPolicy) ExpiresAt(earnedAt time.Time) *time.Time {
+	if p.ValidMonths <= 0 {
+		return nil
+	}
+	t := earnedAt.AddDate(0, p.ValidMonths, 0)
+	return &t
+}
+
+// ExpiringSoonCutoff is the end of the window in which points count as expiring soon
+func (p PointsExpiryPolicy) ExpiringSoonCutoff(now time.Time) time.Time {
+	return now.AddDate(0, 0, p.WarningDays)
+}
+
+// ExpiringPoints is the part of a balance that lapses before the given time
+type ExpiringPoints struct {
+	Points     int        `json:"points"`
+	Before     time.Time  `json:"before"`
+	NextExpiry *time.Time `json:"next_expiry,omitempty"`
+}
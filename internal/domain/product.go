@@ -8,13 +8,83 @@ const (
 	SizeLarge  ProductSize = "Large"
 )
 
+func (s ProductSize) IsValid() bool {
+	switch s {
+	case SizeSmall, SizeMedium, SizeLarge:
+		return true
+	}
+	return false
+}
+
 type Product struct {
 	ID                int64       `json:"id"`
 	Name              string      `json:"name"`
 	Type              string      `json:"type"`
 	Flavor            string      `json:"flavor"`
 	Size              ProductSize `json:"size"`
-	Price             float64     `json:"price"`
-	Quantity          int         `json:"quantity"`
-	ManufacturingDate string      `json:"manufacturing_date"` // YYYY-MM-DD
+	Price             Money       `json:"price"`
+	Quantity          int         `json:"quantity"` // stock at StoreID, or across every store when it is nil
+	StoreID           *int64      `json:"store_id,omitempty"`
+	ManufacturingDate string      `json:"manufacturing_date"` // YYYY-MM-DD of the newest batch; stock added by a write is dated with it
+}
+
+// ProductPatch holds the fields of a partial update; nil fields are left unchanged
+type ProductPatch struct {
+	Name              *string      `json:"name"`
+	Type              *string      `json:"type"`
+	Flavor            *string      `json:"flavor"`
+	Size              *ProductSize `json:"size"`
+	Price             *Money       `json:"price"`
+	Quantity          *int         `json:"quantity"`
+	ManufacturingDate *string      `json:"manufacturing_date"`
+}
+
+func (pp ProductPatch) Apply(p *Product) {
+	if pp.Name != nil {
+		p.Name = *pp.Name
+	}
+	if pp.Type != nil {
+		p.Type = *pp.Type
+	}
+	if pp.Flavor != nil {
+		p.Flavor = *pp.Flavor
+	}
+	if pp.Size != nil {
+		p.Size = *pp.Size
+	}
+	if pp.Price != nil {
+		p.Price = *pp.Price
+	}
+	if pp.Quantity != nil {
+		p.Quantity = *pp.Quantity
+	}
+	if pp.ManufacturingDate != nil {
+		p.ManufacturingDate = *pp.ManufacturingDate
+	}
+}
+
+// ProductSortFields are the values accepted by ProductFilter.Sort, optionally prefixed with "-" for descending
+var ProductSortFields = []string{"id", "name", "price", "quantity", "manufacturing_date"}
+
+// ProductFilter narrows the catalog listing; zero values mean "no filter"
+type ProductFilter struct {
+	Type              string
+	Flavor            string
+	Size              ProductSize
+	MinPrice          *Money
+	MaxPrice          *Money
+	InStock           bool
+	Search            string // case-insensitive match on name
+	ManufacturingDate string // products with a batch made on this date
+	StoreID           *int64 // Quantity, InStock and sorting by quantity use this store's stock
+	Sort              string
+	Page              int
+	PageSize          int
+}
+
+type ProductPage struct {
+	Items    []Product `json:"items"`
+	Page     int       `json:"page"`
+	PageSize int       `json:"page_size"`
+	Total    int       `json:"total"`
 }
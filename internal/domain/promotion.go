@@ -0,0 +1,276 @@
+package domain
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+)
+
+type PromotionKind string
+
+const (
+	PromotionPercentage PromotionKind = "percentage" // Percent off the price
+	PromotionFixed      PromotionKind = "fixed"      // Amount off each unit
+	PromotionBuyXGetY   PromotionKind = "buy_x_get_y"
+	PromotionBundle     PromotionKind = "bundle" // BundleQuantity units of a type, any flavor, for BundlePrice
+)
+
+func (k PromotionKind) IsValid() bool {
+	switch k {
+	case PromotionPercentage, PromotionFixed, PromotionBuyXGetY, PromotionBundle:
+		return true
+	}
+	return false
+}
+
+// Promotion is a discount applied at checkout. It covers one product, one product type
+// or, with neither set, everything. A promotion with a Code is a coupon: it applies
+// only when the code is given and can be capped to UsageLimit sales.
+type Promotion struct {
+	ID             int64         `json:"id"`
+	Name           string        `json:"name"`
+	Kind           PromotionKind `json:"kind"`
+	ProductID      *int64        `json:"product_id,omitempty"`
+	ProductType    *string       `json:"product_type,omitempty"`
+	Percent        int           `json:"percent,omitempty"`
+	Amount         Money         `json:"amount,omitempty"`
+	BuyQuantity    int           `json:"buy_quantity,omitempty"`
+	FreeQuantity   int           `json:"free_quantity,omitempty"`
+	BundleQuantity int           `json:"bundle_quantity,omitempty"`
+	BundlePrice    Money         `json:"bundle_price,omitempty"`
+	Code           *string       `json:"code,omitempty"`
+	UsageLimit     *int          `json:"usage_limit,omitempty"`
+	UsageCount     int           `json:"usage_count"`
+	StartDate      string        `json:"start_date"` // YYYY-MM-DD
+	EndDate        string        `json:"end_date"`   // YYYY-MM-DD
+	Active         bool          `json:"active"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// NormalizeCouponCode makes coupon codes case-insensitive
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (p *Promotion) runsOn(at time.Time) bool {
+	day := at.Format("2006-01-02")
+	return p.Active && day >= p.StartDate && day <= p.EndDate
+}
+
+func (p *Promotion) covers(line *CartLine) bool {
+	if p.ProductID != nil && *p.ProductID != line.ProductID {
+		return false
+	}
+	return p.ProductType == nil || *p.ProductType == line.ProductType
+}
+
+// lineDiscount is what a percentage, fixed or buy-X-get-Y promotion takes off
+// units of the line, never more than base
+func (p *Promotion) lineDiscount(line *CartLine, units int, base Money) Money {
+	var d Money
+	switch p.Kind {
+	case PromotionPercentage:
+		d = base.MulDiv(p.Percent, 100)
+	case PromotionFixed:
+		d = p.Amount.Mul(units)
+	case PromotionBuyXGetY:
+		if set := p.BuyQuantity + p.FreeQuantity; set > 0 {
+			d = line.Price.Mul(units / set * p.FreeQuantity)
+		}
+	}
+	return min(d, base)
+}
+
+// Discount is one promotion's share of a sale, stored with the transaction
+type Discount struct {
+	PromotionID int64         `json:"promotion_id"`
+	Name        string        `json:"name"`
+	Kind        PromotionKind `json:"kind"`
+	Code        string        `json:"code,omitempty"`
+	Amount      Money         `json:"amount"`
+}
+
+// CartLine is one product being priced
+type CartLine struct {
+	ProductID   int64
+	ProductType string
+	Price       Money
+	Quantity    int
+
+	Discounts    []Discount
+	bundled      int   // units already priced by a bundle
+	free         int   // units given away by a buy-X-get-Y promotion
+	autoDiscount Money // what the line's own promotion took off its unbundled units
+}
+
+func (l *CartLine) Subtotal() Money {
+	return l.Price.Mul(l.Quantity)
+}
+
+func (l *CartLine) DiscountTotal() Money {
+	var total Money
+	for _, d := range l.Discounts {
+		total += d.Amount
+	}
+	return total
+}
+
+func (l *CartLine) addDiscount(p *Promotion, amount Money) {
+	if amount <= 0 {
+		return
+	}
+	d := Discount{PromotionID: p.ID, Name: p.Name, Kind: p.Kind, Amount: amount}
+	if p.Code != nil {
+		d.Code = *p.Code
+	}
+	l.Discounts = append(l.Discounts, d)
+}
+
+// ApplyPromotions prices the cart in three passes:
+//  1. bundles, in creation order, on the most expensive eligible units first
+//  2. the single best percentage, fixed or buy-X-get-Y promotion on each line's other units
+//  3. the coupon matching code, if any, on what is left of the units of each line it
+//     covers that are still charged at the line's price
+//
+// It returns the coupon that was applied so its use can be counted. A code that is
+// unknown, out of its window or covers nothing in the cart is a validation error.
+func ApplyPromotions(lines []CartLine, promos []Promotion, code string, at time.Time) (*Promotion, error) {
+	var auto []*Promotion
+	var coupon *Promotion
+	code = NormalizeCouponCode(code)
+	for i := range promos {
+		p := &promos[i]
+		if !p.runsOn(at) {
+			continue
+		}
+		if p.Code == nil {
+			auto = append(auto, p)
+		} else if code != "" && *p.Code == code {
+			coupon = p
+		}
+	}
+	slices.SortFunc(auto, func(a, b *Promotion) int { return cmp.Compare(a.ID, b.ID) })
+
+	for _, p := range auto {
+		if p.Kind == PromotionBundle {
+			applyBundle(lines, p)
+		}
+	}
+
+	for i := range lines {
+		line := &lines[i]
+		units := line.Quantity - line.bundled
+		if units <= 0 {
+			continue
+		}
+		base := line.Price.Mul(units)
+		var best *Promotion
+		var bestAmount Money
+		for _, p := range auto {
+			if p.Kind == PromotionBundle || !p.covers(line) {
+				continue
+			}
+			if d := p.lineDiscount(line, units, base); d > bestAmount {
+				best, bestAmount = p, d
+			}
+		}
+		if best != nil {
+			line.addDiscount(best, bestAmount)
+			line.autoDiscount = bestAmount
+			if best.Kind == PromotionBuyXGetY {
+				line.free = units / (best.BuyQuantity + best.FreeQuantity) * best.FreeQuantity
+			}
+		}
+	}
+
+	if code == "" {
+		return nil, nil
+	}
+	if coupon == nil {
+		return nil, NewValidationError("coupon code is not valid")
+	}
+	applied := false
+	for i := range lines {
+		line := &lines[i]
+		if !coupon.covers(line) {
+			continue
+		}
+		// units a bundle priced or a buy-X-get-Y gave away are not discounted again
+		units := line.Quantity - line.bundled - line.free
+		if units <= 0 {
+			continue
+		}
+		remaining := line.Price.Mul(line.Quantity-line.bundled) - line.autoDiscount
+		if d := coupon.lineDiscount(line, units, remaining); d > 0 {
+			line.addDiscount(coupon, d)
+			applied = true
+		}
+	}
+	if !applied {
+		return nil, NewValidationError("coupon code does not apply to these items")
+	}
+	return coupon, nil
+}
+
+// applyBundle groups eligible units into as many bundles as fit, most expensive first
+// so the customer gets the larger saving, and spreads each line's share of the discount
+// in proportion to the value it put into the bundles
+func applyBundle(lines []CartLine, p *Promotion) {
+	if p.BundleQuantity <= 0 {
+		return
+	}
+
+	var eligible []int
+	units := 0
+	for i := range lines {
+		if p.covers(&lines[i]) && lines[i].Quantity > lines[i].bundled {
+			eligible = append(eligible, i)
+			units += lines[i].Quantity - lines[i].bundled
+		}
+	}
+	bundles := units / p.BundleQuantity
+	if bundles == 0 {
+		return
+	}
+	slices.SortStableFunc(eligible, func(a, b int) int {
+		return cmp.Compare(lines[b].Price, lines[a].Price)
+	})
+
+	take := make(map[int]int, len(eligible))
+	var value Money
+	left := bundles * p.BundleQuantity
+	for _, i := range eligible {
+		n := min(lines[i].Quantity-lines[i].bundled, left)
+		take[i] = n
+		value += lines[i].Price.Mul(n)
+		left -= n
+	}
+	discount := value - p.BundlePrice.Mul(bundles)
+	if discount <= 0 {
+		return
+	}
+
+	// the last line absorbs rounding so the shares add up to the discount
+	remaining := discount
+	last := -1
+	for _, i := range eligible {
+		if take[i] > 0 {
+			last = i
+		}
+	}
+	for _, i := range eligible {
+		n := take[i]
+		if n == 0 {
+			continue
+		}
+		lines[i].bundled += n
+		share := remaining
+		if i != last {
+			share = discount.MulDiv(int(lines[i].Price.Mul(n)), int(value))
+		}
+		remaining -= share
+		lines[i].addDiscount(p, share)
+	}
+}
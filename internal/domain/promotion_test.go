@@ -0,0 +1,151 @@
+package domain_test
+
+import (
+	"bsnack/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var promoDay = time.Date(2025, 12, 10, 10, 0, 0, 0, time.UTC)
+
+func promo(id int64, kind domain.PromotionKind) domain.Promotion {
+	return domain.Promotion{ID: id, Name: string(kind), Kind: kind, StartDate: "2025-12-01", EndDate: "2025-12-31", Active: true}
+}
+
+func TestApplyPromotions_BestAutomaticPromotionWins(t *testing.T) {
+	pct := promo(1, domain.PromotionPercentage)
+	pct.Percent = 10
+	fixed := promo(2, domain.PromotionFixed)
+	fixed.Amount = domain.NewMoney(2000)
+	fixed.ProductType = strPtr("Makaroni")
+	b2g1 := promo(3, domain.PromotionBuyXGetY)
+	b2g1.BuyQuantity, b2g1.FreeQuantity = 2, 1
+	productID := int64(7)
+	b2g1.ProductID = &productID
+	promos := []domain.Promotion{pct, fixed, b2g1}
+
+	cart := []domain.CartLine{
+		{ProductID: 5, ProductType: "Makaroni", Price: domain.NewMoney(15000), Quantity: 2},
+		{ProductID: 7, ProductType: "Keripik Pangsit", Price: domain.NewMoney(10000), Quantity: 3},
+		{ProductID: 8, ProductType: "Keripik Pangsit", Price: domain.NewMoney(10000), Quantity: 1},
+	}
+
+	coupon, err := domain.ApplyPromotions(cart, promos, "", promoDay)
+
+	assert.NoError(t, err)
+	assert.Nil(t, coupon)
+	// 2 x 2,000 off beats 10% of 30,000
+	assert.Equal(t, domain.NewMoney(4000), cart[0].DiscountTotal())
+	assert.Equal(t, int64(2), cart[0].Discounts[0].PromotionID)
+	// the third bag is free, which beats 10%
+	assert.Equal(t, domain.NewMoney(10000), cart[1].DiscountTotal())
+	assert.Equal(t, domain.NewMoney(1000), cart[2].DiscountTotal())
+}
+
+func TestApplyPromotions_OutsideWindow(t *testing.T) {
+	pct := promo(1, domain.PromotionPercentage)
+	pct.Percent = 50
+	pct.EndDate = "2025-12-09"
+	cart := []domain.CartLine{{ProductID: 1, Price: domain.NewMoney(10000), Quantity: 1}}
+
+	_, err := domain.ApplyPromotions(cart, []domain.Promotion{pct}, "", promoDay)
+
+	assert.NoError(t, err)
+	assert.Empty(t, cart[0].Discounts)
+}
+
+func TestApplyPromotions_BundleAcrossFlavors(t *testing.T) {
+	bundle := promo(1, domain.PromotionBundle)
+	bundle.ProductType = strPtr("Keripik Pangsit")
+	bundle.BundleQuantity, bundle.BundlePrice = 3, domain.NewMoney(25000)
+	pct := promo(2, domain.PromotionPercentage)
+	pct.Percent = 10
+
+	cart := []domain.CartLine{
+		{ProductID: 1, ProductType: "Keripik Pangsit", Price: domain.NewMoney(10000), Quantity: 2}, // Balado
+		{ProductID: 2, ProductType: "Keripik Pangsit", Price: domain.NewMoney(12000), Quantity: 2}, // Jagung Bakar
+	}
+
+	_, err := domain.ApplyPromotions(cart, []domain.Promotion{bundle, pct}, "", promoDay)
+
+	assert.NoError(t, err)
+	// one bundle of the two 12,000 bags and one 10,000 bag: 34,000 for 25,000
+	assert.Equal(t, domain.NewMoney(9000), cart[0].Discounts[0].Amount+cart[1].Discounts[0].Amount)
+	assert.Equal(t, domain.Money(635294), cart[1].Discounts[0].Amount, "share of 24,000 in 34,000")
+	// the bag left out of the bundle still gets 10% off
+	if assert.Len(t, cart[0].Discounts, 2) {
+		assert.Equal(t, domain.NewMoney(1000), cart[0].Discounts[1].Amount)
+	}
+	assert.Len(t, cart[1].Discounts, 1)
+}
+
+func TestApplyPromotions_CouponStacksOnRemainder(t *testing.T) {
+	pct := promo(1, domain.PromotionPercentage)
+	pct.Percent = 10
+	coupon := promo(2, domain.PromotionPercentage)
+	coupon.Percent = 50
+	coupon.Code = strPtr("HEMAT50")
+	cart := []domain.CartLine{{ProductID: 1, Price: domain.NewMoney(10000), Quantity: 2}}
+
+	applied, err := domain.ApplyPromotions(cart, []domain.Promotion{pct, coupon}, " hemat50 ", promoDay)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), applied.ID)
+	// 10% of 20,000, then 50% of the 18,000 left
+	assert.Equal(t, domain.NewMoney(11000), cart[0].DiscountTotal())
+	assert.Equal(t, "HEMAT50", cart[0].Discounts[1].Code)
+}
+
+func TestApplyPromotions_CouponSkipsFreeAndBundledUnits(t *testing.T) {
+	pangsit, makaroni := int64(1), int64(2)
+	bogo := promo(1, domain.PromotionBuyXGetY)
+	bogo.ProductID = &pangsit
+	bogo.BuyQuantity, bogo.FreeQuantity = 2, 1
+	bundle := promo(2, domain.PromotionBundle)
+	bundle.ProductID = &makaroni
+	bundle.BundleQuantity, bundle.BundlePrice = 2, domain.NewMoney(15000)
+	coupon := promo(3, domain.PromotionFixed)
+	coupon.Amount = domain.NewMoney(1000)
+	coupon.Code = strPtr("HEMAT")
+	cart := []domain.CartLine{
+		{ProductID: 1, Price: domain.NewMoney(10000), Quantity: 3},
+		{ProductID: 2, Price: domain.NewMoney(10000), Quantity: 3},
+	}
+
+	_, err := domain.ApplyPromotions(cart, []domain.Promotion{bogo, bundle, coupon}, "HEMAT", promoDay)
+
+	assert.NoError(t, err)
+	// one unit free, then 1,000 off each of the two paid for
+	assert.Equal(t, domain.NewMoney(12000), cart[0].DiscountTotal())
+	// two units in the bundle, then 1,000 off the one left at full price
+	assert.Equal(t, domain.NewMoney(6000), cart[1].DiscountTotal())
+}
+
+func TestApplyPromotions_CouponIgnoredWithoutCode(t *testing.T) {
+	coupon := promo(1, domain.PromotionFixed)
+	coupon.Amount = domain.NewMoney(1000)
+	coupon.Code = strPtr("HEMAT")
+	cart := []domain.CartLine{{ProductID: 1, Price: domain.NewMoney(10000), Quantity: 1}}
+
+	applied, err := domain.ApplyPromotions(cart, []domain.Promotion{coupon}, "", promoDay)
+
+	assert.NoError(t, err)
+	assert.Nil(t, applied)
+	assert.Empty(t, cart[0].Discounts)
+}
+
+func TestApplyPromotions_InvalidCoupon(t *testing.T) {
+	coupon := promo(1, domain.PromotionFixed)
+	coupon.Amount = domain.NewMoney(1000)
+	coupon.Code = strPtr("MAKARONI")
+	coupon.ProductType = strPtr("Makaroni")
+	cart := []domain.CartLine{{ProductID: 1, ProductType: "Keripik Pangsit", Price: domain.NewMoney(10000), Quantity: 1}}
+
+	_, err := domain.ApplyPromotions(cart, []domain.Promotion{coupon}, "UNKNOWN", promoDay)
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
+	_, err = domain.ApplyPromotions(cart, []domain.Promotion{coupon}, "MAKARONI", promoDay)
+	assert.EqualError(t, err, "coupon code does not apply to these items")
+}
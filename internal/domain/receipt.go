@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Receipt is a sale as printed for the customer: a whole order, a purchase made on
+// its own, or a refund
+type Receipt struct {
+	Store         *Store        `json:"store,omitempty"`
+	Number        uuid.UUID     `json:"number"` // the order ID, or the transaction ID outside an order
+	Refund        bool          `json:"refund"`
+	CustomerName  string        `json:"customer_name"`
+	Cashier       string        `json:"cashier,omitempty"`
+	Date          time.Time     `json:"date"`
+	Lines         []Transaction `json:"lines"`
+	Subtotal      Money         `json:"subtotal"`
+	DiscountTotal Money         `json:"discount_total"`
+	NetAmount     Money         `json:"net_amount"`
+	TaxAmount     Money         `json:"tax_amount"`
+	TotalPrice    Money         `json:"total_price"`
+	Payments      []Payment     `json:"payments"`
+	PointsEarned  int           `json:"points_earned"`
+	PointsBalance *int          `json:"points_balance,omitempty"`
+}
+
+// AddLine appends a line and adds it to the totals
+func (r *Receipt) AddLine(t Transaction) {
+	r.Lines = append(r.Lines, t)
+	r.Subtotal += t.Subtotal
+	r.DiscountTotal += t.DiscountTotal
+	r.NetAmount += t.NetAmount
+	r.TaxAmount += t.TaxAmount
+	r.TotalPrice += t.TotalPrice
+}
+
+// TaxLine is the tax charged at one rate, as listed under a receipt's totals
+type TaxLine struct {
+	BasisPoints int
+	Inclusive   bool
+	NetAmount   Money
+	TaxAmount   Money
+}
+
+// TaxLines totals the receipt's tax by rate, in the order the rates first appear.
+// Untaxed lines are left out.
+func (r *Receipt) TaxLines() []TaxLine {
+	var taxes []TaxLine
+	for _, line := range r.Lines {
+		if line.TaxAmount == 0 {
+			continue
+		}
+		i := 0
+		for i < len(taxes) && (taxes[i].BasisPoints != line.TaxBasisPoints || taxes[i].Inclusive != line.TaxInclusive) {
+			i++
+		}
+		if i == len(taxes) {
+			taxes = append(taxes, TaxLine{BasisPoints: line.TaxBasisPoints, Inclusive: line.TaxInclusive})
+		}
+		taxes[i].NetAmount += line.NetAmount
+		taxes[i].TaxAmount += line.TaxAmount
+	}
+	return taxes
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// Redemption records a product handed out for points. Stock and the points ledger
+// change with it, but it is not a sale and carries no income.
+type Redemption struct {
+	ID            int64             `json:"id"`
+	CustomerID    int64             `json:"customer_id"`
+	CustomerName  string            `json:"customer_name"`
+	ProductID     int64             `json:"product_id"`
+	ProductName   string            `json:"product_name"`
+	ProductSize   string            `json:"product_size"`
+	ProductFlavor string            `json:"product_flavor"`
+	Quantity      int               `json:"quantity"`
+	PointsSpent   int               `json:"points_spent"`
+	RedeemRuleID  *int64            `json:"redeem_rule_id,omitempty"`
+	PointsEntryID int64             `json:"points_entry_id"`
+	Batches       []BatchAllocation `json:"batches,omitempty"`
+	StoreID       int64             `json:"store_id"`
+	RedeemedAt    time.Time         `json:"redeemed_at"`
+}
+
+// RedemptionFilter narrows the redemption listing; zero values mean "no filter"
+type RedemptionFilter struct {
+	StartDate  string // YYYY-MM-DD, inclusive
+	EndDate    string // YYYY-MM-DD, inclusive
+	CustomerID int64
+	StoreID    int64
+}
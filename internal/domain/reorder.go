@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// ReorderLevel is when and how much of a product to reorder: once a store's sellable
+// stock falls to Point, Quantity more units are due
+type ReorderLevel struct {
+	ProductID int64     `json:"product_id"`
+	Point     int       `json:"reorder_point"`
+	Quantity  int       `json:"reorder_quantity"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StockLevel is a product's sellable stock at a store, expired units left out, with its
+// reorder level when it has one
+type StockLevel struct {
+	StoreID         int64       `json:"store_id"`
+	ProductID       int64       `json:"product_id"`
+	ProductName     string      `json:"product_name"`
+	ProductFlavor   string      `json:"product_flavor"`
+	ProductSize     ProductSize `json:"product_size"`
+	Quantity        int         `json:"quantity"`
+	ReorderPoint    *int        `json:"reorder_point,omitempty"`
+	ReorderQuantity *int        `json:"reorder_quantity,omitempty"`
+}
+
+// ReorderSuggestion is how many units of a product a store should order, from the rate
+// it sold them at over the lookback window
+type ReorderSuggestion struct {
+	StockLevel
+	UnitsSold         int      `json:"units_sold"`              // net of refunds
+	DailyVelocity     float64  `json:"daily_velocity"`          // units sold per day
+	DaysOfCover       *float64 `json:"days_of_cover,omitempty"` // days the stock lasts; nil without sales
+	SuggestedQuantity int      `json:"suggested_quantity"`
+}
+
+// Suggest sets the velocity of UnitsSold over lookbackDays and the units to order so the
+// stock covers coverDays of sales, and at least the reorder quantity once stock is at
+// the reorder point
+func (s *ReorderSuggestion) Suggest(lookbackDays, coverDays int) {
+	velocity := float64(max(s.UnitsSold, 0)) / float64(lookbackDays)
+	s.DailyVelocity = math.Round(velocity*100) / 100
+	s.DaysOfCover = nil
+	if velocity > 0 {
+		cover := math.Round(float64(s.Quantity)/velocity*10) / 10
+		s.DaysOfCover = &cover
+	}
+
+	need := int(math.Ceil(velocity*float64(coverDays))) - s.Quantity
+	if s.ReorderPoint != nil && s.Quantity <= *s.ReorderPoint {
+		need = max(need, *s.ReorderQuantity)
+	}
+	s.SuggestedQuantity = max(need, 0)
+}
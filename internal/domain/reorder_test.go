@@ -0,0 +1,31 @@
+package domain_test
+
+import (
+	"bsnack/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReorderSuggestion_Suggest(t *testing.T) {
+	s := domain.ReorderSuggestion{StockLevel: domain.StockLevel{Quantity: 9}, UnitsSold: 20}
+
+	s.Suggest(7, 14)
+
+	// 20 units in 7 days is 2.857 a day: 40 units cover 14 days
+	assert.Equal(t, 2.86, s.DailyVelocity)
+	assert.Equal(t, 3.2, *s.DaysOfCover)
+	assert.Equal(t, 31, s.SuggestedQuantity)
+
+	// the reorder quantity is the least ordered once stock is at the reorder point
+	point, qty := 10, 48
+	s.ReorderPoint, s.ReorderQuantity = &point, &qty
+	s.Suggest(7, 14)
+	assert.Equal(t, 48, s.SuggestedQuantity)
+
+	// refunds outweighing sales are no sales at all
+	s = domain.ReorderSuggestion{StockLevel: domain.StockLevel{Quantity: 3}, UnitsSold: -2}
+	s.Suggest(30, 14)
+	assert.Nil(t, s.DaysOfCover)
+	assert.Equal(t, 0, s.SuggestedQuantity)
+}
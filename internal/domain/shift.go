@@ -0,0 +1,57 @@
+package domain
+
+import "time"
+
+// Shift is a cashier's session on a till, from opening with a cash float to closing
+// with the drawer counted. Sales and refunds made on the till carry its ID.
+type Shift struct {
+	ID           int64      `json:"id"`
+	StoreID      int64      `json:"store_id"`
+	Till         string     `json:"till"`
+	Cashier      string     `json:"cashier"`
+	OpeningFloat Money      `json:"opening_float"`
+	OpenedAt     time.Time  `json:"opened_at"`
+	ClosedAt     *time.Time `json:"closed_at,omitempty"`
+	ExpectedCash *Money     `json:"expected_cash,omitempty"` // set on close
+	CountedCash  *Money     `json:"counted_cash,omitempty"`
+	Variance     *Money     `json:"variance,omitempty"` // counted - expected; negative when cash is missing
+}
+
+func (s *Shift) IsOpen() bool {
+	return s.ClosedAt == nil
+}
+
+// Close records the counted cash against what the report expects
+func (s *Shift) Close(expected, counted Money, at time.Time) {
+	variance := counted - expected
+	s.ClosedAt = &at
+	s.ExpectedCash = &expected
+	s.CountedCash = &counted
+	s.Variance = &variance
+}
+
+// ShiftReport is a shift's takings: an X-report while the shift is open and the
+// Z-report once it is closed
+type ShiftReport struct {
+	Shift         Shift          `json:"shift"`
+	Sales         int            `json:"sales"` // purchases and orders, not lines
+	ItemsSold     int            `json:"items_sold"`
+	GrossSales    Money          `json:"gross_sales"`
+	Refunds       Money          `json:"refunds"` // negative
+	TotalNet      Money          `json:"total_net"`
+	TotalTax      Money          `json:"total_tax"`
+	TotalDiscount Money          `json:"total_discount"`
+	Payments      []PaymentTotal `json:"payments"`
+	ExpectedCash  Money          `json:"expected_cash"` // opening float plus cash taken, less cash paid out
+}
+
+// Reconcile works out the cash that should be in the drawer from the float and the
+// cash payments of the shift
+func (r *ShiftReport) Reconcile() {
+	r.ExpectedCash = r.Shift.OpeningFloat
+	for _, p := range r.Payments {
+		if p.Method == PaymentCash {
+			r.ExpectedCash += p.Amount
+		}
+	}
+}
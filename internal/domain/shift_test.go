@@ -0,0 +1,26 @@
+package domain_test
+
+import (
+	"bsnack/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShiftReport_ReconcileCountsCashOnly(t *testing.T) {
+	report := domain.ShiftReport{
+		Shift: domain.Shift{OpeningFloat: domain.NewMoney(100000)},
+		Payments: []domain.PaymentTotal{
+			// 52,000 taken less a 12,000 refund paid out
+			{Method: domain.PaymentCash, Count: 4, Amount: domain.NewMoney(40000)},
+			{Method: domain.PaymentQRIS, Count: 2, Amount: domain.NewMoney(30000)},
+		},
+	}
+
+	report.Reconcile()
+	assert.Equal(t, domain.NewMoney(140000), report.ExpectedCash)
+
+	report.Shift.Close(report.ExpectedCash, domain.NewMoney(141000), report.Shift.OpenedAt)
+	assert.False(t, report.Shift.IsOpen())
+	assert.Equal(t, domain.NewMoney(1000), *report.Shift.Variance)
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+// Stocktake is a physical count of a store's shelves. Counts are submitted while it is
+// open; finalizing corrects the stock of every counted product to its count.
+type Stocktake struct {
+	ID          int64           `json:"id"`
+	StoreID     int64           `json:"store_id"`
+	OpenedBy    string          `json:"opened_by"`
+	OpenedAt    time.Time       `json:"opened_at"`
+	FinalizedBy string          `json:"finalized_by,omitempty"`
+	FinalizedAt *time.Time      `json:"finalized_at,omitempty"`
+	Lines       []StocktakeLine `json:"lines"`
+}
+
+func (s *Stocktake) IsOpen() bool {
+	return s.FinalizedAt == nil
+}
+
+// StocktakeLine is the count of one product against the stock the store held in the
+// system when it was counted
+type StocktakeLine struct {
+	ProductID     int64       `json:"product_id"`
+	ProductName   string      `json:"product_name"`
+	ProductFlavor string      `json:"product_flavor"`
+	ProductSize   ProductSize `json:"product_size"`
+	Counted       int         `json:"counted"`
+	System        int         `json:"system"`
+	Variance      int         `json:"variance"` // counted - system; negative when stock is missing
+	CountedBy     string      `json:"counted_by"`
+	CountedAt     time.Time   `json:"counted_at"`
+}
+
+// Reconcile sets the line's system quantity and the variance of the count against it
+func (l *StocktakeLine) Reconcile(system int) {
+	l.System = system
+	l.Variance = l.Counted - system
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// MainStoreID is the store created with the stores migration. It holds the stock and
+// sales recorded before there were stores and takes sales sent without a store.
+const MainStoreID int64 = 1
+
+// Store is an outlet with its own stock and tills. Customers and their points are
+// shared by every store.
+type Store struct {
+	ID        int64     `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	TaxID     string    `json:"tax_id"` // NPWP printed on receipts
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
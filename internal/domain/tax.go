@@ -0,0 +1,64 @@
+package domain
+
+import "time"
+
+// TaxRate is the PPN charged on a product type; a nil ProductType is the default for
+// types without a rate of their own. Inclusive rates are already part of the shelf
+// price, exclusive ones are added on top of it.
+type TaxRate struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	ProductType *string   `json:"product_type,omitempty"`
+	BasisPoints int       `json:"basis_points"` // 1100 = 11%
+	Inclusive   bool      `json:"inclusive"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Apply splits the amount charged for a line into net and tax. For an inclusive rate
+// the tax is carved out of amount; for an exclusive rate it is added to it.
+func (r *TaxRate) Apply(amount Money) (net, tax Money) {
+	if r == nil || r.BasisPoints == 0 {
+		return amount, 0
+	}
+	if r.Inclusive {
+		tax = amount.MulDiv(r.BasisPoints, 10000+r.BasisPoints)
+		return amount - tax, tax
+	}
+	return amount, amount.MulDiv(r.BasisPoints, 10000)
+}
+
+// TaxRates is the set of active rates read at transaction time
+type TaxRates []TaxRate
+
+// For returns the rate for the product type, falling back to the default rate,
+// or nil when neither is configured and the sale is untaxed
+func (rs TaxRates) For(productType string) *TaxRate {
+	var fallback *TaxRate
+	for i := range rs {
+		rate := &rs[i]
+		if !rate.Active {
+			continue
+		}
+		if rate.ProductType == nil {
+			fallback = rate
+		} else if *rate.ProductType == productType {
+			return rate
+		}
+	}
+	return fallback
+}
+
+// ApplyTax records the tax on a line that costs amount after discounts and sets
+// TotalPrice to the gross the customer pays
+func (t *Transaction) ApplyTax(rate *TaxRate, amount Money) {
+	t.NetAmount, t.TaxAmount = rate.Apply(amount)
+	t.TotalPrice = t.NetAmount + t.TaxAmount
+	t.TaxRateID, t.TaxBasisPoints, t.TaxInclusive = nil, 0, false
+	if rate != nil {
+		t.TaxRateID = &rate.ID
+		t.TaxBasisPoints = rate.BasisPoints
+		t.TaxInclusive = rate.Inclusive
+	}
+}
@@ -0,0 +1,49 @@
+package domain_test
+
+import (
+	"bsnack/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaxRate_Apply(t *testing.T) {
+	inclusive := &domain.TaxRate{BasisPoints: 1100, Inclusive: true}
+	net, tax := inclusive.Apply(domain.NewMoney(11100))
+	assert.Equal(t, domain.NewMoney(10000), net)
+	assert.Equal(t, domain.NewMoney(1100), tax)
+
+	exclusive := &domain.TaxRate{BasisPoints: 1100}
+	net, tax = exclusive.Apply(domain.NewMoney(10000))
+	assert.Equal(t, domain.NewMoney(10000), net)
+	assert.Equal(t, domain.NewMoney(1100), tax)
+
+	var untaxed *domain.TaxRate
+	net, tax = untaxed.Apply(domain.NewMoney(10000))
+	assert.Equal(t, domain.NewMoney(10000), net)
+	assert.Zero(t, tax)
+}
+
+func TestTaxRates_For(t *testing.T) {
+	rates := domain.TaxRates{
+		{ID: 1, BasisPoints: 1100, Active: true},
+		{ID: 2, ProductType: strPtr("Makaroni"), BasisPoints: 0, Active: true},
+		{ID: 3, ProductType: strPtr("Keripik Pangsit"), BasisPoints: 1200, Active: false},
+	}
+
+	assert.Equal(t, int64(2), rates.For("Makaroni").ID)
+	// a retired rate falls back to the default
+	assert.Equal(t, int64(1), rates.For("Keripik Pangsit").ID)
+	assert.Nil(t, rates[1:].For("Keripik Pangsit"))
+}
+
+func TestTransaction_ApplyTax(t *testing.T) {
+	tx := &domain.Transaction{}
+	tx.ApplyTax(&domain.TaxRate{ID: 4, BasisPoints: 1100}, domain.NewMoney(20000))
+
+	assert.Equal(t, domain.NewMoney(20000), tx.NetAmount)
+	assert.Equal(t, domain.NewMoney(2200), tx.TaxAmount)
+	assert.Equal(t, domain.NewMoney(22200), tx.TotalPrice)
+	assert.Equal(t, int64(4), *tx.TaxRateID)
+	assert.Equal(t, 1100, tx.TaxBasisPoints)
+}
@@ -7,25 +7,55 @@ import (
 )
 
 type Transaction struct {
-	ID              uuid.UUID `json:"id"`
-	CustomerID      int64     `json:"customer_id"`
-	CustomerName    string    `json:"customer_name"`
-	ProductID       int64     `json:"product_id"`
-	ProductName     string    `json:"product_name"`
-	ProductSize     string    `json:"product_size"`
-	ProductFlavor   string    `json:"product_flavor"`
-	Quantity        int       `json:"quantity"`
-	TotalPrice      float64   `json:"total_price"`
-	TransactionDate time.Time `json:"transaction_date"`
-	IsNewCustomer   bool      `json:"is_new_customer"`
+	ID              uuid.UUID         `json:"id"`
+	OrderID         *uuid.UUID        `json:"order_id,omitempty"`
+	RefundOf        *uuid.UUID        `json:"refund_of,omitempty"` // set on refund rows, which carry negative quantity and price
+	CustomerID      int64             `json:"customer_id"`
+	CustomerName    string            `json:"customer_name"`
+	ProductID       int64             `json:"product_id"`
+	ProductName     string            `json:"product_name"`
+	ProductSize     string            `json:"product_size"`
+	ProductFlavor   string            `json:"product_flavor"`
+	Quantity        int               `json:"quantity"`
+	Subtotal        Money             `json:"subtotal"`
+	DiscountTotal   Money             `json:"discount_total"`
+	Discounts       []Discount        `json:"discounts,omitempty"`
+	NetAmount       Money             `json:"net_amount"`
+	TaxAmount       Money             `json:"tax_amount"`
+	TaxRateID       *int64            `json:"tax_rate_id,omitempty"`
+	TaxBasisPoints  int               `json:"tax_basis_points"`
+	TaxInclusive    bool              `json:"tax_inclusive"`
+	TotalPrice      Money             `json:"total_price"` // gross paid: NetAmount + TaxAmount
+	Payments        []Payment         `json:"payments,omitempty"`
+	Batches         []BatchAllocation `json:"batches,omitempty"` // the stock batches the line took from, or a refund put back into
+	PointsEarned    int               `json:"points_earned"`
+	PointsBalance   *int              `json:"points_balance,omitempty"` // the customer's balance right after the sale
+	PointUnits      int64             `json:"-"`                        // fractional points accrued, see PointFractions
+	EarnRuleID      *int64            `json:"earn_rule_id,omitempty"`
+	BonusRuleID     *int64            `json:"bonus_rule_id,omitempty"`
+	CustomerTier    CustomerTier      `json:"customer_tier,omitempty"`
+	StoreID         int64             `json:"store_id"`
+	ShiftID         *int64            `json:"shift_id,omitempty"`
+	Cashier         string            `json:"cashier,omitempty"`
+	TransactionDate time.Time         `json:"transaction_date"`
+	IsNewCustomer   bool              `json:"is_new_customer"`
 }
 
+// SalesReport covers one store, or every store when StoreID is nil
 type SalesReport struct {
-	StartDate      string        `json:"start_date"`
-	EndDate        string        `json:"end_date"`
-	TotalCustomers int           `json:"total_customers"`
-	TotalProducts  int           `json:"total_products"`
-	TotalIncome    float64       `json:"total_income"`
-	BestSeller     string        `json:"best_seller"`
-	Transactions   []Transaction `json:"transactions"`
+	StartDate      string         `json:"start_date"`
+	EndDate        string         `json:"end_date"`
+	StoreID        *int64         `json:"store_id,omitempty"`
+	TotalCustomers int            `json:"total_customers"`
+	TotalProducts  int            `json:"total_products"`
+	TotalIncome    Money          `json:"total_income"` // gross, net of discounts
+	TotalNet       Money          `json:"total_net"`
+	TotalTax       Money          `json:"total_tax"`
+	TotalDiscount  Money          `json:"total_discount"`
+	Payments       []PaymentTotal `json:"payments"`
+	BestSeller     string         `json:"best_seller"`
+	RedeemedUnits  int            `json:"redeemed_units"`
+	PointsRedeemed int            `json:"points_redeemed"`
+	Transactions   []Transaction  `json:"transactions"`
+	Orders         []Order        `json:"orders"`
 }
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"bsnack/internal/domain"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// maxBodyBytes caps every JSON request body
+const maxBodyBytes = 1 << 20
+
+var (
+	errBodyTooLarge = errors.New("request body too large")
+	// errEmptyBody is returned as-is so handlers with an optional body can accept it
+	errEmptyBody = domain.NewValidationError("request body must not be empty")
+)
+
+// decodeJSON reads exactly one JSON value into dst, rejecting unknown fields and oversized bodies.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return decodeErr(err)
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return domain.NewValidationError("request body must contain a single JSON object")
+	}
+	return nil
+}
+
+func decodeErr(err error) error {
+	var (
+		maxBytesErr *http.MaxBytesError
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+	)
+
+	switch {
+	case err == io.EOF:
+		return errEmptyBody
+	case errors.As(err, &maxBytesErr):
+		return errBodyTooLarge
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return domain.NewValidationError("request body contains malformed JSON")
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return domain.NewValidationError("field " + typeErr.Field + " has the wrong type")
+	default:
+		// unknown fields and field-level decode errors such as an invalid price
+		return domain.NewValidationError(err.Error())
+	}
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"bsnack/internal/domain"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	decode := func(body string) (domain.Product, error) {
+		var p domain.Product
+		req := httptest.NewRequest("POST", "/products", strings.NewReader(body))
+		err := decodeJSON(httptest.NewRecorder(), req, &p)
+		return p, err
+	}
+
+	p, err := decode(`{"name": "Keripik", "price": 10000}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "Keripik", p.Name)
+
+	_, err = decode(`{"name": "Keripik", "colour": "red"}`)
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	assert.Contains(t, err.Error(), "colour")
+
+	_, err = decode(`{"name": "Keripik"} {"name": "again"}`)
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
+	_, err = decode(`{"name": `)
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
+	_, err = decode(`{"quantity": "ten"}`)
+	assert.EqualError(t, err, "field quantity has the wrong type")
+
+	_, err = decode(``)
+	assert.Equal(t, errEmptyBody, err)
+
+	_, err = decode(`{"name": "` + strings.Repeat("a", maxBodyBytes) + `"}`)
+	assert.ErrorIs(t, err, errBodyTooLarge)
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/validation"
+	"bsnack/pkg/logger"
+	"errors"
+	"net/http"
+)
+
+// errorResponse is the body of every non-2xx response
+type errorResponse struct {
+	Code   string                  `json:"code"`
+	Error  string                  `json:"error"`
+	Fields []validation.FieldError `json:"fields,omitempty"`
+}
+
+// errorMappings is checked in order; the first kind matched by errors.Is wins
+var errorMappings = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{errBodyTooLarge, http.StatusRequestEntityTooLarge, "payload_too_large"},
+	{domain.ErrValidation, http.StatusBadRequest, "validation_error"},
+	{domain.ErrNotFound, http.StatusNotFound, "not_found"},
+	{domain.ErrConflict, http.StatusConflict, "conflict"},
+	{domain.ErrInsufficientStock, http.StatusUnprocessableEntity, "insufficient_stock"},
+	{domain.ErrExpiredStock, http.StatusUnprocessableEntity, "expired_stock"},
+	{domain.ErrInsufficientPoints, http.StatusUnprocessableEntity, "insufficient_points"},
+}
+
+// handleError maps domain errors to a status code and hides unexpected errors behind a 500
+func (h *Handler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	var fieldErrs validation.Errors
+	if errors.As(err, &fieldErrs) {
+		h.respondJSON(w, http.StatusBadRequest, errorResponse{
+			Code:   "validation_error",
+			Error:  "request validation failed",
+			Fields: fieldErrs,
+		})
+		return
+	}
+
+	for _, m := range errorMappings {
+		if errors.Is(err, m.kind) {
+			h.respondError(w, m.status, m.code, err.Error())
+			return
+		}
+	}
+
+	logger.Error("unhandled error",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"err", err)
+	h.respondError(w, http.StatusInternalServerError, "internal_error", "internal server error")
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, status int, code, message string) {
+	h.respondJSON(w, status, errorResponse{Code: code, Error: message})
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/validation"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleError(t *testing.T) {
+	cases := []struct {
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{domain.NewValidationError("quantity must be greater than 0"), http.StatusBadRequest, "validation_error", "quantity must be greater than 0"},
+		{domain.NewNotFoundError("product not found"), http.StatusNotFound, "not_found", "product not found"},
+		{domain.NewConflictError("transaction already fully refunded"), http.StatusConflict, "conflict", "transaction already fully refunded"},
+		{domain.ErrInsufficientStock, http.StatusUnprocessableEntity, "insufficient_stock", "insufficient stock"},
+		{domain.ErrExpiredStock, http.StatusUnprocessableEntity, "expired_stock", "stock past its expiry date cannot be sold"},
+		{fmt.Errorf("redeem: %w", domain.ErrInsufficientPoints), http.StatusUnprocessableEntity, "insufficient_points", "redeem: insufficient points"},
+		{errors.New("pq: connection refused"), http.StatusInternalServerError, "internal_error", "internal server error"},
+	}
+
+	h := &Handler{}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		h.handleError(rec, httptest.NewRequest(http.MethodPost, "/transactions", nil), tc.err)
+
+		var body errorResponse
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		assert.Equal(t, tc.status, rec.Code, tc.err.Error())
+		assert.Equal(t, tc.code, body.Code)
+		assert.Equal(t, tc.message, body.Error)
+	}
+}
+
+func TestHandleError_FieldErrors(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := validation.Errors{{Field: "name", Message: "is required"}, {Field: "price", Message: "must be greater than 0"}}
+
+	(&Handler{}).handleError(rec, httptest.NewRequest(http.MethodPost, "/products", nil), err)
+
+	var body errorResponse
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "validation_error", body.Code)
+	assert.Equal(t, []validation.FieldError(err), body.Fields)
+}
@@ -2,22 +2,59 @@ package http
 
 import (
 	"bsnack/internal/domain"
+	"bsnack/internal/receipt"
 	"bsnack/internal/service"
+	"bsnack/internal/validation"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+
+	"github.com/google/uuid"
 )
 
 type Handler struct {
-	prodSvc  *service.ProductService
-	transSvc *service.TransactionService
-	custSvc  *service.CustomerService
+	prodSvc    *service.ProductService
+	transSvc   *service.TransactionService
+	custSvc    *service.CustomerService
+	loyaltySvc *service.LoyaltyService
+	tierSvc    *service.TierService
+	promoSvc   *service.PromotionService
+	taxSvc     *service.TaxService
+	shiftSvc   *service.ShiftService
+	storeSvc   *service.StoreService
+	invSvc     *service.InventoryService
+	countSvc   *service.StocktakeService
+	printer    *receipt.Printer
 }
 
-func NewHandler(prodSvc *service.ProductService, transSvc *service.TransactionService, custSvc *service.CustomerService) *Handler {
+func NewHandler(
+	prodSvc *service.ProductService,
+	transSvc *service.TransactionService,
+	custSvc *service.CustomerService,
+	loyaltySvc *service.LoyaltyService,
+	tierSvc *service.TierService,
+	promoSvc *service.PromotionService,
+	taxSvc *service.TaxService,
+	shiftSvc *service.ShiftService,
+	storeSvc *service.StoreService,
+	invSvc *service.InventoryService,
+	countSvc *service.StocktakeService,
+	printer *receipt.Printer,
+) *Handler {
 	return &Handler{
-		prodSvc:  prodSvc,
-		transSvc: transSvc,
-		custSvc:  custSvc,
+		prodSvc:    prodSvc,
+		transSvc:   transSvc,
+		custSvc:    custSvc,
+		loyaltySvc: loyaltySvc,
+		tierSvc:    tierSvc,
+		promoSvc:   promoSvc,
+		taxSvc:     taxSvc,
+		shiftSvc:   shiftSvc,
+		storeSvc:   storeSvc,
+		invSvc:     invSvc,
+		countSvc:   countSvc,
+		printer:    printer,
 	}
 }
 
@@ -29,55 +66,444 @@ func (h *Handler) respondJSON(w http.ResponseWriter, status int, payload any) {
 	}
 }
 
-func (h *Handler) respondError(w http.ResponseWriter, status int, message string) {
-	h.respondJSON(w, status, map[string]string{"error": message})
-}
-
 // Customer Handlers
 
 // GET /customers
 func (h *Handler) ListCustomers(w http.ResponseWriter, r *http.Request) {
 	customers, err := h.custSvc.GetAllCustomers(r.Context())
 	if err != nil {
-		h.respondError(w, http.StatusInternalServerError, err.Error())
+		h.handleError(w, r, err)
 		return
 	}
 	h.respondJSON(w, http.StatusOK, customers)
 }
 
+// POST /customers
+func (h *Handler) CreateCustomer(w http.ResponseWriter, r *http.Request) {
+	var req service.CustomerRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.Customer(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	customer, err := h.custSvc.CreateCustomer(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, customer)
+}
+
+// GET /customers/{id}
+func (h *Handler) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	customer, err := h.custSvc.GetCustomer(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, customer)
+}
+
+// GET /customers/lookup?phone=08123456789 or ?email=budi@example.com
+func (h *Handler) LookupCustomer(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	customer, err := h.custSvc.LookupCustomer(r.Context(), q.Get("phone"), q.Get("email"))
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, customer)
+}
+
+// PUT /customers/{id}
+func (h *Handler) UpdateCustomer(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var req service.CustomerRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.Customer(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	customer, err := h.custSvc.UpdateCustomer(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, customer)
+}
+
+// DELETE /customers/{id}
+func (h *Handler) DeleteCustomer(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := h.custSvc.DeleteCustomer(r.Context(), id); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /customers/{id}/merge folds the customer in source_id into {id}
+func (h *Handler) MergeCustomers(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var req struct {
+		SourceID int64 `json:"source_id"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if req.SourceID <= 0 {
+		h.handleError(w, r, validation.Errors{{Field: "source_id", Message: "is required"}})
+		return
+	}
+
+	customer, err := h.custSvc.MergeCustomers(r.Context(), id, req.SourceID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, customer)
+}
+
+// GET /customers/{id}/points/history
+func (h *Handler) GetPointsHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	history, err := h.custSvc.GetPointsHistory(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, history)
+}
+
+// GET /customers/{id}/tier/history
+func (h *Handler) GetTierHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	events, err := h.tierSvc.GetTierHistory(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, events)
+}
+
+// POST /customers/{id}/points/adjustments
+func (h *Handler) AdjustPoints(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var req service.PointsAdjustmentRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.PointsAdjustment(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	entry, err := h.custSvc.AdjustPoints(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, entry)
+}
+
 // Product Handlers
 
 // POST /products
 func (h *Handler) AddProduct(w http.ResponseWriter, r *http.Request) {
 	var p domain.Product
-	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+	if err := decodeJSON(w, r, &p); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.Product(&p); err != nil {
+		h.handleError(w, r, err)
 		return
 	}
 
 	if err := h.prodSvc.AddProduct(r.Context(), &p); err != nil {
-		h.respondError(w, http.StatusInternalServerError, err.Error())
+		h.handleError(w, r, err)
 		return
 	}
 
 	h.respondJSON(w, http.StatusCreated, p)
 }
 
-// GET /products?date=2025-10-22
+// GET /products?type=&flavor=&size=&min_price=&max_price=&in_stock=&q=&date=&store_id=&sort=-price&page=1&page_size=20
 func (h *Handler) GetProducts(w http.ResponseWriter, r *http.Request) {
-	date := r.URL.Query().Get("date")
-	if date == "" {
-		h.respondError(w, http.StatusBadRequest, "date query param required (YYYY-MM-DD)")
+	filter, err := parseProductFilter(r.URL.Query())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	page, err := h.prodSvc.ListProducts(r.Context(), filter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, page)
+}
+
+// GET /products/{id}?store_id= shows the stock at one store, or across every store
+func (h *Handler) GetProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	storeID, err := parseStoreID(r.URL.Query())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	p, err := h.prodSvc.GetProduct(r.Context(), id, storeID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, p)
+}
+
+// PUT /products/{id}
+func (h *Handler) ReplaceProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var p domain.Product
+	if err := decodeJSON(w, r, &p); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	p.ID = id
+	if err := validation.Product(&p); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := h.prodSvc.UpdateProduct(r.Context(), &p); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, p)
+}
+
+// PATCH /products/{id}?store_id= patches the quantity at one store, the main store by default
+func (h *Handler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	storeID, err := parseStoreID(r.URL.Query())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if storeID == nil {
+		mainStore := domain.MainStoreID
+		storeID = &mainStore
+	}
+
+	var patch domain.ProductPatch
+	if err := decodeJSON(w, r, &patch); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	p, err := h.prodSvc.GetProduct(r.Context(), id, storeID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	patch.Apply(p)
+	if err := validation.Product(p); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := h.prodSvc.UpdateProduct(r.Context(), p); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, p)
+}
+
+// DELETE /products/{id} archives the product; its sales history is kept
+func (h *Handler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := h.prodSvc.ArchiveProduct(r.Context(), id); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /products/{id}/batches?store_id= lists the batches in stock, next to be sold first
+func (h *Handler) ListBatches(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	storeID, err := parseStoreID(r.URL.Query())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	batches, err := h.prodSvc.ListBatches(r.Context(), id, storeID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, batches)
+}
+
+// POST /products/{id}/batches
+func (h *Handler) ReceiveBatch(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var req service.BatchRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.Batch(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	batch, err := h.prodSvc.ReceiveBatch(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, batch)
+}
+
+// GET /products/{id}/movements?store_id=&kind=&start=&end= is the product's stock audit trail
+func (h *Handler) ListMovements(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	filter, err := parseMovementFilter(r.URL.Query())
+	if err != nil {
+		h.handleError(w, r, err)
 		return
 	}
+	filter.ProductID = id
 
-	products, err := h.prodSvc.GetProductsByDate(r.Context(), date)
+	movements, err := h.prodSvc.ListMovements(r.Context(), filter)
 	if err != nil {
-		h.respondError(w, http.StatusInternalServerError, err.Error())
+		h.handleError(w, r, err)
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, products)
+	h.respondJSON(w, http.StatusOK, movements)
+}
+
+// POST /products/{id}/movements restocks or adjusts stock by hand
+func (h *Handler) MoveStock(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var req service.MovementRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.StockMovement(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	movements, err := h.prodSvc.MoveStock(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, movements)
 }
 
 // Transaction Handlers
@@ -85,54 +511,906 @@ func (h *Handler) GetProducts(w http.ResponseWriter, r *http.Request) {
 // POST /transactions
 func (h *Handler) CreateTransaction(w http.ResponseWriter, r *http.Request) {
 	var req service.PurchaseRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.Purchase(&req); err != nil {
+		h.handleError(w, r, err)
 		return
 	}
 
-	if err := h.transSvc.Purchase(r.Context(), req); err != nil {
-		h.respondError(w, http.StatusInternalServerError, err.Error())
+	tx, err := h.transSvc.Purchase(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
 		return
 	}
 
-	h.respondJSON(w, http.StatusCreated, map[string]string{"message": "Transaction successful"})
+	h.respondJSON(w, http.StatusCreated, tx)
 }
 
-// POST /redemptions (Redeem Points)
-func (h *Handler) Redeem(w http.ResponseWriter, r *http.Request) {
-	type RedeemReq struct {
-		CustomerName string `json:"customer_name"`
-		ProductID    int64  `json:"product_id"`
+// GET /transactions/{id}/receipt?format=text|pdf prints the sale the transaction belongs to
+func (h *Handler) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.handleError(w, r, domain.NewValidationError("invalid transaction id"))
+		return
 	}
-	var req RedeemReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+	format, err := parseReceiptFormat(r.URL.Query())
+	if err != nil {
+		h.handleError(w, r, err)
 		return
 	}
 
-	if err := h.transSvc.Redeem(r.Context(), req.CustomerName, req.ProductID); err != nil {
-		h.respondError(w, http.StatusBadRequest, err.Error())
+	rec, err := h.transSvc.GetReceipt(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, map[string]string{"message": "Redemption successful"})
+	if format == "pdf" {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="receipt-%s.pdf"`, rec.Number))
+		w.Write(h.printer.PDF(rec))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(h.printer.Text(rec))
 }
 
-// GET /transactions?start=2025-10-01&end=2025-12-31
-func (h *Handler) GetReport(w http.ResponseWriter, r *http.Request) {
-	start := r.URL.Query().Get("start")
-	end := r.URL.Query().Get("end")
+// POST /transactions/{id}/refund
+func (h *Handler) RefundTransaction(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.handleError(w, r, domain.NewValidationError("invalid transaction id"))
+		return
+	}
 
-	if start == "" {
-		h.respondError(w, http.StatusBadRequest, "start date required")
+	// quantity 0 refunds the whole remaining quantity
+	var req struct {
+		Quantity int   `json:"quantity"`
+		ShiftID  int64 `json:"shift_id"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil && !errors.Is(err, errEmptyBody) {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.Refund(req.Quantity, req.ShiftID); err != nil {
+		h.handleError(w, r, err)
 		return
 	}
 
-	report, err := h.transSvc.GetReport(r.Context(), start, end)
+	refund, err := h.transSvc.Refund(r.Context(), id, req.Quantity, req.ShiftID)
 	if err != nil {
-		h.respondError(w, http.StatusInternalServerError, err.Error())
+		h.handleError(w, r, err)
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, report)
+	h.respondJSON(w, http.StatusCreated, refund)
+}
+
+// POST /orders
+func (h *Handler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	var req service.CheckoutRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.Checkout(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	order, err := h.transSvc.Checkout(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, order)
+}
+
+// POST /redemptions (Redeem Points)
+func (h *Handler) Redeem(w http.ResponseWriter, r *http.Request) {
+	var req service.RedeemRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.Redeem(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	redemption, err := h.transSvc.Redeem(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, redemption)
+}
+
+// GET /redemptions?start=2025-10-01&end=2025-12-31&customer_id=5
+func (h *Handler) GetRedemptions(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseRedemptionFilter(r.URL.Query())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	redemptions, err := h.transSvc.ListRedemptions(r.Context(), filter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, redemptions)
+}
+
+// GET /transactions?start=2025-10-01&end=2025-12-31&store_id= reports one store, or every store
+func (h *Handler) GetReport(w http.ResponseWriter, r *http.Request) {
+	start := r.URL.Query().Get("start")
+	end := r.URL.Query().Get("end")
+
+	if start == "" {
+		h.handleError(w, r, domain.NewValidationError("start date required"))
+		return
+	}
+	storeID, err := parseStoreID(r.URL.Query())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	report, err := h.transSvc.GetReport(r.Context(), start, end, storeID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, report)
+}
+
+// Loyalty Rule Handlers
+
+// GET /loyalty/rules
+func (h *Handler) GetLoyaltyRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.loyaltySvc.GetRules(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, rules)
+}
+
+// POST /loyalty/rules/earn
+func (h *Handler) CreateEarnRule(w http.ResponseWriter, r *http.Request) {
+	var req service.EarnRuleRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.EarnRule(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	rule, err := h.loyaltySvc.CreateEarnRule(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, rule)
+}
+
+// PUT /loyalty/rules/earn/{id}
+func (h *Handler) UpdateEarnRule(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var req service.EarnRuleRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.EarnRule(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	rule, err := h.loyaltySvc.UpdateEarnRule(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, rule)
+}
+
+// POST /loyalty/rules/bonus
+func (h *Handler) CreateBonusRule(w http.ResponseWriter, r *http.Request) {
+	var req service.BonusRuleRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.BonusRule(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	rule, err := h.loyaltySvc.CreateBonusRule(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, rule)
+}
+
+// PUT /loyalty/rules/bonus/{id}
+func (h *Handler) UpdateBonusRule(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var req service.BonusRuleRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.BonusRule(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	rule, err := h.loyaltySvc.UpdateBonusRule(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, rule)
+}
+
+// POST /loyalty/rules/redeem
+func (h *Handler) CreateRedeemRule(w http.ResponseWriter, r *http.Request) {
+	var req service.RedeemRuleRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.RedeemRule(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	rule, err := h.loyaltySvc.CreateRedeemRule(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, rule)
+}
+
+// PUT /loyalty/rules/redeem/{id}
+func (h *Handler) UpdateRedeemRule(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var req service.RedeemRuleRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.RedeemRule(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	rule, err := h.loyaltySvc.UpdateRedeemRule(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, rule)
+}
+
+// PUT /loyalty/tiers/{tier}
+func (h *Handler) UpdateTier(w http.ResponseWriter, r *http.Request) {
+	var req service.TierRuleRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.TierRule(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	tier, err := h.loyaltySvc.UpdateTier(r.Context(), domain.CustomerTier(r.PathValue("tier")), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, tier)
+}
+
+// DELETE /loyalty/rules/{kind}/{id} retires a rule; sales keep referring to it
+func (h *Handler) DeactivateLoyaltyRule(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	kind := domain.LoyaltyRuleKind(r.PathValue("kind"))
+	if err := h.loyaltySvc.DeactivateRule(r.Context(), kind, id); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Promotion Handlers
+
+// GET /promotions
+func (h *Handler) ListPromotions(w http.ResponseWriter, r *http.Request) {
+	promos, err := h.promoSvc.ListPromotions(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, promos)
+}
+
+// POST /promotions
+func (h *Handler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	var req service.PromotionRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.Promotion(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	promo, err := h.promoSvc.CreatePromotion(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, promo)
+}
+
+// PUT /promotions/{id}
+func (h *Handler) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var req service.PromotionRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.Promotion(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	promo, err := h.promoSvc.UpdatePromotion(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, promo)
+}
+
+// DELETE /promotions/{id} retires a promotion; sales keep referring to it
+func (h *Handler) DeactivatePromotion(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := h.promoSvc.DeactivatePromotion(r.Context(), id); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Tax Handlers
+
+// GET /tax-rates
+func (h *Handler) ListTaxRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.taxSvc.ListTaxRates(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, rates)
+}
+
+// POST /tax-rates
+func (h *Handler) CreateTaxRate(w http.ResponseWriter, r *http.Request) {
+	var req service.TaxRateRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.TaxRate(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	rate, err := h.taxSvc.CreateTaxRate(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, rate)
+}
+
+// PUT /tax-rates/{id}
+func (h *Handler) UpdateTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var req service.TaxRateRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.TaxRate(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	rate, err := h.taxSvc.UpdateTaxRate(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, rate)
+}
+
+// DELETE /tax-rates/{id} retires a rate; sales keep the rate they were made with
+func (h *Handler) DeactivateTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := h.taxSvc.DeactivateTaxRate(r.Context(), id); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Shift Handlers
+
+// POST /shifts opens a shift on a till
+func (h *Handler) OpenShift(w http.ResponseWriter, r *http.Request) {
+	var req service.OpenShiftRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.OpenShift(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	shift, err := h.shiftSvc.OpenShift(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, shift)
+}
+
+// POST /shifts/{id}/close counts the drawer and returns the Z-report
+func (h *Handler) CloseShift(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var req service.CloseShiftRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.CloseShift(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	report, err := h.shiftSvc.CloseShift(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, report)
+}
+
+// GET /shifts/{id}/report
+func (h *Handler) GetShiftReport(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	report, err := h.shiftSvc.GetShiftReport(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, report)
+}
+
+// Store Handlers
+
+// GET /stores
+func (h *Handler) ListStores(w http.ResponseWriter, r *http.Request) {
+	stores, err := h.storeSvc.ListStores(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, stores)
+}
+
+// POST /stores
+func (h *Handler) CreateStore(w http.ResponseWriter, r *http.Request) {
+	var req service.StoreRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.Store(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	store, err := h.storeSvc.CreateStore(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, store)
+}
+
+// PUT /stores/{id}
+func (h *Handler) UpdateStore(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var req service.StoreRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.Store(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	store, err := h.storeSvc.UpdateStore(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, store)
+}
+
+// Inventory Handlers
+
+// GET /shelf-lives
+func (h *Handler) ListShelfLives(w http.ResponseWriter, r *http.Request) {
+	lives, err := h.invSvc.ListShelfLives(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, lives)
+}
+
+// PUT /shelf-lives/{type} sets the shelf life of a product type
+func (h *Handler) SetShelfLife(w http.ResponseWriter, r *http.Request) {
+	var req service.ShelfLifeRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	req.ProductType = r.PathValue("type")
+	if err := validation.ShelfLife(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	life, err := h.invSvc.SetShelfLife(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, life)
+}
+
+// DELETE /shelf-lives/{type}
+func (h *Handler) DeleteShelfLife(w http.ResponseWriter, r *http.Request) {
+	if err := h.invSvc.DeleteShelfLife(r.Context(), r.PathValue("type")); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /inventory/expiring?within=14d&store_id=
+func (h *Handler) ListExpiring(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseExpiryFilter(r.URL.Query())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	batches, err := h.invSvc.ListExpiring(r.Context(), filter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, batches)
+}
+
+// POST /inventory/write-offs writes off expired stock
+func (h *Handler) WriteOffExpired(w http.ResponseWriter, r *http.Request) {
+	var req service.WriteOffRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.WriteOff(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	writeOffs, err := h.invSvc.WriteOffExpired(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, writeOffs)
+}
+
+// GET /inventory/write-offs?start=&end=&store_id=
+func (h *Handler) ListWriteOffs(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseWriteOffFilter(r.URL.Query())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	writeOffs, err := h.invSvc.ListWriteOffs(r.Context(), filter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, writeOffs)
+}
+
+// PUT /products/{id}/reorder-level
+func (h *Handler) SetReorderLevel(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var req service.ReorderLevelRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.ReorderLevel(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	level, err := h.invSvc.SetReorderLevel(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, level)
+}
+
+// DELETE /products/{id}/reorder-level
+func (h *Handler) DeleteReorderLevel(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := h.invSvc.DeleteReorderLevel(r.Context(), id); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /inventory/low-stock?store_id=
+func (h *Handler) ListLowStock(w http.ResponseWriter, r *http.Request) {
+	storeID, err := parseStoreID(r.URL.Query())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	levels, err := h.invSvc.ListLowStock(r.Context(), storeID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, levels)
+}
+
+// GET /inventory/reorder-suggestions?store_id=&lookback=30d&cover=14d
+func (h *Handler) SuggestReorders(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseReorderFilter(r.URL.Query())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	suggestions, err := h.invSvc.SuggestReorders(r.Context(), filter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, suggestions)
+}
+
+// Stocktake Handlers
+
+// POST /stocktakes opens a count at a store
+func (h *Handler) OpenStocktake(w http.ResponseWriter, r *http.Request) {
+	var req service.OpenStocktakeRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.OpenStocktake(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	stocktake, err := h.countSvc.OpenStocktake(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, stocktake)
+}
+
+// GET /stocktakes/{id} returns the counts with their variance
+func (h *Handler) GetStocktake(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	stocktake, err := h.countSvc.GetStocktake(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, stocktake)
+}
+
+// PUT /stocktakes/{id}/counts records counts in bulk
+func (h *Handler) SubmitStocktakeCounts(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var req service.StocktakeCountsRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.StocktakeCounts(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	stocktake, err := h.countSvc.SubmitCounts(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, stocktake)
+}
+
+// POST /stocktakes/{id}/finalize posts the variances as stock adjustments
+func (h *Handler) FinalizeStocktake(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	var req service.FinalizeStocktakeRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := validation.FinalizeStocktake(&req); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	stocktake, err := h.countSvc.FinalizeStocktake(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, stocktake)
 }
@@ -0,0 +1,223 @@
+package http
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/service"
+	"bsnack/internal/validation"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// pathID parses a numeric path parameter such as /products/{id}
+func pathID(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, domain.NewValidationError("invalid " + name)
+	}
+	return id, nil
+}
+
+// parseProductFilter reads the GET /products query string, reporting every bad parameter at once
+func parseProductFilter(q url.Values) (domain.ProductFilter, error) {
+	var v validation.Validator
+	f := domain.ProductFilter{
+		Type:              q.Get("type"),
+		Flavor:            q.Get("flavor"),
+		Size:              domain.ProductSize(q.Get("size")),
+		Search:            strings.TrimSpace(q.Get("q")),
+		ManufacturingDate: q.Get("date"),
+		Sort:              q.Get("sort"),
+	}
+
+	if f.Size != "" {
+		v.Check(f.Size.IsValid(), "size", "must be one of Small, Medium, Large")
+	}
+	v.Date(f.ManufacturingDate, "date", true)
+	if f.Sort != "" {
+		v.Check(slices.Contains(domain.ProductSortFields, strings.TrimPrefix(f.Sort, "-")),
+			"sort", "must be one of "+strings.Join(domain.ProductSortFields, ", ")+" (prefix - for descending)")
+	}
+
+	f.MinPrice = queryMoney(&v, q, "min_price")
+	f.MaxPrice = queryMoney(&v, q, "max_price")
+	if f.MinPrice != nil && f.MaxPrice != nil {
+		v.Check(*f.MinPrice <= *f.MaxPrice, "max_price", "must not be less than min_price")
+	}
+
+	if raw := q.Get("in_stock"); raw != "" {
+		inStock, err := strconv.ParseBool(raw)
+		v.Check(err == nil, "in_stock", "must be true or false")
+		f.InStock = inStock
+	}
+	f.StoreID = queryID(&v, q, "store_id")
+	f.Page = queryInt(&v, q, "page")
+	f.PageSize = queryInt(&v, q, "page_size")
+
+	return f, v.Err()
+}
+
+// parseStoreID reads the optional store_id parameter; nil means every store
+func parseStoreID(q url.Values) (*int64, error) {
+	var v validation.Validator
+	id := queryID(&v, q, "store_id")
+	return id, v.Err()
+}
+
+// movementKinds are the kinds GET /products/{id}/movements filters on
+var movementKinds = []domain.MovementKind{
+	domain.MovementOpening, domain.MovementSale, domain.MovementRedemption, domain.MovementRefund,
+	domain.MovementRestock, domain.MovementAdjustment, domain.MovementWriteOff,
+}
+
+// parseMovementFilter reads the GET /products/{id}/movements query string; every parameter is optional
+func parseMovementFilter(q url.Values) (domain.MovementFilter, error) {
+	var v validation.Validator
+	f := domain.MovementFilter{
+		Kind:      domain.MovementKind(q.Get("kind")),
+		StartDate: q.Get("start"),
+		EndDate:   q.Get("end"),
+	}
+
+	f.StoreID = queryID(&v, q, "store_id")
+	if f.Kind != "" {
+		v.Check(slices.Contains(movementKinds, f.Kind), "kind", "must be a stock movement kind")
+	}
+	v.Date(f.StartDate, "start", true)
+	v.Date(f.EndDate, "end", true)
+	if f.StartDate != "" && f.EndDate != "" {
+		v.Check(f.StartDate <= f.EndDate, "end", "must not be before start")
+	}
+
+	return f, v.Err()
+}
+
+// parseReceiptFormat reads the receipt format, text unless pdf is asked for
+func parseReceiptFormat(q url.Values) (string, error) {
+	var v validation.Validator
+	format := q.Get("format")
+	if format == "" {
+		format = "text"
+	}
+	v.Check(format == "text" || format == "pdf", "format", "must be text or pdf")
+	return format, v.Err()
+}
+
+// parseRedemptionFilter reads the GET /redemptions query string; every parameter is optional
+func parseRedemptionFilter(q url.Values) (domain.RedemptionFilter, error) {
+	var v validation.Validator
+	f := domain.RedemptionFilter{
+		StartDate: q.Get("start"),
+		EndDate:   q.Get("end"),
+	}
+
+	v.Date(f.StartDate, "start", true)
+	v.Date(f.EndDate, "end", true)
+	if f.StartDate != "" && f.EndDate != "" {
+		v.Check(f.StartDate <= f.EndDate, "end", "must not be before start")
+	}
+	if raw := q.Get("customer_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		v.Check(err == nil && id > 0, "customer_id", "must be a positive id")
+		f.CustomerID = id
+	}
+	if id := queryID(&v, q, "store_id"); id != nil {
+		f.StoreID = *id
+	}
+
+	return f, v.Err()
+}
+
+// day windows of the inventory reports: their defaults, and the longest one accepted
+const (
+	defaultExpiryWindow = 14
+	defaultLookback     = 30
+	defaultCover        = 14
+	maxDayWindow        = 365
+)
+
+// parseExpiryFilter reads the GET /inventory/expiring query string
+func parseExpiryFilter(q url.Values) (domain.ExpiryFilter, error) {
+	var v validation.Validator
+	f := domain.ExpiryFilter{
+		WithinDays: queryDays(&v, q, "within", defaultExpiryWindow, 0),
+		StoreID:    queryID(&v, q, "store_id"),
+	}
+	return f, v.Err()
+}
+
+// parseReorderFilter reads the GET /inventory/reorder-suggestions query string
+func parseReorderFilter(q url.Values) (service.ReorderFilter, error) {
+	var v validation.Validator
+	f := service.ReorderFilter{
+		StoreID:      queryID(&v, q, "store_id"),
+		LookbackDays: queryDays(&v, q, "lookback", defaultLookback, 1),
+		CoverDays:    queryDays(&v, q, "cover", defaultCover, 1),
+	}
+	return f, v.Err()
+}
+
+// queryDays reads a number of days, written 14d or 14, from least to maxDayWindow
+func queryDays(v *validation.Validator, q url.Values, name string, def, least int) int {
+	raw := q.Get(name)
+	if raw == "" {
+		return def
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(raw, "d"))
+	v.Check(err == nil && days >= least && days <= maxDayWindow, name,
+		fmt.Sprintf("must be a number of days from %dd to %dd", least, maxDayWindow))
+	return days
+}
+
+// parseWriteOffFilter reads the GET /inventory/write-offs query string; every parameter is optional
+func parseWriteOffFilter(q url.Values) (domain.WriteOffFilter, error) {
+	var v validation.Validator
+	f := domain.WriteOffFilter{
+		StartDate: q.Get("start"),
+		EndDate:   q.Get("end"),
+	}
+
+	v.Date(f.StartDate, "start", true)
+	v.Date(f.EndDate, "end", true)
+	if f.StartDate != "" && f.EndDate != "" {
+		v.Check(f.StartDate <= f.EndDate, "end", "must not be before start")
+	}
+	if id := queryID(&v, q, "store_id"); id != nil {
+		f.StoreID = *id
+	}
+
+	return f, v.Err()
+}
+
+func queryMoney(v *validation.Validator, q url.Values, key string) *domain.Money {
+	raw := q.Get(key)
+	if raw == "" {
+		return nil
+	}
+	m, err := domain.ParseMoney(raw)
+	v.Check(err == nil && m >= 0, key, "must be a non-negative amount")
+	return &m
+}
+
+func queryID(v *validation.Validator, q url.Values, key string) *int64 {
+	raw := q.Get(key)
+	if raw == "" {
+		return nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	v.Check(err == nil && id > 0, key, "must be a positive id")
+	return &id
+}
+
+func queryInt(v *validation.Validator, q url.Values, key string) int {
+	raw := q.Get(key)
+	if raw == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(raw)
+	v.Check(err == nil && n > 0, key, "must be a positive integer")
+	return n
+}
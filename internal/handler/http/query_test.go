@@ -0,0 +1,119 @@
+package http
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/validation"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProductFilter(t *testing.T) {
+	q, _ := url.ParseQuery("type=Keripik&size=Small&min_price=1000&max_price=25000.50&in_stock=true&q=pangsit&sort=-price&page=2&page_size=10")
+
+	f, err := parseProductFilter(q)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Keripik", f.Type)
+	assert.Equal(t, domain.SizeSmall, f.Size)
+	assert.Equal(t, domain.NewMoney(1000), *f.MinPrice)
+	assert.Equal(t, domain.Money(2500050), *f.MaxPrice)
+	assert.True(t, f.InStock)
+	assert.Equal(t, "pangsit", f.Search)
+	assert.Equal(t, "-price", f.Sort)
+	assert.Equal(t, 2, f.Page)
+	assert.Equal(t, 10, f.PageSize)
+}
+
+func TestParseProductFilter_Invalid(t *testing.T) {
+	q, _ := url.ParseQuery("size=Jumbo&min_price=500&max_price=100&in_stock=maybe&sort=colour&page=0&date=2025-13-01")
+
+	_, err := parseProductFilter(q)
+
+	var errs validation.Errors
+	assert.ErrorAs(t, err, &errs)
+	var fields []string
+	for _, fe := range errs {
+		fields = append(fields, fe.Field)
+	}
+	assert.Equal(t, []string{"size", "date", "sort", "max_price", "in_stock", "page"}, fields)
+}
+
+func TestParseRedemptionFilter(t *testing.T) {
+	q, _ := url.ParseQuery("start=2025-10-01&end=2025-12-31&customer_id=5")
+
+	f, err := parseRedemptionFilter(q)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RedemptionFilter{StartDate: "2025-10-01", EndDate: "2025-12-31", CustomerID: 5}, f)
+
+	q, _ = url.ParseQuery("start=2025-12-31&end=2025-10-01&customer_id=abc")
+	_, err = parseRedemptionFilter(q)
+
+	var errs validation.Errors
+	if assert.ErrorAs(t, err, &errs) {
+		assert.Equal(t, "end", errs[0].Field)
+		assert.Equal(t, "customer_id", errs[1].Field)
+	}
+}
+
+func TestParseExpiryFilter(t *testing.T) {
+	f, err := parseExpiryFilter(url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ExpiryFilter{WithinDays: 14}, f)
+
+	q, _ := url.ParseQuery("within=30d&store_id=2")
+	f, err = parseExpiryFilter(q)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, f.WithinDays)
+	assert.Equal(t, int64(2), *f.StoreID)
+
+	for _, within := range []string{"2w", "-1d", "366d"} {
+		_, err = parseExpiryFilter(url.Values{"within": {within}})
+		assert.ErrorIs(t, err, domain.ErrValidation, within)
+	}
+}
+
+func TestParseMovementFilter(t *testing.T) {
+	q, _ := url.ParseQuery("store_id=2&kind=write_off&start=2025-12-01&end=2025-12-31")
+
+	f, err := parseMovementFilter(q)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), *f.StoreID)
+	assert.Equal(t, domain.MovementWriteOff, f.Kind)
+	assert.Equal(t, "2025-12-01", f.StartDate)
+
+	_, err = parseMovementFilter(url.Values{"kind": {"theft"}})
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
+
+func TestParseReorderFilter(t *testing.T) {
+	f, err := parseReorderFilter(url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, 30, f.LookbackDays)
+	assert.Equal(t, 14, f.CoverDays)
+
+	q, _ := url.ParseQuery("lookback=0d&cover=400")
+	_, err = parseReorderFilter(q)
+
+	var errs validation.Errors
+	if assert.ErrorAs(t, err, &errs) {
+		assert.Equal(t, "lookback", errs[0].Field)
+		assert.Equal(t, "cover", errs[1].Field)
+	}
+}
+
+func TestParseReceiptFormat(t *testing.T) {
+	format, err := parseReceiptFormat(url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, "text", format)
+
+	format, err = parseReceiptFormat(url.Values{"format": {"pdf"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "pdf", format)
+
+	_, err = parseReceiptFormat(url.Values{"format": {"html"}})
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
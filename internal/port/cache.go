@@ -8,8 +8,9 @@ import (
 
 // CacheRepository defines Redis operations
 type CacheRepository interface {
-	GetReport(ctx context.Context, start, end string) (*domain.SalesReport, error)
-	SetReport(ctx context.Context, start, end string, report *domain.SalesReport, ttl time.Duration) error
+	// GetReport and SetReport key reports by store; a nil storeID is the consolidated report
+	GetReport(ctx context.Context, start, end string, storeID *int64) (*domain.SalesReport, error)
+	SetReport(ctx context.Context, start, end string, storeID *int64, report *domain.SalesReport, ttl time.Duration) error
 
 	InvalidateProducts(ctx context.Context, date string) error
 }
@@ -3,27 +3,252 @@ package port
 import (
 	"bsnack/internal/domain"
 	"context"
+	"time"
+
+	"github.com/google/uuid"
 )
 
-// ProductRepository defines interactions with product data
+// ProductRepository defines interactions with product data. Stock is kept per store
+// in batches; a product's quantity is the sum of its batches.
 type ProductRepository interface {
+	// Create adds the product with p.Quantity in stock at p.StoreID, as a batch made
+	// on p.ManufacturingDate
 	Create(ctx context.Context, p *domain.Product) error
-	GetByDate(ctx context.Context, date string) ([]domain.Product, error)
+	// GetByID ignores archived products and returns the stock across every store
 	GetByID(ctx context.Context, id int64) (*domain.Product, error)
-	UpdateStock(ctx context.Context, id int64, delta int) error
+	// GetAtStore is GetByID with the stock held at one store
+	GetAtStore(ctx context.Context, storeID, id int64) (*domain.Product, error)
+	// GetAtStoreForUpdate is GetAtStore holding the product's batches at the store until
+	// the unit of work ends, so the stock cannot change before it is corrected
+	GetAtStoreForUpdate(ctx context.Context, storeID, id int64) (*domain.Product, error)
+	List(ctx context.Context, f domain.ProductFilter) ([]domain.Product, int, error)
+	// Update replaces the product's catalog details; stock is changed through batches
+	Update(ctx context.Context, p *domain.Product) error
+	// Archive soft deletes the product so past transactions keep their reference
+	Archive(ctx context.Context, id int64) error
+	// ListBatches returns the batches with stock left, at one store or every store
+	// when storeID is nil, in the order sales take from them
+	ListBatches(ctx context.Context, id int64, storeID *int64) ([]domain.Batch, error)
+	// ReceiveBatch adds a batch to the store's stock. A lot the store already holds is
+	// topped up when its dates match and is a conflict otherwise.
+	ReceiveBatch(ctx context.Context, b *domain.Batch) error
+	// DecrementStock removes qty units from the store's unexpired stock, from the batch
+	// that expires first, then the oldest, and returns what it took from each batch. It
+	// returns domain.ErrExpiredStock when only expired units would cover qty and
+	// domain.ErrInsufficientStock instead of letting quantity go below zero; run it in
+	// a unit of work, which holds the batches until it ends.
+	DecrementStock(ctx context.Context, storeID, id int64, qty int) ([]domain.BatchAllocation, error)
+	// RestoreStock puts the quantities back into the batches they were taken from
+	RestoreStock(ctx context.Context, batches []domain.BatchAllocation) error
+}
+
+// ShelfLifeRepository stores the shelf life of each product type
+type ShelfLifeRepository interface {
+	ListAll(ctx context.Context) ([]domain.ShelfLife, error)
+	// Set creates or replaces the shelf life of s.ProductType
+	Set(ctx context.Context, s *domain.ShelfLife) error
+	Delete(ctx context.Context, productType string) error
+}
+
+// InventoryRepository reports on stock. It writes off stock by its expiry date, which is
+// the date a batch was received with or else its product type's shelf life after it was
+// made, and checks sellable stock against reorder levels.
+type InventoryRepository interface {
+	// ListExpiring returns the batches in stock that expire within f.WithinDays of
+	// today, including those already expired, soonest first
+	ListExpiring(ctx context.Context, f domain.ExpiryFilter) ([]domain.ExpiringBatch, error)
+	// WriteOffExpired empties every expired batch, at one store and of one product when
+	// they are given, recording a write-off for each
+	WriteOffExpired(ctx context.Context, storeID, productID *int64, reason, actor string) ([]domain.WriteOff, error)
+	// ListWriteOffs returns write-offs matching the filter, newest first
+	ListWriteOffs(ctx context.Context, f domain.WriteOffFilter) ([]domain.WriteOff, error)
+	// ListLowStock returns the products whose sellable stock at an active store, or at
+	// the one store given, is at or below their reorder point
+	ListLowStock(ctx context.Context, storeID *int64) ([]domain.StockLevel, error)
+	// ListSalesVelocity returns, per active store or the one store given, the products
+	// sold over the last lookbackDays or with a reorder level, with the units sold
+	ListSalesVelocity(ctx context.Context, storeID *int64, lookbackDays int) ([]domain.ReorderSuggestion, error)
+}
+
+// ReorderLevelRepository stores the reorder level of each product
+type ReorderLevelRepository interface {
+	// Set creates or replaces the reorder level of l.ProductID
+	Set(ctx context.Context, l *domain.ReorderLevel) error
+	Delete(ctx context.Context, productID int64) error
 }
 
-// CustomerRepository defines interactions with customer data
+// StockMovementRepository is the ledger of every change to stock, one movement per
+// batch touched. Record it in the unit of work that changes the stock.
+type StockMovementRepository interface {
+	// Record adds the movements, filling in the store, product and lot of each from its batch
+	Record(ctx context.Context, movements []domain.StockMovement) error
+	// List returns a product's movements matching the filter, oldest first, with the
+	// stock its store held after each
+	List(ctx context.Context, f domain.MovementFilter) ([]domain.StockMovement, error)
+}
+
+// StocktakeRepository stores stocktakes and their counts; a store has at most one
+// stocktake open
+type StocktakeRepository interface {
+	// Create opens the stocktake; it is a conflict when the store already has one open
+	Create(ctx context.Context, s *domain.Stocktake) error
+	// GetByID returns the stocktake with its lines reconciled against the stock the
+	// store held when each was counted
+	GetByID(ctx context.Context, id int64) (*domain.Stocktake, error)
+	// GetForUpdate is GetByID holding the stocktake until the unit of work ends, so
+	// counts and finalizing do not run side by side
+	GetForUpdate(ctx context.Context, id int64) (*domain.Stocktake, error)
+	// SaveCounts records the counts with their system quantities, replacing earlier
+	// counts of the same products
+	SaveCounts(ctx context.Context, id int64, lines []domain.StocktakeLine) error
+	// Finalize closes the stocktake
+	Finalize(ctx context.Context, s *domain.Stocktake) error
+}
+
+// StoreRepository stores the outlets; stores are deactivated, never deleted
+type StoreRepository interface {
+	ListAll(ctx context.Context) ([]domain.Store, error)
+	GetByID(ctx context.Context, id int64) (*domain.Store, error)
+	Create(ctx context.Context, s *domain.Store) error
+	Update(ctx context.Context, s *domain.Store) error
+}
+
+// CustomerRepository defines interactions with customer data.
+// Deleted and merged customers are invisible to every lookup.
 type CustomerRepository interface {
+	GetByID(ctx context.Context, id int64) (*domain.Customer, error)
+	// GetForUpdate is GetByID holding the customer until the unit of work ends
+	GetForUpdate(ctx context.Context, id int64) (*domain.Customer, error)
+	// GetByName returns a conflict error when several customers share the name
 	GetByName(ctx context.Context, name string) (*domain.Customer, error)
+	// LockName serialises registrations under one name until the unit of work ends
+	LockName(ctx context.Context, name string) error
+	GetByPhone(ctx context.Context, phone string) (*domain.Customer, error)
+	GetByEmail(ctx context.Context, email string) (*domain.Customer, error)
 	Create(ctx context.Context, c *domain.Customer) error
+	Update(ctx context.Context, c *domain.Customer) error
+	Delete(ctx context.Context, id int64) error
+	// MarkMerged retires source after its history and points moved to target
+	MarkMerged(ctx context.Context, sourceID, targetID int64) error
 	UpdatePoints(ctx context.Context, id int64, points int) error
 	ListAll(ctx context.Context) ([]domain.Customer, error)
+	// ChangeTier moves the customer to e.ToTier and records the event
+	ChangeTier(ctx context.Context, e *domain.TierEvent) error
+	ListTierEvents(ctx context.Context, customerID int64) ([]domain.TierEvent, error)
 }
 
 // TransactionRepository defines interactions with sales data
 type TransactionRepository interface {
 	Create(ctx context.Context, t *domain.Transaction) error
-	// GetReport aggregates data for the specific date range
-	GetReport(ctx context.Context, startDate, endDate string) (*domain.SalesReport, error)
+	// GetByID locks the row when bound to a unit of work so concurrent refunds serialize
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Transaction, error)
+	// GetReceipt loads the sale the transaction belongs to, with product names and discounts
+	GetReceipt(ctx context.Context, id uuid.UUID) (*domain.Receipt, error)
+	// GetRefundedQuantity returns the units already refunded against a transaction
+	GetRefundedQuantity(ctx context.Context, id uuid.UUID) (int, error)
+	// GetPointsBasis returns the net accrued point units and net points of the purchase
+	// the transaction belongs to: its order when it has one, otherwise itself
+	GetPointsBasis(ctx context.Context, t *domain.Transaction) (netUnits int64, netPoints int, err error)
+	// GetRefundedCoupon returns the coupon used on the purchase the transaction belongs to,
+	// its order when it has one, once every unit of it has been refunded; nil otherwise
+	GetRefundedCoupon(ctx context.Context, t *domain.Transaction) (*int64, error)
+	// RollingSpend returns each customer's net spend on transactions dated after since
+	RollingSpend(ctx context.Context, since time.Time) (map[int64]domain.Money, error)
+	ReassignCustomer(ctx context.Context, fromID, toID int64) error
+	// GetReport aggregates data for the specific date range at one store, or at every
+	// store when storeID is nil
+	GetReport(ctx context.Context, startDate, endDate string, storeID *int64) (*domain.SalesReport, error)
+	// GetShiftReport totals the sales, refunds and payments taken on the shift
+	GetShiftReport(ctx context.Context, shift *domain.Shift) (*domain.ShiftReport, error)
+}
+
+// OrderRepository defines interactions with order headers
+type OrderRepository interface {
+	Create(ctx context.Context, o *domain.Order) error
+	ReassignCustomer(ctx context.Context, fromID, toID int64) error
+}
+
+// PromotionRepository stores the discounts applied at checkout
+type PromotionRepository interface {
+	// ActivePromotions returns every active promotion; date filtering is left to the caller
+	ActivePromotions(ctx context.Context) ([]domain.Promotion, error)
+	ListAll(ctx context.Context) ([]domain.Promotion, error)
+	Create(ctx context.Context, p *domain.Promotion) error
+	Update(ctx context.Context, p *domain.Promotion) error
+	// Deactivate retires a promotion; promotions are never deleted because sales reference them
+	Deactivate(ctx context.Context, id int64) error
+	// UseCoupon counts one use of a coupon, returning a conflict error once its usage limit is reached
+	UseCoupon(ctx context.Context, id int64) error
+	// ReleaseCoupon gives back one use of a coupon whose sale was refunded in full
+	ReleaseCoupon(ctx context.Context, id int64) error
+}
+
+// ShiftRepository stores cashier shifts; a till has at most one open shift
+type ShiftRepository interface {
+	// Create opens the shift, returning a conflict error when its till already has one open
+	Create(ctx context.Context, s *domain.Shift) error
+	// GetByID holds the shift open for the rest of the unit of work
+	GetByID(ctx context.Context, id int64) (*domain.Shift, error)
+	// GetForClose locks the shift against sales for the rest of the unit of work
+	GetForClose(ctx context.Context, id int64) (*domain.Shift, error)
+	// Close records the closing count, returning a not found error if the shift is already closed
+	Close(ctx context.Context, s *domain.Shift) error
+}
+
+// PaymentRepository records the tenders taken for sales and paid out for refunds
+type PaymentRepository interface {
+	Create(ctx context.Context, p *domain.Payment) error
+}
+
+// TaxRateRepository stores the PPN rates applied to sales
+type TaxRateRepository interface {
+	ActiveRates(ctx context.Context) (domain.TaxRates, error)
+	ListAll(ctx context.Context) (domain.TaxRates, error)
+	Create(ctx context.Context, r *domain.TaxRate) error
+	Update(ctx context.Context, r *domain.TaxRate) error
+	// Deactivate retires a rate; rates are never deleted because sales reference them
+	Deactivate(ctx context.Context, id int64) error
+}
+
+// RedemptionRepository records products exchanged for points
+type RedemptionRepository interface {
+	Create(ctx context.Context, r *domain.Redemption) error
+	// List returns matching redemptions, newest first
+	List(ctx context.Context, f domain.RedemptionFilter) ([]domain.Redemption, error)
+	ReassignCustomer(ctx context.Context, fromID, toID int64) error
+}
+
+// PointsLedgerRepository records every loyalty points movement
+type PointsLedgerRepository interface {
+	Append(ctx context.Context, e *domain.PointsEntry) error
+	ListByCustomer(ctx context.Context, customerID int64) ([]domain.PointsEntry, error)
+	// Balance sums the ledger, independently of customers.points
+	Balance(ctx context.Context, customerID int64) (int, error)
+	ReassignCustomer(ctx context.Context, fromID, toID int64) error
+	// ConsumeLots spends points from the customer's open lots, soonest expiry first
+	ConsumeLots(ctx context.Context, customerID int64, points int) error
+	// ListExpiredLots returns lots with unspent points that lapsed at or before now
+	ListExpiredLots(ctx context.Context, now time.Time) ([]domain.PointsEntry, error)
+	// ListCustomerExpiredLots returns one customer's lots with unspent points that lapsed at or before now
+	ListCustomerExpiredLots(ctx context.Context, customerID int64, now time.Time) ([]domain.PointsEntry, error)
+	// ExpireLot zeroes a lapsed lot and returns how many points it still held
+	ExpireLot(ctx context.Context, id int64, now time.Time) (int, error)
+	// ExpiringBefore sums unspent points lapsing at or before the cutoff and returns the earliest expiry
+	ExpiringBefore(ctx context.Context, customerID int64, cutoff time.Time) (int, *time.Time, error)
+}
+
+// LoyaltyRuleRepository stores the earn, bonus and redemption rules
+type LoyaltyRuleRepository interface {
+	// ActiveRules returns every active rule; date and weekday filtering is left to the caller
+	ActiveRules(ctx context.Context) (*domain.LoyaltyRules, error)
+	ListAll(ctx context.Context) (*domain.LoyaltyRules, error)
+	CreateEarnRule(ctx context.Context, rule *domain.EarnRule) error
+	UpdateEarnRule(ctx context.Context, rule *domain.EarnRule) error
+	CreateBonusRule(ctx context.Context, rule *domain.BonusRule) error
+	UpdateBonusRule(ctx context.Context, rule *domain.BonusRule) error
+	CreateRedeemRule(ctx context.Context, rule *domain.RedeemRule) error
+	UpdateRedeemRule(ctx context.Context, rule *domain.RedeemRule) error
+	UpdateTier(ctx context.Context, t *domain.TierRule) error
+	// Deactivate retires a rule; rules are never deleted because sales reference them
+	Deactivate(ctx context.Context, kind domain.LoyaltyRuleKind, id int64) error
 }
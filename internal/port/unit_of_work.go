@@ -0,0 +1,28 @@
+package port
+
+import "context"
+
+// Repositories groups the repositories bound to a single unit of work
+type Repositories struct {
+	Product     ProductRepository
+	Customer    CustomerRepository
+	Transaction TransactionRepository
+	Order       OrderRepository
+	Points      PointsLedgerRepository
+	Loyalty     LoyaltyRuleRepository
+	Redemption  RedemptionRepository
+	Promotion   PromotionRepository
+	Tax         TaxRateRepository
+	Payment     PaymentRepository
+	Shift       ShiftRepository
+	Store       StoreRepository
+	Movement    StockMovementRepository
+	Inventory   InventoryRepository
+	Stocktake   StocktakeRepository
+}
+
+// UnitOfWork runs a set of repository writes atomically.
+// If fn returns an error every write made through repos is rolled back.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(repos Repositories) error) error
+}
@@ -0,0 +1,208 @@
+// Package receipt lays out sale receipts as plain text for thermal printers and as PDF
+package receipt
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/pkg/pdf"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Header is the store block printed at the top of every receipt
+type Header struct {
+	Name    string
+	Address string
+	TaxID   string // NPWP
+}
+
+// Printer lays out receipts Width characters wide, the line width of the thermal
+// printer: 32 for 58 mm paper, 48 for 80 mm
+type Printer struct {
+	Header Header
+	Width  int
+}
+
+// Text renders the receipt as ASCII lines ending in \n, ready to send to an ESC/POS printer
+func (p *Printer) Text(r *domain.Receipt) []byte {
+	return []byte(strings.Join(p.lines(r), "\n") + "\n")
+}
+
+// PDF renders the same layout as Text on a page the width of the paper roll
+func (p *Printer) PDF(r *domain.Receipt) []byte {
+	return pdf.Page{FontSize: 8, Margin: 12, Columns: p.Width, Lines: p.lines(r)}.Bytes()
+}
+
+func (p *Printer) lines(r *domain.Receipt) []string {
+	var out []string
+	center := func(s string) {
+		for _, line := range wrap(s, p.Width) {
+			out = append(out, strings.Repeat(" ", (p.Width-len(line))/2)+line)
+		}
+	}
+	row := func(label, value string) {
+		label, value = ascii(label), ascii(value)
+		if len(label)+1+len(value) > p.Width {
+			out = append(out, wrap(label, p.Width)...)
+			label = ""
+		}
+		for _, line := range wrap(value, p.Width) {
+			out = append(out, label+strings.Repeat(" ", p.Width-len(label)-len(line))+line)
+			label = ""
+		}
+	}
+	rule := func() { out = append(out, strings.Repeat("-", p.Width)) }
+
+	header := p.header(r.Store)
+	center(header.Name)
+	if header.Address != "" {
+		center(header.Address)
+	}
+	if header.TaxID != "" {
+		center("NPWP " + header.TaxID)
+	}
+	rule()
+	if r.Refund {
+		center("REFUND")
+	}
+	number := r.Number.String()
+	if len("No ")+len(number) > p.Width {
+		// the unhyphenated form still parses as the transaction or order ID
+		number = strings.ReplaceAll(number, "-", "")
+	}
+	row("No", number)
+	row("Date", r.Date.Format("2006-01-02 15:04"))
+	row("Customer", r.CustomerName)
+	if r.Cashier != "" {
+		row("Cashier", r.Cashier)
+	}
+	rule()
+
+	for _, line := range r.Lines {
+		out = append(out, wrap(fmt.Sprintf("%s %s (%s)", line.ProductName, line.ProductFlavor, line.ProductSize), p.Width)...)
+		row(fmt.Sprintf("  %d x %s", line.Quantity, money(line.Subtotal.MulDiv(1, line.Quantity))), money(line.Subtotal))
+		for _, d := range line.Discounts {
+			row("  "+d.Name, money(-d.Amount))
+		}
+		// refunds reverse the discount pro rata without a breakdown
+		if len(line.Discounts) == 0 && line.DiscountTotal != 0 {
+			row("  Discount", money(-line.DiscountTotal))
+		}
+	}
+	rule()
+
+	row("Subtotal", money(r.Subtotal))
+	if r.DiscountTotal != 0 {
+		row("Discount", money(-r.DiscountTotal))
+	}
+	row("Net", money(r.NetAmount))
+	for _, tax := range r.TaxLines() {
+		label := "PPN " + percent(tax.BasisPoints)
+		if tax.Inclusive {
+			label += " (incl.)"
+		}
+		row(label, money(tax.TaxAmount))
+	}
+	row("TOTAL", money(r.TotalPrice))
+	var change domain.Money
+	for _, payment := range r.Payments {
+		row(methodNames[payment.Method], money(payment.Tendered))
+		if payment.Reference != "" {
+			row("  Ref", payment.Reference)
+		}
+		change += payment.Change
+	}
+	if change != 0 {
+		row("Change", money(change))
+	}
+	rule()
+
+	row("Points earned", strconv.Itoa(r.PointsEarned))
+	if r.PointsBalance != nil {
+		row("Points balance", strconv.Itoa(*r.PointsBalance))
+	}
+	out = append(out, "")
+	center("Thank you")
+	return out
+}
+
+// header prints the details of the store that made the sale, falling back to the
+// printer's header for what the store leaves blank
+func (p *Printer) header(store *domain.Store) Header {
+	h := p.Header
+	if store == nil {
+		return h
+	}
+	if store.Name != "" {
+		h.Name = store.Name
+	}
+	if store.Address != "" {
+		h.Address = store.Address
+	}
+	if store.TaxID != "" {
+		h.TaxID = store.TaxID
+	}
+	return h
+}
+
+var methodNames = map[domain.PaymentMethod]string{
+	domain.PaymentCash:    "Cash",
+	domain.PaymentCard:    "Card",
+	domain.PaymentQRIS:    "QRIS",
+	domain.PaymentEWallet: "E-wallet",
+}
+
+// money formats an amount the Indonesian way: 18.000 or 18.000,50
+func money(m domain.Money) string {
+	sen := int64(m)
+	sign := ""
+	if sen < 0 {
+		sign, sen = "-", -sen
+	}
+	whole := strconv.FormatInt(sen/100, 10)
+	var b strings.Builder
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	if frac := sen % 100; frac != 0 {
+		fmt.Fprintf(&b, ",%02d", frac)
+	}
+	return sign + b.String()
+}
+
+// percent formats basis points as a percentage: 1100 is 11%, 1150 is 11,5%
+func percent(bp int) string {
+	s := strconv.Itoa(bp / 100)
+	if frac := bp % 100; frac != 0 {
+		s += "," + strings.TrimRight(fmt.Sprintf("%02d", frac), "0")
+	}
+	return s + "%"
+}
+
+// ascii replaces what a thermal printer's code page may not have with '?'
+func ascii(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' || r > '~' {
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+// wrap breaks s into lines of at most width characters, at spaces where it can
+func wrap(s string, width int) []string {
+	s = ascii(s)
+	var lines []string
+	for len(s) > width {
+		cut := strings.LastIndexByte(s[:width+1], ' ')
+		if cut <= 0 {
+			cut = width
+		}
+		lines = append(lines, strings.TrimRight(s[:cut], " "))
+		s = strings.TrimLeft(s[cut:], " ")
+	}
+	return append(lines, s)
+}
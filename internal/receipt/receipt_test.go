@@ -0,0 +1,122 @@
+package receipt_test
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/receipt"
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleReceipt() *domain.Receipt {
+	balance := 120
+	r := &domain.Receipt{
+		Number:        uuid.MustParse("6f1c2a7e-3b4d-4e5f-8a9b-0c1d2e3f4a5b"),
+		CustomerName:  "Budi",
+		Date:          time.Date(2025, 12, 10, 14, 3, 0, 0, time.UTC),
+		PointsEarned:  31,
+		PointsBalance: &balance,
+	}
+	r.AddLine(domain.Transaction{ProductName: "Keripik Pangsit", ProductFlavor: "Jagung Bakar", ProductSize: "Large",
+		Quantity: 2, Subtotal: domain.NewMoney(20000), DiscountTotal: domain.NewMoney(2000),
+		Discounts: []domain.Discount{{Name: "Diskon 10%", Amount: domain.NewMoney(2000)}},
+		NetAmount: domain.Money(1621622), TaxAmount: domain.Money(178378), TaxBasisPoints: 1100, TaxInclusive: true,
+		TotalPrice: domain.NewMoney(18000)})
+	r.AddLine(domain.Transaction{ProductName: "Makaroni", ProductFlavor: "Pedas", ProductSize: "Small",
+		Quantity: 1, Subtotal: domain.NewMoney(12500), NetAmount: domain.NewMoney(12500), TaxAmount: domain.Money(137500),
+		TaxBasisPoints: 1100, TotalPrice: domain.NewMoney(13875)})
+	r.Payments = []domain.Payment{
+		{Method: domain.PaymentQRIS, Amount: domain.NewMoney(10000), Tendered: domain.NewMoney(10000), Reference: "QR20251210-0042"},
+		{Method: domain.PaymentCash, Amount: domain.NewMoney(21875), Tendered: domain.NewMoney(50000), Change: domain.NewMoney(28125)},
+	}
+	return r
+}
+
+func TestPrinter_Text(t *testing.T) {
+	p := &receipt.Printer{Header: receipt.Header{Name: "BSNACK", Address: "Jl. Merdeka 10, Bandung", TaxID: "01.234.567.8-901.000"}, Width: 32}
+
+	want := `             BSNACK
+    Jl. Merdeka 10, Bandung
+   NPWP 01.234.567.8-901.000
+--------------------------------
+No
+6f1c2a7e3b4d4e5f8a9b0c1d2e3f4a5b
+Date            2025-12-10 14:03
+Customer                    Budi
+--------------------------------
+Keripik Pangsit Jagung Bakar
+(Large)
+  2 x 10.000              20.000
+  Diskon 10%              -2.000
+Makaroni Pedas (Small)
+  1 x 12.500              12.500
+--------------------------------
+Subtotal                  32.500
+Discount                  -2.000
+Net                    28.716,22
+PPN 11% (incl.)         1.783,78
+PPN 11%                    1.375
+TOTAL                     31.875
+QRIS                      10.000
+  Ref            QR20251210-0042
+Cash                      50.000
+Change                    28.125
+--------------------------------
+Points earned                 31
+Points balance               120
+
+           Thank you
+`
+	assert.Equal(t, want, string(p.Text(sampleReceipt())))
+}
+
+func TestPrinter_TextLinesFitWidth(t *testing.T) {
+	p := &receipt.Printer{Header: receipt.Header{Name: "Toko Camilan Bu Sri yang Sangat Panjang Sekali"}, Width: 24}
+	r := sampleReceipt()
+	r.CustomerName = "Ni Luh Putu Ayu Kadek Wulandari"
+
+	for _, line := range strings.Split(strings.TrimSuffix(string(p.Text(r)), "\n"), "\n") {
+		assert.LessOrEqual(t, len(line), 24, "%q", line)
+	}
+}
+
+func TestPrinter_PDF(t *testing.T) {
+	p := &receipt.Printer{Header: receipt.Header{Name: "BSNACK (Bandung)"}, Width: 32}
+
+	doc := p.PDF(sampleReceipt())
+
+	assert.True(t, bytes.HasPrefix(doc, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(doc, []byte("%%EOF\n")))
+	assert.Contains(t, string(doc), `(        BSNACK \(Bandung\)) Tj`)
+	assert.Contains(t, string(doc), "(TOTAL                     31.875) Tj")
+
+	// startxref must point at the cross-reference table, and each entry at its object
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(doc)
+	if assert.NotNil(t, m) {
+		xref, _ := strconv.Atoi(string(m[1]))
+		assert.True(t, bytes.HasPrefix(doc[xref:], []byte("xref\n0 6\n")))
+		for i, entry := range regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(doc[xref:], -1) {
+			off, _ := strconv.Atoi(string(entry[1]))
+			assert.True(t, bytes.HasPrefix(doc[off:], []byte(strconv.Itoa(i+1)+" 0 obj\n")))
+		}
+	}
+}
+
+func TestPrinter_TextStoreHeader(t *testing.T) {
+	p := &receipt.Printer{Header: receipt.Header{Name: "BSNACK", Address: "Jl. Merdeka 10, Bandung", TaxID: "01.234.567.8-901.000"}, Width: 32}
+	r := sampleReceipt()
+	r.Store = &domain.Store{ID: 2, Code: "JKT", Name: "BSNACK Jakarta", Address: "Jl. Sabang 5, Jakarta"}
+
+	lines := strings.Split(string(p.Text(r)), "\n")
+
+	assert.Equal(t, "         BSNACK Jakarta", lines[0])
+	assert.Equal(t, "     Jl. Sabang 5, Jakarta", lines[1])
+	// the store has no NPWP of its own and prints the company's
+	assert.Equal(t, "   NPWP 01.234.567.8-901.000", lines[2])
+}
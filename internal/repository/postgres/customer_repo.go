@@ -8,36 +8,136 @@ import (
 )
 
 type CustomerRepo struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewCustomerRepo(db *sql.DB) port.CustomerRepository {
 	return &CustomerRepo{db: db}
 }
 
-func (r *CustomerRepo) GetByName(ctx context.Context, name string) (*domain.Customer, error) {
+const customerColumns = `id, name, phone, email, points, tier, created_at, updated_at`
+
+func scanCustomer(row interface{ Scan(...any) error }, c *domain.Customer) error {
+	var phone, email sql.NullString
+	if err := row.Scan(&c.ID, &c.Name, &phone, &email, &c.Points, &c.Tier, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return err
+	}
+	c.Phone = nullStringPtr(phone)
+	c.Email = nullStringPtr(email)
+	return nil
+}
+
+func (r *CustomerRepo) getOne(ctx context.Context, where string, arg any) (*domain.Customer, error) {
 	c := &domain.Customer{}
-	query := `SELECT id, name, points, created_at FROM customers WHERE name = $1`
-	err := r.db.QueryRowContext(ctx, query, name).Scan(&c.ID, &c.Name, &c.Points, &c.CreatedAt)
+	query := `SELECT ` + customerColumns + ` FROM customers WHERE ` + where + ` AND deleted_at IS NULL`
+	if err := scanCustomer(r.db.QueryRowContext(ctx, query, arg), c); err != nil {
+		return nil, translateErr(err, "customer not found")
+	}
+	return c, nil
+}
+
+func (r *CustomerRepo) GetByID(ctx context.Context, id int64) (*domain.Customer, error) {
+	return r.getOne(ctx, "id = $1", id)
+}
+
+func (r *CustomerRepo) GetForUpdate(ctx context.Context, id int64) (*domain.Customer, error) {
+	c := &domain.Customer{}
+	query := `SELECT ` + customerColumns + ` FROM customers WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	if err := scanCustomer(r.db.QueryRowContext(ctx, query, id), c); err != nil {
+		return nil, translateErr(err, "customer not found")
+	}
+	return c, nil
+}
+
+func (r *CustomerRepo) GetByPhone(ctx context.Context, phone string) (*domain.Customer, error) {
+	return r.getOne(ctx, "phone = $1", phone)
+}
+
+func (r *CustomerRepo) GetByEmail(ctx context.Context, email string) (*domain.Customer, error) {
+	return r.getOne(ctx, "email = $1", email)
+}
+
+func (r *CustomerRepo) GetByName(ctx context.Context, name string) (*domain.Customer, error) {
+	// LIMIT 2 is enough to tell a unique match from an ambiguous one
+	query := `SELECT ` + customerColumns + ` FROM customers WHERE name = $1 AND deleted_at IS NULL ORDER BY id LIMIT 2`
+
+	rows, err := r.db.QueryContext(ctx, query, name)
 	if err != nil {
 		return nil, err
 	}
-	return c, nil
+	defer rows.Close()
+
+	var matches []domain.Customer
+	for rows.Next() {
+		var c domain.Customer
+		if err := scanCustomer(rows, &c); err != nil {
+			return nil, err
+		}
+		matches = append(matches, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, domain.NewNotFoundError("customer not found")
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, domain.NewConflictError("several customers are named " + name + ", use customer_id")
+	}
+}
+
+func (r *CustomerRepo) LockName(ctx context.Context, name string) error {
+	// names are not unique, so a transaction-scoped advisory lock stands in for the index
+	_, err := r.db.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('customers.name'), hashtext($1))`, name)
+	return err
 }
 
 func (r *CustomerRepo) Create(ctx context.Context, c *domain.Customer) error {
-	query := `INSERT INTO customers (name, points) VALUES ($1, $2) RETURNING id, created_at`
-	return r.db.QueryRowContext(ctx, query, c.Name, c.Points).Scan(&c.ID, &c.CreatedAt)
+	query := `INSERT INTO customers (name, phone, email, points) VALUES ($1, $2, $3, $4) RETURNING id, tier, created_at, updated_at`
+	err := r.db.QueryRowContext(ctx, query, c.Name, c.Phone, c.Email, c.Points).Scan(&c.ID, &c.Tier, &c.CreatedAt, &c.UpdatedAt)
+	return translateConflict(err)
+}
+
+func (r *CustomerRepo) Update(ctx context.Context, c *domain.Customer) error {
+	query := `
+		UPDATE customers SET name = $1, phone = $2, email = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4 AND deleted_at IS NULL
+		RETURNING points, tier, created_at, updated_at`
+	err := r.db.QueryRowContext(ctx, query, c.Name, c.Phone, c.Email, c.ID).Scan(&c.Points, &c.Tier, &c.CreatedAt, &c.UpdatedAt)
+	return translateErr(err, "customer not found")
+}
+
+func (r *CustomerRepo) Delete(ctx context.Context, id int64) error {
+	query := `UPDATE customers SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	return requireRow(res, "customer not found")
+}
+
+func (r *CustomerRepo) MarkMerged(ctx context.Context, sourceID, targetID int64) error {
+	query := `
+		UPDATE customers SET points = 0, merged_into = $2, deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, query, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+	return requireRow(res, "customer not found")
 }
 
 func (r *CustomerRepo) UpdatePoints(ctx context.Context, id int64, points int) error {
-	query := `UPDATE customers SET points = points + $1 WHERE id = $2`
+	query := `UPDATE customers SET points = points + $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
 	_, err := r.db.ExecContext(ctx, query, points, id)
 	return err
 }
 
 func (r *CustomerRepo) ListAll(ctx context.Context) ([]domain.Customer, error) {
-	query := `SELECT id, name, points, created_at, updated_at FROM customers ORDER BY points DESC`
+	query := `SELECT ` + customerColumns + ` FROM customers WHERE deleted_at IS NULL ORDER BY points DESC`
 
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
@@ -48,10 +148,50 @@ func (r *CustomerRepo) ListAll(ctx context.Context) ([]domain.Customer, error) {
 	var customers []domain.Customer
 	for rows.Next() {
 		var c domain.Customer
-		if err := rows.Scan(&c.ID, &c.Name, &c.Points, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if err := scanCustomer(rows, &c); err != nil {
 			return nil, err
 		}
 		customers = append(customers, c)
 	}
-	return customers, nil
+	return customers, rows.Err()
+}
+
+func (r *CustomerRepo) ChangeTier(ctx context.Context, e *domain.TierEvent) error {
+	query := `UPDATE customers SET tier = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, query, e.ToTier, e.CustomerID)
+	if err != nil {
+		return err
+	}
+	if err := requireRow(res, "customer not found"); err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO customer_tier_events (customer_id, from_tier, to_tier, rolling_spend)
+		VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query, e.CustomerID, e.FromTier, e.ToTier, e.RollingSpend).Scan(&e.ID, &e.CreatedAt)
+}
+
+func (r *CustomerRepo) ListTierEvents(ctx context.Context, customerID int64) ([]domain.TierEvent, error) {
+	query := `
+		SELECT id, customer_id, from_tier, to_tier, rolling_spend, created_at
+		FROM customer_tier_events
+		WHERE customer_id = $1
+		ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.TierEvent{}
+	for rows.Next() {
+		var e domain.TierEvent
+		if err := rows.Scan(&e.ID, &e.CustomerID, &e.FromTier, &e.ToTier, &e.RollingSpend, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
 }
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"bsnack/internal/domain"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the Postgres SQLSTATE for a unique constraint failure
+const uniqueViolation = "23505"
+
+// conflictMessages describes the unique constraints a client can break. The driver's
+// detail is never passed on: it echoes the values of other customers' records.
+var conflictMessages = map[string]string{
+	"ux_customers_phone":              "phone is already registered to another customer",
+	"ux_customers_email":              "email is already registered to another customer",
+	"uq_loyalty_earn_rules_type":      "an active earn rule already covers this product type",
+	"uq_loyalty_redeem_rules_product": "an active redeem rule already covers this product",
+	"uq_loyalty_redeem_rules_size":    "an active redeem rule already covers this size",
+	"uq_promotions_code":              "an active promotion already uses this code",
+	"uq_tax_rates_type":               "an active tax rate already covers this product type",
+	"stores_code_key":                 "store code is already in use",
+	"uq_shifts_open_till":             "till already has an open shift",
+	"uq_stocktakes_open_store":        "store already has an open stocktake",
+}
+
+// translateErr maps driver errors onto domain error kinds
+func translateErr(err error, notFoundMsg string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.NewNotFoundError(notFoundMsg)
+	}
+	return translateConflict(err)
+}
+
+// translateConflict maps a unique constraint failure onto a conflict error; inserts use
+// it directly since they cannot miss a row
+func translateConflict(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		if msg, ok := conflictMessages[pqErr.Constraint]; ok {
+			return domain.NewConflictError(msg)
+		}
+		return domain.NewConflictError("conflicts with an existing record")
+	}
+	return err
+}
+
+// requireRow reports a not found error when an UPDATE or DELETE matched nothing
+func requireRow(res sql.Result, notFoundMsg string) error {
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return domain.NewNotFoundError(notFoundMsg)
+	}
+	return nil
+}
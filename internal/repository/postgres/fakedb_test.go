@@ -0,0 +1,97 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeStep is one statement the repository is expected to run: the first statement
+// containing match answers with the given columns and rows
+type fakeStep struct {
+	match   string
+	columns []string
+	rows    [][]driver.Value
+}
+
+// fakeDB is a scripted database/sql driver. It checks that statements arrive in the
+// order of the script and keeps every statement run, so tests can assert the SQL a
+// repository sends without a Postgres server.
+type fakeDB struct {
+	t     *testing.T
+	steps []fakeStep
+	Ran   []string
+}
+
+// newFakeDB returns a *sql.DB that answers from steps in order
+func newFakeDB(t *testing.T, steps ...fakeStep) (*sql.DB, *fakeDB) {
+	f := &fakeDB{t: t, steps: steps}
+	db := sql.OpenDB(f)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return db, f
+}
+
+// Done fails the test when part of the script was not run
+func (f *fakeDB) Done() {
+	f.t.Helper()
+	if len(f.steps) > 0 {
+		f.t.Errorf("statement never run: %q", f.steps[0].match)
+	}
+}
+
+func (f *fakeDB) next(query string) (fakeStep, error) {
+	f.Ran = append(f.Ran, query)
+	if len(f.steps) == 0 || !strings.Contains(query, f.steps[0].match) {
+		f.t.Errorf("unexpected statement: %s", query)
+		return fakeStep{}, errors.New("unexpected statement")
+	}
+	step := f.steps[0]
+	f.steps = f.steps[1:]
+	return step, nil
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return f, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return nil }
+
+func (f *fakeDB) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (f *fakeDB) Close() error                        { return nil }
+func (f *fakeDB) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (f *fakeDB) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	step, err := f.next(query)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: step.columns, rows: step.rows}, nil
+}
+
+func (f *fakeDB) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if _, err := f.next(query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type InventoryRepo struct {
+	db DBTX
+}
+
+func NewInventoryRepo(db *sql.DB) port.InventoryRepository {
+	return &InventoryRepo{db: db}
+}
+
+func (r *InventoryRepo) ListExpiring(ctx context.Context, f domain.ExpiryFilter) ([]domain.ExpiringBatch, error) {
+	query := `
+		SELECT b.id, b.store_id, b.product_id, b.lot, b.manufacturing_date, b.expiry, b.quantity, b.received_at,
+			p.name, p.type, p.flavor, p.size, b.expiry - CURRENT_DATE
+		FROM (SELECT b.*, ` + batchExpiry + ` AS expiry FROM stock_batches b) b
+		JOIN products p ON b.product_id = p.id
+		WHERE b.quantity > 0
+		  AND b.expiry <= CURRENT_DATE + $1::int
+		  AND ($2::int IS NULL OR b.store_id = $2)
+		ORDER BY b.expiry, b.id`
+
+	rows, err := r.db.QueryContext(ctx, query, f.WithinDays, f.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := []domain.ExpiringBatch{}
+	for rows.Next() {
+		var e domain.ExpiringBatch
+		var made, expiry time.Time
+		if err := rows.Scan(&e.ID, &e.StoreID, &e.ProductID, &e.Lot, &made, &expiry, &e.Quantity, &e.ReceivedAt,
+			&e.ProductName, &e.ProductType, &e.ProductFlavor, &e.ProductSize, &e.DaysLeft); err != nil {
+			return nil, err
+		}
+		e.ManufacturingDate = made.Format("2006-01-02")
+		expires := expiry.Format("2006-01-02")
+		e.ExpiryDate = &expires
+		batches = append(batches, e)
+	}
+	return batches, rows.Err()
+}
+
+func (r *InventoryRepo) WriteOffExpired(ctx context.Context, storeID, productID *int64, reason, actor string) ([]domain.WriteOff, error) {
+	// one statement locks the expired batches, empties them and records what they held
+	query := `
+		WITH expired AS (
+			SELECT b.id, b.store_id, b.product_id, b.lot, ` + batchExpiry + ` AS expiry, b.quantity
+			FROM stock_batches b
+			WHERE b.quantity > 0
+			  AND NOT ` + unexpired + `
+			  AND ($1::int IS NULL OR b.store_id = $1)
+			  AND ($2::int IS NULL OR b.product_id = $2)
+			FOR UPDATE
+		), emptied AS (
+			UPDATE stock_batches b SET quantity = b.quantity - e.quantity FROM expired e WHERE b.id = e.id
+		)
+		INSERT INTO write_offs (batch_id, store_id, product_id, lot, expiry_date, quantity, reason, actor)
+		SELECT id, store_id, product_id, lot, expiry, quantity, $3, $4 FROM expired ORDER BY id
+		RETURNING ` + writeOffColumns
+
+	rows, err := r.db.QueryContext(ctx, query, storeID, productID, reason, actor)
+	if err != nil {
+		return nil, err
+	}
+	return scanWriteOffs(rows)
+}
+
+const writeOffColumns = `id, batch_id, store_id, product_id, lot, expiry_date, quantity, reason, actor, created_at`
+
+func (r *InventoryRepo) ListWriteOffs(ctx context.Context, f domain.WriteOffFilter) ([]domain.WriteOff, error) {
+	where := []string{"TRUE"}
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if f.StartDate != "" {
+		where = append(where, "created_at::date >= "+arg(f.StartDate)+"::date")
+	}
+	if f.EndDate != "" {
+		where = append(where, "created_at::date <= "+arg(f.EndDate)+"::date")
+	}
+	if f.StoreID > 0 {
+		where = append(where, "store_id = "+arg(f.StoreID))
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+writeOffColumns+` FROM write_offs WHERE `+
+		strings.Join(where, " AND ")+` ORDER BY created_at DESC, id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanWriteOffs(rows)
+}
+
+// sellableStock is the unexpired stock of the products row p at the stores row s
+const sellableStock = `COALESCE((SELECT SUM(b.quantity) FROM stock_batches b
+	WHERE b.product_id = p.id AND b.store_id = s.id AND ` + unexpired + `), 0)`
+
+func (r *InventoryRepo) ListLowStock(ctx context.Context, storeID *int64) ([]domain.StockLevel, error) {
+	query := `
+		SELECT store_id, product_id, name, flavor, size, quantity, reorder_point, reorder_quantity
+		FROM (
+			SELECT s.id AS store_id, p.id AS product_id, p.name, p.flavor, p.size, ` + sellableStock + ` AS quantity,
+				l.reorder_point, l.reorder_quantity
+			FROM reorder_levels l
+			JOIN products p ON l.product_id = p.id
+			CROSS JOIN stores s
+			WHERE p.archived_at IS NULL AND s.active AND ($1::int IS NULL OR s.id = $1)
+		) x
+		WHERE quantity <= reorder_point
+		ORDER BY store_id, quantity - reorder_point, name, flavor`
+
+	rows, err := r.db.QueryContext(ctx, query, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	levels := []domain.StockLevel{}
+	for rows.Next() {
+		var l domain.StockLevel
+		if err := rows.Scan(&l.StoreID, &l.ProductID, &l.ProductName, &l.ProductFlavor, &l.ProductSize, &l.Quantity,
+			&l.ReorderPoint, &l.ReorderQuantity); err != nil {
+			return nil, err
+		}
+		levels = append(levels, l)
+	}
+	return levels, rows.Err()
+}
+
+func (r *InventoryRepo) ListSalesVelocity(ctx context.Context, storeID *int64, lookbackDays int) ([]domain.ReorderSuggestion, error) {
+	// units sold are net of refunds; the window ends now and starts lookbackDays ago
+	query := `
+		SELECT store_id, product_id, name, flavor, size, quantity, reorder_point, reorder_quantity, sold
+		FROM (
+			SELECT s.id AS store_id, p.id AS product_id, p.name, p.flavor, p.size, ` + sellableStock + ` AS quantity,
+				l.reorder_point, l.reorder_quantity,
+				COALESCE((SELECT SUM(t.quantity) FROM transactions t
+					WHERE t.product_id = p.id AND t.store_id = s.id
+					  AND t.transaction_date >= CURRENT_TIMESTAMP - make_interval(days => $2)), 0) AS sold
+			FROM products p
+			CROSS JOIN stores s
+			LEFT JOIN reorder_levels l ON l.product_id = p.id
+			WHERE p.archived_at IS NULL AND s.active AND ($1::int IS NULL OR s.id = $1)
+		) x
+		WHERE sold > 0 OR reorder_point IS NOT NULL
+		ORDER BY store_id, name, flavor, product_id`
+
+	rows, err := r.db.QueryContext(ctx, query, storeID, lookbackDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []domain.ReorderSuggestion{}
+	for rows.Next() {
+		var s domain.ReorderSuggestion
+		if err := rows.Scan(&s.StoreID, &s.ProductID, &s.ProductName, &s.ProductFlavor, &s.ProductSize, &s.Quantity,
+			&s.ReorderPoint, &s.ReorderQuantity, &s.UnitsSold); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, rows.Err()
+}
+
+func scanWriteOffs(rows *sql.Rows) ([]domain.WriteOff, error) {
+	defer rows.Close()
+
+	writeOffs := []domain.WriteOff{}
+	for rows.Next() {
+		var w domain.WriteOff
+		var expiry time.Time
+		if err := rows.Scan(&w.ID, &w.BatchID, &w.StoreID, &w.ProductID, &w.Lot, &expiry, &w.Quantity, &w.Reason,
+			&w.Actor, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		w.ExpiryDate = expiry.Format("2006-01-02")
+		writeOffs = append(writeOffs, w)
+	}
+	return writeOffs, rows.Err()
+}
@@ -0,0 +1,240 @@
+package postgres
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type LoyaltyRuleRepo struct {
+	db DBTX
+}
+
+func NewLoyaltyRuleRepo(db *sql.DB) port.LoyaltyRuleRepository {
+	return &LoyaltyRuleRepo{db: db}
+}
+
+func (r *LoyaltyRuleRepo) ActiveRules(ctx context.Context) (*domain.LoyaltyRules, error) {
+	return r.load(ctx, "WHERE active")
+}
+
+func (r *LoyaltyRuleRepo) ListAll(ctx context.Context) (*domain.LoyaltyRules, error) {
+	return r.load(ctx, "")
+}
+
+// load reads the earn, bonus and redeem rules with the same filter, plus the tiers
+func (r *LoyaltyRuleRepo) load(ctx context.Context, where string) (*domain.LoyaltyRules, error) {
+	rules := &domain.LoyaltyRules{
+		Earn:   []domain.EarnRule{},
+		Bonus:  []domain.BonusRule{},
+		Redeem: []domain.RedeemRule{},
+		Tiers:  []domain.TierRule{},
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, name, product_type, spend_per_point, active, created_at, updated_at
+		FROM loyalty_earn_rules `+where+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var e domain.EarnRule
+		var productType sql.NullString
+		if err := rows.Scan(&e.ID, &e.Name, &productType, &e.SpendPerPoint, &e.Active, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		e.ProductType = nullStringPtr(productType)
+		rules.Earn = append(rules.Earn, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.QueryContext(ctx, `
+		SELECT id, name, product_type, multiplier_percent, start_date, end_date, weekdays, active, created_at, updated_at
+		FROM loyalty_bonus_rules `+where+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var b domain.BonusRule
+		var productType sql.NullString
+		var start, end time.Time
+		var weekdays int
+		if err := rows.Scan(&b.ID, &b.Name, &productType, &b.MultiplierPercent, &start, &end, &weekdays,
+			&b.Active, &b.CreatedAt, &b.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		b.ProductType = nullStringPtr(productType)
+		b.StartDate = start.Format("2006-01-02")
+		b.EndDate = end.Format("2006-01-02")
+		b.Weekdays = weekdaysFromMask(weekdays)
+		rules.Bonus = append(rules.Bonus, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.QueryContext(ctx, `
+		SELECT id, name, product_id, product_size, points, active, created_at, updated_at
+		FROM loyalty_redeem_rules `+where+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var rr domain.RedeemRule
+		var productID sql.NullInt64
+		var size sql.NullString
+		if err := rows.Scan(&rr.ID, &rr.Name, &productID, &size, &rr.Points, &rr.Active, &rr.CreatedAt, &rr.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rr.ProductID = nullInt64Ptr(productID)
+		if size.Valid {
+			s := domain.ProductSize(size.String)
+			rr.ProductSize = &s
+		}
+		rules.Redeem = append(rules.Redeem, rr)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// tiers are always in force, so the filter does not apply to them
+	rows, err = r.db.QueryContext(ctx, `
+		SELECT tier, min_spend, multiplier_percent, updated_at FROM loyalty_tiers ORDER BY min_spend`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t domain.TierRule
+		if err := rows.Scan(&t.Tier, &t.MinSpend, &t.MultiplierPercent, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rules.Tiers = append(rules.Tiers, t)
+	}
+	return rules, rows.Err()
+}
+
+func (r *LoyaltyRuleRepo) CreateEarnRule(ctx context.Context, e *domain.EarnRule) error {
+	query := `
+		INSERT INTO loyalty_earn_rules (name, product_type, spend_per_point, active)
+		VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, e.Name, e.ProductType, e.SpendPerPoint, e.Active).
+		Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
+	return translateConflict(err)
+}
+
+func (r *LoyaltyRuleRepo) UpdateEarnRule(ctx context.Context, e *domain.EarnRule) error {
+	query := `
+		UPDATE loyalty_earn_rules
+		SET name = $1, product_type = $2, spend_per_point = $3, active = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5 RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, e.Name, e.ProductType, e.SpendPerPoint, e.Active, e.ID).
+		Scan(&e.CreatedAt, &e.UpdatedAt)
+	return translateErr(err, "earn rule not found")
+}
+
+func (r *LoyaltyRuleRepo) CreateBonusRule(ctx context.Context, b *domain.BonusRule) error {
+	query := `
+		INSERT INTO loyalty_bonus_rules (name, product_type, multiplier_percent, start_date, end_date, weekdays, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		b.Name, b.ProductType, b.MultiplierPercent, b.StartDate, b.EndDate, weekdaysMask(b.Weekdays), b.Active,
+	).Scan(&b.ID, &b.CreatedAt, &b.UpdatedAt)
+	return translateConflict(err)
+}
+
+func (r *LoyaltyRuleRepo) UpdateBonusRule(ctx context.Context, b *domain.BonusRule) error {
+	query := `
+		UPDATE loyalty_bonus_rules
+		SET name = $1, product_type = $2, multiplier_percent = $3, start_date = $4, end_date = $5, weekdays = $6,
+			active = $7, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8 RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		b.Name, b.ProductType, b.MultiplierPercent, b.StartDate, b.EndDate, weekdaysMask(b.Weekdays), b.Active, b.ID,
+	).Scan(&b.CreatedAt, &b.UpdatedAt)
+	return translateErr(err, "bonus rule not found")
+}
+
+func (r *LoyaltyRuleRepo) CreateRedeemRule(ctx context.Context, rr *domain.RedeemRule) error {
+	query := `
+		INSERT INTO loyalty_redeem_rules (name, product_id, product_size, points, active)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, rr.Name, rr.ProductID, rr.ProductSize, rr.Points, rr.Active).
+		Scan(&rr.ID, &rr.CreatedAt, &rr.UpdatedAt)
+	return translateConflict(err)
+}
+
+func (r *LoyaltyRuleRepo) UpdateRedeemRule(ctx context.Context, rr *domain.RedeemRule) error {
+	query := `
+		UPDATE loyalty_redeem_rules
+		SET name = $1, product_id = $2, product_size = $3, points = $4, active = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6 RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, rr.Name, rr.ProductID, rr.ProductSize, rr.Points, rr.Active, rr.ID).
+		Scan(&rr.CreatedAt, &rr.UpdatedAt)
+	return translateErr(err, "redeem rule not found")
+}
+
+func (r *LoyaltyRuleRepo) UpdateTier(ctx context.Context, t *domain.TierRule) error {
+	query := `
+		UPDATE loyalty_tiers SET min_spend = $1, multiplier_percent = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE tier = $3 RETURNING updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, t.MinSpend, t.MultiplierPercent, t.Tier).Scan(&t.UpdatedAt)
+	return translateErr(err, "tier not found")
+}
+
+// ruleTables maps a rule kind onto its table; the kind is validated before it gets here
+var ruleTables = map[domain.LoyaltyRuleKind]string{
+	domain.RuleEarn:   "loyalty_earn_rules",
+	domain.RuleBonus:  "loyalty_bonus_rules",
+	domain.RuleRedeem: "loyalty_redeem_rules",
+}
+
+func (r *LoyaltyRuleRepo) Deactivate(ctx context.Context, kind domain.LoyaltyRuleKind, id int64) error {
+	table, ok := ruleTables[kind]
+	if !ok {
+		return domain.NewValidationError("unknown rule kind")
+	}
+
+	query := `UPDATE ` + table + ` SET active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	return requireRow(res, string(kind)+" rule not found")
+}
+
+// weekdaysMask stores weekdays as bits, bit 0 being Sunday
+func weekdaysMask(days []time.Weekday) int {
+	mask := 0
+	for _, d := range days {
+		mask |= 1 << d
+	}
+	return mask
+}
+
+func weekdaysFromMask(mask int) []time.Weekday {
+	var days []time.Weekday
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if mask&(1<<d) != 0 {
+			days = append(days, d)
+		}
+	}
+	return days
+}
@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"database/sql"
+)
+
+type OrderRepo struct {
+	db DBTX
+}
+
+func NewOrderRepo(db *sql.DB) port.OrderRepository {
+	return &OrderRepo{db: db}
+}
+
+func (r *OrderRepo) Create(ctx context.Context, o *domain.Order) error {
+	query := `
+		INSERT INTO orders (customer_id, total_quantity, subtotal, discount_total, net_amount, tax_amount, total_price,
+			points_earned, points_balance, store_id, shift_id, order_date) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id`
+
+	return r.db.QueryRowContext(ctx, query,
+		o.CustomerID, o.TotalQuantity, o.Subtotal, o.DiscountTotal, o.NetAmount, o.TaxAmount, o.TotalPrice,
+		o.PointsEarned, o.PointsBalance, o.StoreID, o.ShiftID, o.OrderDate,
+	).Scan(&o.ID)
+}
+
+func (r *OrderRepo) ReassignCustomer(ctx context.Context, fromID, toID int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE orders SET customer_id = $2 WHERE customer_id = $1`, fromID, toID)
+	return err
+}
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type PaymentRepo struct {
+	db DBTX
+}
+
+func NewPaymentRepo(db *sql.DB) port.PaymentRepository {
+	return &PaymentRepo{db: db}
+}
+
+func (r *PaymentRepo) Create(ctx context.Context, p *domain.Payment) error {
+	query := `
+		INSERT INTO payments (transaction_id, order_id, method, amount, tendered, change_given, reference)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	reference := sql.NullString{String: p.Reference, Valid: p.Reference != ""}
+	return r.db.QueryRowContext(ctx, query,
+		p.TransactionID, p.OrderID, p.Method, p.Amount, p.Tendered, p.Change, reference,
+	).Scan(&p.ID, &p.CreatedAt)
+}
+
+// listPayments returns the payments of a purchase or an order in the order they were taken
+func listPayments(ctx context.Context, db DBTX, column string, id uuid.UUID) ([]domain.Payment, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, transaction_id, order_id, method, amount, tendered, change_given, COALESCE(reference, ''), created_at
+		FROM payments WHERE `+column+` = $1 ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []domain.Payment{}
+	for rows.Next() {
+		var p domain.Payment
+		var transactionID, orderID uuid.NullUUID
+		if err := rows.Scan(&p.ID, &transactionID, &orderID, &p.Method, &p.Amount, &p.Tendered, &p.Change,
+			&p.Reference, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		p.TransactionID = nullUUIDPtr(transactionID)
+		p.OrderID = nullUUIDPtr(orderID)
+		payments = append(payments, p)
+	}
+	return payments, rows.Err()
+}
@@ -0,0 +1,182 @@
+package postgres
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PointsLedgerRepo struct {
+	db DBTX
+}
+
+func NewPointsLedgerRepo(db *sql.DB) port.PointsLedgerRepository {
+	return &PointsLedgerRepo{db: db}
+}
+
+func (r *PointsLedgerRepo) Append(ctx context.Context, e *domain.PointsEntry) error {
+	query := `
+		INSERT INTO points_ledger (customer_id, entry_type, points, transaction_id, order_id, redeem_rule_id, reason, remaining, expires_at) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at`
+
+	return r.db.QueryRowContext(ctx, query,
+		e.CustomerID, e.Type, e.Points, e.TransactionID, e.OrderID, e.RedeemRuleID, e.Reason, e.Remaining, e.ExpiresAt,
+	).Scan(&e.ID, &e.CreatedAt)
+}
+
+func (r *PointsLedgerRepo) ListByCustomer(ctx context.Context, customerID int64) ([]domain.PointsEntry, error) {
+	query := `
+		SELECT ` + ledgerColumns + `
+		FROM points_ledger
+		WHERE customer_id = $1
+		ORDER BY created_at DESC, id DESC`
+
+	return r.list(ctx, query, customerID)
+}
+
+const ledgerColumns = `id, customer_id, entry_type, points, transaction_id, order_id, redeem_rule_id, reason, remaining, expires_at, created_at`
+
+func (r *PointsLedgerRepo) list(ctx context.Context, query string, args ...any) ([]domain.PointsEntry, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []domain.PointsEntry{}
+	for rows.Next() {
+		var e domain.PointsEntry
+		var trxID, orderID uuid.NullUUID
+		var redeemRuleID sql.NullInt64
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&e.ID, &e.CustomerID, &e.Type, &e.Points, &trxID, &orderID, &redeemRuleID, &e.Reason,
+			&e.Remaining, &expiresAt, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.TransactionID = nullUUIDPtr(trxID)
+		e.OrderID = nullUUIDPtr(orderID)
+		e.RedeemRuleID = nullInt64Ptr(redeemRuleID)
+		if expiresAt.Valid {
+			e.ExpiresAt = &expiresAt.Time
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (r *PointsLedgerRepo) Balance(ctx context.Context, customerID int64) (int, error) {
+	var balance int
+	query := `SELECT COALESCE(SUM(points), 0) FROM points_ledger WHERE customer_id = $1`
+	err := r.db.QueryRowContext(ctx, query, customerID).Scan(&balance)
+	return balance, err
+}
+
+func (r *PointsLedgerRepo) ReassignCustomer(ctx context.Context, fromID, toID int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE points_ledger SET customer_id = $2 WHERE customer_id = $1`, fromID, toID)
+	return err
+}
+
+func (r *PointsLedgerRepo) ConsumeLots(ctx context.Context, customerID int64, points int) error {
+	// lots that never expire are spent last; lapsed lots are left for the expiry sweep
+	query := `
+		SELECT id, remaining FROM points_ledger
+		WHERE customer_id = $1 AND remaining > 0 AND (expires_at IS NULL OR expires_at > now())
+		ORDER BY expires_at ASC NULLS LAST, created_at, id
+		FOR UPDATE`
+
+	rows, err := r.db.QueryContext(ctx, query, customerID)
+	if err != nil {
+		return err
+	}
+	type lot struct {
+		id   int64
+		take int
+	}
+	var lots []lot
+	for rows.Next() && points > 0 {
+		var l lot
+		var remaining int
+		if err := rows.Scan(&l.id, &remaining); err != nil {
+			rows.Close()
+			return err
+		}
+		l.take = min(remaining, points)
+		points -= l.take
+		lots = append(lots, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// any shortfall (e.g. a clawback after the points were spent) is left on the balance
+	for _, l := range lots {
+		if _, err := r.db.ExecContext(ctx,
+			`UPDATE points_ledger SET remaining = remaining - $2 WHERE id = $1`, l.id, l.take); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PointsLedgerRepo) ListExpiredLots(ctx context.Context, now time.Time) ([]domain.PointsEntry, error) {
+	query := `
+		SELECT ` + ledgerColumns + `
+		FROM points_ledger
+		WHERE remaining > 0 AND expires_at <= $1
+		ORDER BY expires_at, id`
+
+	return r.list(ctx, query, now)
+}
+
+func (r *PointsLedgerRepo) ListCustomerExpiredLots(ctx context.Context, customerID int64, now time.Time) ([]domain.PointsEntry, error) {
+	query := `
+		SELECT ` + ledgerColumns + `
+		FROM points_ledger
+		WHERE customer_id = $1 AND remaining > 0 AND expires_at <= $2
+		ORDER BY expires_at, id`
+
+	return r.list(ctx, query, customerID, now)
+}
+
+func (r *PointsLedgerRepo) ExpireLot(ctx context.Context, id int64, now time.Time) (int, error) {
+	// the row lock keeps a concurrent redemption from spending the lot while it expires
+	var remaining int
+	query := `
+		SELECT remaining FROM points_ledger
+		WHERE id = $1 AND remaining > 0 AND expires_at <= $2
+		FOR UPDATE`
+
+	err := r.db.QueryRowContext(ctx, query, id, now).Scan(&remaining)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = r.db.ExecContext(ctx, `UPDATE points_ledger SET remaining = 0 WHERE id = $1`, id)
+	return remaining, err
+}
+
+func (r *PointsLedgerRepo) ExpiringBefore(ctx context.Context, customerID int64, cutoff time.Time) (int, *time.Time, error) {
+	query := `
+		SELECT COALESCE(SUM(remaining), 0), MIN(expires_at)
+		FROM points_ledger
+		WHERE customer_id = $1 AND remaining > 0 AND expires_at <= $2`
+
+	var points int
+	var next sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query, customerID, cutoff).Scan(&points, &next); err != nil {
+		return 0, nil, err
+	}
+	if !next.Valid {
+		return points, nil, nil
+	}
+	return points, &next.Time, nil
+}
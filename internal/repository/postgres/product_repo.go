@@ -5,73 +5,377 @@ import (
 	"bsnack/internal/port"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 )
 
 type ProductRepo struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewProductRepo(db *sql.DB) port.ProductRepository {
 	return &ProductRepo{db: db}
 }
 
+const productColumns = `id, name, type, flavor, size, price`
+
+// newestBatch is the manufacturing_date column of a product row: the date of its newest batch at any store
+const newestBatch = `COALESCE((SELECT TO_CHAR(MAX(b.manufacturing_date), 'YYYY-MM-DD') FROM stock_batches b WHERE b.product_id = products.id), '')`
+
+// batchExpiry is the expiry date of the stock_batches row aliased b: the date it was
+// received with, else its manufacturing date plus its product type's shelf life. It is
+// NULL when neither is known and the batch never expires.
+const batchExpiry = `COALESCE(b.expiry_date, b.manufacturing_date +
+	(SELECT sl.days FROM shelf_lives sl JOIN products sp ON sp.type = sl.product_type WHERE sp.id = b.product_id))`
+
+// batchOrder is the order sales take from batches: first to expire, then oldest
+const batchOrder = batchExpiry + ` NULLS LAST, b.manufacturing_date, b.id`
+
+// unexpired keeps the batches that can still be sold today
+const unexpired = `COALESCE(` + batchExpiry + ` >= CURRENT_DATE, TRUE)`
+
+// totalStock and storeStock are the quantity column of a product row: the unexpired
+// stock across every store, or at the store bound to the placeholder
+const (
+	totalStock = `COALESCE((SELECT SUM(b.quantity) FROM stock_batches b WHERE b.product_id = products.id AND ` + unexpired + `), 0)`
+	storeStock = `COALESCE((SELECT SUM(b.quantity) FROM stock_batches b WHERE b.product_id = products.id AND b.store_id = %s AND ` +
+		unexpired + `), 0)`
+)
+
+// productSortColumns whitelists the ORDER BY targets for List
+var productSortColumns = map[string]string{
+	"id":                 "id",
+	"name":               "name",
+	"price":              "price",
+	"quantity":           "quantity",
+	"manufacturing_date": "manufacturing_date",
+}
+
+// Create inserts the product with its stock at p.StoreID, or at the main store without
+// one, as a batch made on p.ManufacturingDate. The batch is recorded even when empty so
+// the product has a manufacturing date.
 func (r *ProductRepo) Create(ctx context.Context, p *domain.Product) error {
 	query := `
-		INSERT INTO products (name, type, flavor, size, price, quantity, manufacturing_date) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+		WITH p AS (
+			INSERT INTO products (name, type, flavor, size, price) 
+			VALUES ($1, $2, $3, $4, $5) RETURNING id
+		)
+		INSERT INTO stock_batches (store_id, product_id, lot, manufacturing_date, quantity)
+		SELECT $6, id, $7, $8, $9 FROM p RETURNING product_id`
 	return r.db.QueryRowContext(ctx, query,
-		p.Name, p.Type, p.Flavor, p.Size, p.Price, p.Quantity, p.ManufacturingDate,
+		p.Name, p.Type, p.Flavor, p.Size, p.Price,
+		stockStore(p), domain.DefaultLot(p.ManufacturingDate), p.ManufacturingDate, p.Quantity,
 	).Scan(&p.ID)
 }
 
 func (r *ProductRepo) GetByID(ctx context.Context, id int64) (*domain.Product, error) {
+	query := `SELECT ` + productColumns + `, ` + newestBatch + `, ` + totalStock + ` FROM products WHERE id = $1 AND archived_at IS NULL`
+	return r.get(ctx, query, id)
+}
+
+func (r *ProductRepo) GetAtStore(ctx context.Context, storeID, id int64) (*domain.Product, error) {
+	query := `SELECT ` + productColumns + `, ` + newestBatch + `, ` + fmt.Sprintf(storeStock, "$2") +
+		` FROM products WHERE id = $1 AND archived_at IS NULL`
+	p, err := r.get(ctx, query, id, storeID)
+	if err != nil {
+		return nil, err
+	}
+	p.StoreID = &storeID
+	return p, nil
+}
+
+func (r *ProductRepo) GetAtStoreForUpdate(ctx context.Context, storeID, id int64) (*domain.Product, error) {
+	// the stock is a sum, so lock the batches it adds up before reading it
+	_, err := r.db.ExecContext(ctx,
+		`SELECT id FROM stock_batches WHERE store_id = $1 AND product_id = $2 FOR UPDATE`, storeID, id)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetAtStore(ctx, storeID, id)
+}
+
+func (r *ProductRepo) get(ctx context.Context, query string, args ...any) (*domain.Product, error) {
 	p := &domain.Product{}
-	query := `SELECT id, name, type, flavor, size, price, quantity, manufacturing_date FROM products WHERE id = $1`
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&p.ID, &p.Name, &p.Type, &p.Flavor, &p.Size, &p.Price, &p.Quantity, &p.ManufacturingDate,
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(
+		&p.ID, &p.Name, &p.Type, &p.Flavor, &p.Size, &p.Price, &p.ManufacturingDate, &p.Quantity,
 	)
-	return p, err
+	if err != nil {
+		return nil, translateErr(err, "product not found")
+	}
+	return p, nil
 }
 
-func (r *ProductRepo) GetByDate(ctx context.Context, date string) ([]domain.Product, error) {
-	query := `
-		SELECT id, name, type, flavor, size, price, quantity, manufacturing_date 
-		FROM products WHERE manufacturing_date = $1`
+func (r *ProductRepo) List(ctx context.Context, f domain.ProductFilter) ([]domain.Product, int, error) {
+	where := []string{"archived_at IS NULL"}
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if f.Type != "" {
+		where = append(where, "type = "+arg(f.Type))
+	}
+	if f.Flavor != "" {
+		where = append(where, "flavor = "+arg(f.Flavor))
+	}
+	if f.Size != "" {
+		where = append(where, "size = "+arg(f.Size))
+	}
+	if f.MinPrice != nil {
+		where = append(where, "price >= "+arg(*f.MinPrice))
+	}
+	if f.MaxPrice != nil {
+		where = append(where, "price <= "+arg(*f.MaxPrice))
+	}
+	stock := totalStock
+	if f.StoreID != nil {
+		stock = fmt.Sprintf(storeStock, arg(*f.StoreID))
+	}
+	if f.InStock {
+		where = append(where, stock+" > 0")
+	}
+	if f.Search != "" {
+		where = append(where, "name ILIKE "+arg("%"+escapeLike(f.Search)+"%"))
+	}
+	if f.ManufacturingDate != "" {
+		where = append(where, "EXISTS (SELECT 1 FROM stock_batches b WHERE b.product_id = products.id AND b.manufacturing_date = "+
+			arg(f.ManufacturingDate)+")")
+	}
+	whereSQL := " WHERE " + strings.Join(where, " AND ")
 
-	rows, err := r.db.QueryContext(ctx, query, date)
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM products`+whereSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order := "id"
+	if col, ok := productSortColumns[strings.TrimPrefix(f.Sort, "-")]; ok {
+		order = col
+		if strings.HasPrefix(f.Sort, "-") {
+			order += " DESC"
+		}
+	}
+	// id breaks ties so pages are stable
+	query := `SELECT ` + productColumns + `, ` + newestBatch + ` AS manufacturing_date, ` + stock + ` AS quantity FROM products` + whereSQL +
+		` ORDER BY ` + order + `, id LIMIT ` + arg(f.PageSize) + ` OFFSET ` + arg((f.Page-1)*f.PageSize)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
-	var products []domain.Product
+	products := []domain.Product{}
 	for rows.Next() {
 		var p domain.Product
-		if err := rows.Scan(&p.ID, &p.Name, &p.Type, &p.Flavor, &p.Size, &p.Price, &p.Quantity, &p.ManufacturingDate); err != nil {
-			return nil, err
+		if err := rows.Scan(&p.ID, &p.Name, &p.Type, &p.Flavor, &p.Size, &p.Price, &p.ManufacturingDate, &p.Quantity); err != nil {
+			return nil, 0, err
 		}
+		p.StoreID = f.StoreID
 		products = append(products, p)
 	}
-	return products, nil
+	return products, total, rows.Err()
 }
-func (r *ProductRepo) UpdateStock(ctx context.Context, id int64, delta int) error {
-	// fmt.Printf("DEBUG: Updating Stock for ID %d with delta %d\n", id, delta)
 
-	query := `UPDATE products SET quantity = quantity + $1 WHERE id = $2`
+func (r *ProductRepo) Update(ctx context.Context, p *domain.Product) error {
+	query := `
+		UPDATE products 
+		SET name = $1, type = $2, flavor = $3, size = $4, price = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6 AND archived_at IS NULL`
 
-	res, err := r.db.ExecContext(ctx, query, delta, id)
+	res, err := r.db.ExecContext(ctx, query, p.Name, p.Type, p.Flavor, p.Size, p.Price, p.ID)
 	if err != nil {
 		return err
 	}
+	return requireRow(res, "product not found")
+}
 
-	rows, err := res.RowsAffected()
+func stockStore(p *domain.Product) int64 {
+	if p.StoreID == nil {
+		return domain.MainStoreID
+	}
+	return *p.StoreID
+}
+
+func (r *ProductRepo) Archive(ctx context.Context, id int64) error {
+	query := `UPDATE products SET archived_at = CURRENT_TIMESTAMP WHERE id = $1 AND archived_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
-		return fmt.Errorf("product with id %d not found during stock update", id)
+	return requireRow(res, "product not found")
+}
+
+func (r *ProductRepo) ListBatches(ctx context.Context, id int64, storeID *int64) ([]domain.Batch, error) {
+	query := `
+		SELECT b.id, b.store_id, b.product_id, b.lot, b.manufacturing_date, ` + batchExpiry + `, b.quantity, b.received_at
+		FROM stock_batches b
+		WHERE b.product_id = $1 AND ($2::int IS NULL OR b.store_id = $2) AND b.quantity > 0
+		ORDER BY ` + batchOrder
+
+	rows, err := r.db.QueryContext(ctx, query, id, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := []domain.Batch{}
+	for rows.Next() {
+		var b domain.Batch
+		var made time.Time
+		var expiry sql.NullTime
+		if err := rows.Scan(&b.ID, &b.StoreID, &b.ProductID, &b.Lot, &made, &expiry, &b.Quantity, &b.ReceivedAt); err != nil {
+			return nil, err
+		}
+		b.ManufacturingDate = made.Format("2006-01-02")
+		b.ExpiryDate = nullDatePtr(expiry)
+		batches = append(batches, b)
+	}
+	return batches, rows.Err()
+}
+
+func (r *ProductRepo) ReceiveBatch(ctx context.Context, b *domain.Batch) error {
+	// the update only matches a lot with the same dates; anything else returns no row
+	query := `
+		INSERT INTO stock_batches (store_id, product_id, lot, manufacturing_date, expiry_date, quantity)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (store_id, product_id, lot) DO UPDATE SET quantity = stock_batches.quantity + EXCLUDED.quantity
+		WHERE stock_batches.manufacturing_date = EXCLUDED.manufacturing_date
+			AND stock_batches.expiry_date IS NOT DISTINCT FROM EXCLUDED.expiry_date
+		RETURNING id, quantity, received_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		b.StoreID, b.ProductID, b.Lot, b.ManufacturingDate, b.ExpiryDate, b.Quantity,
+	).Scan(&b.ID, &b.Quantity, &b.ReceivedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.NewConflictError("lot already received with other dates")
+	}
+	return err
+}
+
+func (r *ProductRepo) DecrementStock(ctx context.Context, storeID, id int64, qty int) ([]domain.BatchAllocation, error) {
+	// lock the store's batches so concurrent sales take from them one after another
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT b.id, b.lot, b.manufacturing_date, `+batchExpiry+`, b.quantity
+		FROM stock_batches b
+		WHERE b.store_id = $1 AND b.product_id = $2 AND b.quantity > 0 AND `+unexpired+`
+		ORDER BY `+batchOrder+`
+		FOR UPDATE`, storeID, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var taken []domain.BatchAllocation
+	need := qty
+	for rows.Next() && need > 0 {
+		var a domain.BatchAllocation
+		var made time.Time
+		var expiry sql.NullTime
+		if err := rows.Scan(&a.BatchID, &a.Lot, &made, &expiry, &a.Quantity); err != nil {
+			return nil, err
+		}
+		a.ManufacturingDate = made.Format("2006-01-02")
+		a.ExpiryDate = nullDatePtr(expiry)
+		a.Quantity = min(a.Quantity, need)
+		need -= a.Quantity
+		taken = append(taken, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if need > 0 {
+		var exists bool
+		if err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)`, id).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, domain.NewNotFoundError(fmt.Sprintf("product with id %d not found during stock update", id))
+		}
+
+		// the units are on the shelf but can no longer be sold
+		var expired int
+		err := r.db.QueryRowContext(ctx, `
+			SELECT COALESCE(SUM(b.quantity), 0) FROM stock_batches b
+			WHERE b.store_id = $1 AND b.product_id = $2 AND NOT `+unexpired, storeID, id).Scan(&expired)
+		if err != nil {
+			return nil, err
+		}
+		if expired >= need {
+			return nil, domain.ErrExpiredStock
+		}
+		return nil, domain.ErrInsufficientStock
+	}
+
+	for _, a := range taken {
+		if _, err := r.db.ExecContext(ctx, `UPDATE stock_batches SET quantity = quantity - $1 WHERE id = $2`, a.Quantity, a.BatchID); err != nil {
+			return nil, err
+		}
+	}
+	return taken, nil
+}
+
+func (r *ProductRepo) RestoreStock(ctx context.Context, batches []domain.BatchAllocation) error {
+	for _, a := range batches {
+		res, err := r.db.ExecContext(ctx, `UPDATE stock_batches SET quantity = quantity + $1 WHERE id = $2`, a.Quantity, a.BatchID)
+		if err != nil {
+			return err
+		}
+		if err := requireRow(res, fmt.Sprintf("batch %d not found during stock update", a.BatchID)); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
+// insertAllocations records the batches a transaction or redemption took stock from;
+// column names the owner's key in batch_allocations
+func insertAllocations(ctx context.Context, db DBTX, column string, id any, batches []domain.BatchAllocation) error {
+	for _, a := range batches {
+		_, err := db.ExecContext(ctx,
+			`INSERT INTO batch_allocations (batch_id, `+column+`, quantity) VALUES ($1, $2, $3)`, a.BatchID, id, a.Quantity)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// listAllocations returns the batches a transaction or redemption took stock from, in
+// the order they were taken
+func listAllocations(ctx context.Context, db DBTX, column string, id any) ([]domain.BatchAllocation, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT a.batch_id, b.lot, b.manufacturing_date, `+batchExpiry+`, a.quantity
+		FROM batch_allocations a
+		JOIN stock_batches b ON a.batch_id = b.id
+		WHERE a.`+column+` = $1 ORDER BY a.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []domain.BatchAllocation
+	for rows.Next() {
+		var a domain.BatchAllocation
+		var made time.Time
+		var expiry sql.NullTime
+		if err := rows.Scan(&a.BatchID, &a.Lot, &made, &expiry, &a.Quantity); err != nil {
+			return nil, err
+		}
+		a.ManufacturingDate = made.Format("2006-01-02")
+		a.ExpiryDate = nullDatePtr(expiry)
+		batches = append(batches, a)
+	}
+	return batches, rows.Err()
+}
+
+// escapeLike makes user input match literally inside a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
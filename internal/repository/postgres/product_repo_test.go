@@ -0,0 +1,75 @@
+package postgres_test
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/repository/postgres"
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var batchColumns = []string{"id", "lot", "manufacturing_date", "expiry_date", "quantity"}
+
+func batchRow(id int64, lot string, made string, qty int64) []driver.Value {
+	d, _ := time.Parse("2006-01-02", made)
+	return []driver.Value{id, lot, d, nil, qty}
+}
+
+func TestDecrementStock_LocksAndTakesInSaleOrder(t *testing.T) {
+	db, fake := newFakeDB(t,
+		fakeStep{match: "FROM stock_batches b", columns: batchColumns, rows: [][]driver.Value{
+			batchRow(3, "L0901", "2025-09-01", 1),
+			batchRow(4, "L0915", "2025-09-15", 5),
+		}},
+		fakeStep{match: "UPDATE stock_batches SET quantity = quantity - $1"},
+		fakeStep{match: "UPDATE stock_batches SET quantity = quantity - $1"},
+	)
+	repo := postgres.NewProductRepo(db)
+
+	taken, err := repo.DecrementStock(context.TODO(), 2, 1, 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 4}, []int64{taken[0].BatchID, taken[1].BatchID})
+	assert.Equal(t, []int{1, 2}, []int{taken[0].Quantity, taken[1].Quantity})
+	// the batches are read under a row lock, so a concurrent sale waits for this one
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(fake.Ran[0]), "FOR UPDATE"))
+	fake.Done()
+}
+
+func TestDecrementStock_ShortfallIsInsufficientStock(t *testing.T) {
+	db, fake := newFakeDB(t,
+		fakeStep{match: "FROM stock_batches b", columns: batchColumns, rows: [][]driver.Value{
+			batchRow(3, "L0901", "2025-09-01", 1),
+		}},
+		fakeStep{match: "SELECT EXISTS", columns: []string{"exists"}, rows: [][]driver.Value{{true}}},
+		fakeStep{match: "NOT COALESCE", columns: []string{"sum"}, rows: [][]driver.Value{{int64(0)}}},
+	)
+	repo := postgres.NewProductRepo(db)
+
+	_, err := repo.DecrementStock(context.TODO(), 2, 1, 2)
+
+	// the last unit went to an earlier sale; nothing is taken from the locked batches
+	assert.ErrorIs(t, err, domain.ErrInsufficientStock)
+	for _, q := range fake.Ran {
+		assert.NotContains(t, q, "UPDATE stock_batches")
+	}
+	fake.Done()
+}
+
+func TestDecrementStock_ShortfallCoveredByExpiredUnits(t *testing.T) {
+	db, fake := newFakeDB(t,
+		fakeStep{match: "FROM stock_batches b", columns: batchColumns},
+		fakeStep{match: "SELECT EXISTS", columns: []string{"exists"}, rows: [][]driver.Value{{true}}},
+		fakeStep{match: "NOT COALESCE", columns: []string{"sum"}, rows: [][]driver.Value{{int64(4)}}},
+	)
+	repo := postgres.NewProductRepo(db)
+
+	_, err := repo.DecrementStock(context.TODO(), 2, 1, 2)
+
+	assert.ErrorIs(t, err, domain.ErrExpiredStock)
+	fake.Done()
+}
@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type PromotionRepo struct {
+	db DBTX
+}
+
+func NewPromotionRepo(db *sql.DB) port.PromotionRepository {
+	return &PromotionRepo{db: db}
+}
+
+const promotionColumns = `id, name, kind, product_id, product_type, percent, amount, buy_quantity, free_quantity,
+	bundle_quantity, bundle_price, code, usage_limit, usage_count, start_date, end_date, active, created_at, updated_at`
+
+func (r *PromotionRepo) ActivePromotions(ctx context.Context) ([]domain.Promotion, error) {
+	return r.list(ctx, `SELECT `+promotionColumns+` FROM promotions WHERE active ORDER BY id`)
+}
+
+func (r *PromotionRepo) ListAll(ctx context.Context) ([]domain.Promotion, error) {
+	return r.list(ctx, `SELECT `+promotionColumns+` FROM promotions ORDER BY id`)
+}
+
+func (r *PromotionRepo) list(ctx context.Context, query string) ([]domain.Promotion, error) {
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promos := []domain.Promotion{}
+	for rows.Next() {
+		var p domain.Promotion
+		var productID, usageLimit sql.NullInt64
+		var productType, code sql.NullString
+		var start, end time.Time
+		if err := rows.Scan(&p.ID, &p.Name, &p.Kind, &productID, &productType, &p.Percent, &p.Amount,
+			&p.BuyQuantity, &p.FreeQuantity, &p.BundleQuantity, &p.BundlePrice, &code, &usageLimit, &p.UsageCount,
+			&start, &end, &p.Active, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		p.ProductID = nullInt64Ptr(productID)
+		p.ProductType = nullStringPtr(productType)
+		p.Code = nullStringPtr(code)
+		if usageLimit.Valid {
+			limit := int(usageLimit.Int64)
+			p.UsageLimit = &limit
+		}
+		p.StartDate = start.Format("2006-01-02")
+		p.EndDate = end.Format("2006-01-02")
+		promos = append(promos, p)
+	}
+	return promos, rows.Err()
+}
+
+func (r *PromotionRepo) Create(ctx context.Context, p *domain.Promotion) error {
+	query := `
+		INSERT INTO promotions (name, kind, product_id, product_type, percent, amount, buy_quantity, free_quantity,
+			bundle_quantity, bundle_price, code, usage_limit, start_date, end_date, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id, usage_count, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		p.Name, p.Kind, p.ProductID, p.ProductType, p.Percent, p.Amount, p.BuyQuantity, p.FreeQuantity,
+		p.BundleQuantity, p.BundlePrice, p.Code, p.UsageLimit, p.StartDate, p.EndDate, p.Active,
+	).Scan(&p.ID, &p.UsageCount, &p.CreatedAt, &p.UpdatedAt)
+	return translateConflict(err)
+}
+
+// Update replaces the promotion's terms but keeps its usage count
+func (r *PromotionRepo) Update(ctx context.Context, p *domain.Promotion) error {
+	query := `
+		UPDATE promotions
+		SET name = $1, kind = $2, product_id = $3, product_type = $4, percent = $5, amount = $6, buy_quantity = $7,
+			free_quantity = $8, bundle_quantity = $9, bundle_price = $10, code = $11, usage_limit = $12,
+			start_date = $13, end_date = $14, active = $15, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $16 RETURNING usage_count, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		p.Name, p.Kind, p.ProductID, p.ProductType, p.Percent, p.Amount, p.BuyQuantity, p.FreeQuantity,
+		p.BundleQuantity, p.BundlePrice, p.Code, p.UsageLimit, p.StartDate, p.EndDate, p.Active, p.ID,
+	).Scan(&p.UsageCount, &p.CreatedAt, &p.UpdatedAt)
+	return translateErr(err, "promotion not found")
+}
+
+func (r *PromotionRepo) Deactivate(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE promotions SET active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return requireRow(res, "promotion not found")
+}
+
+func (r *PromotionRepo) ReleaseCoupon(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE promotions SET usage_count = usage_count - 1 WHERE id = $1 AND usage_count > 0`, id)
+	return err
+}
+
+func (r *PromotionRepo) UseCoupon(ctx context.Context, id int64) error {
+	// the limit is checked in the UPDATE so concurrent sales cannot overshoot it
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE promotions SET usage_count = usage_count + 1
+		WHERE id = $1 AND (usage_limit IS NULL OR usage_count < usage_limit)`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.NewConflictError("coupon usage limit reached")
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type RedemptionRepo struct {
+	db DBTX
+}
+
+func NewRedemptionRepo(db *sql.DB) port.RedemptionRepository {
+	return &RedemptionRepo{db: db}
+}
+
+// Create inserts the redemption and the batches it drew on; run it in a unit of work
+func (r *RedemptionRepo) Create(ctx context.Context, rd *domain.Redemption) error {
+	query := `
+		INSERT INTO redemptions (customer_id, product_id, quantity, points_spent, redeem_rule_id, points_entry_id, store_id,
+			redeemed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, query,
+		rd.CustomerID, rd.ProductID, rd.Quantity, rd.PointsSpent, rd.RedeemRuleID, rd.PointsEntryID, rd.StoreID,
+		rd.RedeemedAt,
+	).Scan(&rd.ID)
+	if err != nil {
+		return err
+	}
+	return insertAllocations(ctx, r.db, "redemption_id", rd.ID, rd.Batches)
+}
+
+func (r *RedemptionRepo) List(ctx context.Context, f domain.RedemptionFilter) ([]domain.Redemption, error) {
+	where := []string{"TRUE"}
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if f.StartDate != "" {
+		where = append(where, "r.redeemed_at::date >= "+arg(f.StartDate)+"::date")
+	}
+	if f.EndDate != "" {
+		where = append(where, "r.redeemed_at::date <= "+arg(f.EndDate)+"::date")
+	}
+	if f.CustomerID > 0 {
+		where = append(where, "r.customer_id = "+arg(f.CustomerID))
+	}
+	if f.StoreID > 0 {
+		where = append(where, "r.store_id = "+arg(f.StoreID))
+	}
+
+	query := `
+		SELECT r.id, r.customer_id, c.name, r.product_id, p.name, p.size, p.flavor,
+			r.quantity, r.points_spent, r.redeem_rule_id, r.points_entry_id, r.store_id, r.redeemed_at
+		FROM redemptions r
+		JOIN customers c ON r.customer_id = c.id
+		JOIN products p ON r.product_id = p.id
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY r.redeemed_at DESC, r.id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	redemptions := []domain.Redemption{}
+	for rows.Next() {
+		var rd domain.Redemption
+		var ruleID sql.NullInt64
+		if err := rows.Scan(&rd.ID, &rd.CustomerID, &rd.CustomerName, &rd.ProductID, &rd.ProductName, &rd.ProductSize,
+			&rd.ProductFlavor, &rd.Quantity, &rd.PointsSpent, &ruleID, &rd.PointsEntryID, &rd.StoreID, &rd.RedeemedAt); err != nil {
+			return nil, err
+		}
+		rd.RedeemRuleID = nullInt64Ptr(ruleID)
+		redemptions = append(redemptions, rd)
+	}
+	return redemptions, rows.Err()
+}
+
+func (r *RedemptionRepo) ReassignCustomer(ctx context.Context, fromID, toID int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE redemptions SET customer_id = $2 WHERE customer_id = $1`, fromID, toID)
+	return err
+}
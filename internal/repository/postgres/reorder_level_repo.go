@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"database/sql"
+)
+
+type ReorderLevelRepo struct {
+	db DBTX
+}
+
+func NewReorderLevelRepo(db *sql.DB) port.ReorderLevelRepository {
+	return &ReorderLevelRepo{db: db}
+}
+
+func (r *ReorderLevelRepo) Set(ctx context.Context, l *domain.ReorderLevel) error {
+	query := `
+		INSERT INTO reorder_levels (product_id, reorder_point, reorder_quantity) VALUES ($1, $2, $3)
+		ON CONFLICT (product_id) DO UPDATE
+		SET reorder_point = EXCLUDED.reorder_point, reorder_quantity = EXCLUDED.reorder_quantity, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at`
+	return r.db.QueryRowContext(ctx, query, l.ProductID, l.Point, l.Quantity).Scan(&l.UpdatedAt)
+}
+
+func (r *ReorderLevelRepo) Delete(ctx context.Context, productID int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM reorder_levels WHERE product_id = $1`, productID)
+	if err != nil {
+		return err
+	}
+	return requireRow(res, "reorder level not found")
+}
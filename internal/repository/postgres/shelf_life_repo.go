@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"database/sql"
+)
+
+type ShelfLifeRepo struct {
+	db DBTX
+}
+
+func NewShelfLifeRepo(db *sql.DB) port.ShelfLifeRepository {
+	return &ShelfLifeRepo{db: db}
+}
+
+func (r *ShelfLifeRepo) ListAll(ctx context.Context) ([]domain.ShelfLife, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT product_type, days, updated_at FROM shelf_lives ORDER BY product_type`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lives := []domain.ShelfLife{}
+	for rows.Next() {
+		var s domain.ShelfLife
+		if err := rows.Scan(&s.ProductType, &s.Days, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		lives = append(lives, s)
+	}
+	return lives, rows.Err()
+}
+
+func (r *ShelfLifeRepo) Set(ctx context.Context, s *domain.ShelfLife) error {
+	query := `
+		INSERT INTO shelf_lives (product_type, days) VALUES ($1, $2)
+		ON CONFLICT (product_type) DO UPDATE SET days = EXCLUDED.days, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at`
+	return r.db.QueryRowContext(ctx, query, s.ProductType, s.Days).Scan(&s.UpdatedAt)
+}
+
+func (r *ShelfLifeRepo) Delete(ctx context.Context, productType string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM shelf_lives WHERE product_type = $1`, productType)
+	if err != nil {
+		return err
+	}
+	return requireRow(res, "shelf life not found")
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"database/sql"
+)
+
+type ShiftRepo struct {
+	db DBTX
+}
+
+func NewShiftRepo(db *sql.DB) port.ShiftRepository {
+	return &ShiftRepo{db: db}
+}
+
+const shiftColumns = `id, store_id, till, cashier, opening_float, opened_at, closed_at, expected_cash, counted_cash`
+
+func (r *ShiftRepo) Create(ctx context.Context, s *domain.Shift) error {
+	query := `
+		INSERT INTO shifts (store_id, till, cashier, opening_float)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, opened_at`
+
+	err := r.db.QueryRowContext(ctx, query, s.StoreID, s.Till, s.Cashier, s.OpeningFloat).Scan(&s.ID, &s.OpenedAt)
+	return translateConflict(err)
+}
+
+// GetByID takes a shared lock when bound to a unit of work, so sales on the shift
+// can run side by side but the shift cannot close under them
+func (r *ShiftRepo) GetByID(ctx context.Context, id int64) (*domain.Shift, error) {
+	return r.get(ctx, `SELECT `+shiftColumns+` FROM shifts WHERE id = $1 FOR SHARE`, id)
+}
+
+// GetForClose waits for sales in flight on the shift and blocks new ones until the
+// unit of work ends
+func (r *ShiftRepo) GetForClose(ctx context.Context, id int64) (*domain.Shift, error) {
+	return r.get(ctx, `SELECT `+shiftColumns+` FROM shifts WHERE id = $1 FOR UPDATE`, id)
+}
+
+func (r *ShiftRepo) get(ctx context.Context, query string, id int64) (*domain.Shift, error) {
+	var s domain.Shift
+	var closedAt sql.NullTime
+	var expected, counted *domain.Money
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&s.ID, &s.StoreID, &s.Till, &s.Cashier, &s.OpeningFloat, &s.OpenedAt, &closedAt, &expected, &counted,
+	)
+	if err != nil {
+		return nil, translateErr(err, "shift not found")
+	}
+	if closedAt.Valid && expected != nil && counted != nil {
+		s.Close(*expected, *counted, closedAt.Time)
+	}
+	return &s, nil
+}
+
+func (r *ShiftRepo) Close(ctx context.Context, s *domain.Shift) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE shifts SET closed_at = $2, expected_cash = $3, counted_cash = $4
+		WHERE id = $1 AND closed_at IS NULL`,
+		s.ID, s.ClosedAt, s.ExpectedCash, s.CountedCash)
+	if err != nil {
+		return err
+	}
+	return requireRow(res, "shift not found")
+}
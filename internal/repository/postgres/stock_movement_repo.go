@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type StockMovementRepo struct {
+	db DBTX
+}
+
+func NewStockMovementRepo(db *sql.DB) port.StockMovementRepository {
+	return &StockMovementRepo{db: db}
+}
+
+func (r *StockMovementRepo) Record(ctx context.Context, movements []domain.StockMovement) error {
+	query := `
+		WITH b AS (
+			SELECT id, store_id, product_id, lot FROM stock_batches WHERE id = $1
+		), m AS (
+			INSERT INTO stock_movements (batch_id, store_id, product_id, kind, quantity, reason, actor, reference)
+			SELECT id, store_id, product_id, $2, $3, $4, $5, $6 FROM b
+			RETURNING id, store_id, product_id, created_at
+		)
+		SELECT m.id, m.store_id, m.product_id, b.lot, m.created_at FROM m, b`
+
+	for i := range movements {
+		m := &movements[i]
+		err := r.db.QueryRowContext(ctx, query,
+			m.BatchID, m.Kind, m.Quantity, m.Reason, m.Actor, m.Reference,
+		).Scan(&m.ID, &m.StoreID, &m.ProductID, &m.Lot, &m.CreatedAt)
+		if err != nil {
+			return translateErr(err, fmt.Sprintf("batch %d not found", m.BatchID))
+		}
+	}
+	return nil
+}
+
+func (r *StockMovementRepo) List(ctx context.Context, f domain.MovementFilter) ([]domain.StockMovement, error) {
+	// the balance runs over the whole history, so the filters other than the product
+	// and store apply after it is summed
+	args := []any{f.ProductID, f.StoreID}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{"TRUE"}
+	if f.Kind != "" {
+		where = append(where, "kind = "+arg(f.Kind))
+	}
+	if f.StartDate != "" {
+		where = append(where, "created_at::date >= "+arg(f.StartDate)+"::date")
+	}
+	if f.EndDate != "" {
+		where = append(where, "created_at::date <= "+arg(f.EndDate)+"::date")
+	}
+
+	query := `
+		SELECT id, store_id, product_id, batch_id, lot, kind, quantity, balance, reason, actor, reference, created_at
+		FROM (
+			SELECT m.*, b.lot, SUM(m.quantity) OVER (PARTITION BY m.store_id ORDER BY m.id) AS balance
+			FROM stock_movements m
+			JOIN stock_batches b ON m.batch_id = b.id
+			WHERE m.product_id = $1 AND ($2::int IS NULL OR m.store_id = $2)
+		) m
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := []domain.StockMovement{}
+	for rows.Next() {
+		var m domain.StockMovement
+		if err := rows.Scan(&m.ID, &m.StoreID, &m.ProductID, &m.BatchID, &m.Lot, &m.Kind, &m.Quantity, &m.Balance,
+			&m.Reason, &m.Actor, &m.Reference, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+	return movements, rows.Err()
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"database/sql"
+)
+
+type StocktakeRepo struct {
+	db DBTX
+}
+
+func NewStocktakeRepo(db *sql.DB) port.StocktakeRepository {
+	return &StocktakeRepo{db: db}
+}
+
+const stocktakeColumns = `id, store_id, opened_by, opened_at, finalized_by, finalized_at`
+
+func (r *StocktakeRepo) Create(ctx context.Context, s *domain.Stocktake) error {
+	query := `INSERT INTO stocktakes (store_id, opened_by) VALUES ($1, $2) RETURNING id, opened_at`
+
+	err := r.db.QueryRowContext(ctx, query, s.StoreID, s.OpenedBy).Scan(&s.ID, &s.OpenedAt)
+	return translateConflict(err)
+}
+
+func (r *StocktakeRepo) GetByID(ctx context.Context, id int64) (*domain.Stocktake, error) {
+	return r.get(ctx, `SELECT `+stocktakeColumns+` FROM stocktakes WHERE id = $1`, id)
+}
+
+func (r *StocktakeRepo) GetForUpdate(ctx context.Context, id int64) (*domain.Stocktake, error) {
+	return r.get(ctx, `SELECT `+stocktakeColumns+` FROM stocktakes WHERE id = $1 FOR UPDATE`, id)
+}
+
+func (r *StocktakeRepo) get(ctx context.Context, query string, id int64) (*domain.Stocktake, error) {
+	var s domain.Stocktake
+	var finalizedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&s.ID, &s.StoreID, &s.OpenedBy, &s.OpenedAt, &s.FinalizedBy, &finalizedAt,
+	)
+	if err != nil {
+		return nil, translateErr(err, "stocktake not found")
+	}
+	if finalizedAt.Valid {
+		s.FinalizedAt = &finalizedAt.Time
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT c.product_id, products.name, products.flavor, products.size, c.counted,
+			c.system_quantity, c.counted_by, c.counted_at
+		FROM stocktake_counts c
+		JOIN products ON products.id = c.product_id
+		WHERE c.stocktake_id = $1
+		ORDER BY products.name, products.flavor, c.product_id`, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	s.Lines = []domain.StocktakeLine{}
+	for rows.Next() {
+		var l domain.StocktakeLine
+		var system int
+		if err := rows.Scan(&l.ProductID, &l.ProductName, &l.ProductFlavor, &l.ProductSize, &l.Counted,
+			&system, &l.CountedBy, &l.CountedAt); err != nil {
+			return nil, err
+		}
+		l.Reconcile(system)
+		s.Lines = append(s.Lines, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *StocktakeRepo) SaveCounts(ctx context.Context, id int64, lines []domain.StocktakeLine) error {
+	query := `
+		INSERT INTO stocktake_counts (stocktake_id, product_id, counted, counted_by, system_quantity)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (stocktake_id, product_id) DO UPDATE
+		SET counted = EXCLUDED.counted, counted_by = EXCLUDED.counted_by, counted_at = CURRENT_TIMESTAMP,
+			system_quantity = EXCLUDED.system_quantity`
+
+	for _, l := range lines {
+		if _, err := r.db.ExecContext(ctx, query, id, l.ProductID, l.Counted, l.CountedBy, l.System); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *StocktakeRepo) Finalize(ctx context.Context, s *domain.Stocktake) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE stocktakes SET finalized_by = $2, finalized_at = $3
+		WHERE id = $1 AND finalized_at IS NULL`,
+		s.ID, s.FinalizedBy, s.FinalizedAt)
+	if err != nil {
+		return err
+	}
+	return requireRow(res, "stocktake not found")
+}
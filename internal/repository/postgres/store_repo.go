@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"database/sql"
+)
+
+type StoreRepo struct {
+	db DBTX
+}
+
+func NewStoreRepo(db *sql.DB) port.StoreRepository {
+	return &StoreRepo{db: db}
+}
+
+const storeColumns = `id, code, name, address, tax_id, active, created_at, updated_at`
+
+func (r *StoreRepo) ListAll(ctx context.Context) ([]domain.Store, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+storeColumns+` FROM stores ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stores := []domain.Store{}
+	for rows.Next() {
+		var s domain.Store
+		if err := rows.Scan(&s.ID, &s.Code, &s.Name, &s.Address, &s.TaxID, &s.Active, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		stores = append(stores, s)
+	}
+	return stores, rows.Err()
+}
+
+func (r *StoreRepo) GetByID(ctx context.Context, id int64) (*domain.Store, error) {
+	var s domain.Store
+	err := r.db.QueryRowContext(ctx, `SELECT `+storeColumns+` FROM stores WHERE id = $1`, id).Scan(
+		&s.ID, &s.Code, &s.Name, &s.Address, &s.TaxID, &s.Active, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, translateErr(err, "store not found")
+	}
+	return &s, nil
+}
+
+func (r *StoreRepo) Create(ctx context.Context, s *domain.Store) error {
+	query := `
+		INSERT INTO stores (code, name, address, tax_id, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, s.Code, s.Name, s.Address, s.TaxID, s.Active).
+		Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+	return translateConflict(err)
+}
+
+func (r *StoreRepo) Update(ctx context.Context, s *domain.Store) error {
+	query := `
+		UPDATE stores
+		SET code = $1, name = $2, address = $3, tax_id = $4, active = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6 RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, s.Code, s.Name, s.Address, s.TaxID, s.Active, s.ID).
+		Scan(&s.CreatedAt, &s.UpdatedAt)
+	return translateErr(err, "store not found")
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"database/sql"
+)
+
+type TaxRateRepo struct {
+	db DBTX
+}
+
+func NewTaxRateRepo(db *sql.DB) port.TaxRateRepository {
+	return &TaxRateRepo{db: db}
+}
+
+const taxRateColumns = `id, name, product_type, basis_points, inclusive, active, created_at, updated_at`
+
+func (r *TaxRateRepo) ActiveRates(ctx context.Context) (domain.TaxRates, error) {
+	return r.list(ctx, `SELECT `+taxRateColumns+` FROM tax_rates WHERE active ORDER BY id`)
+}
+
+func (r *TaxRateRepo) ListAll(ctx context.Context) (domain.TaxRates, error) {
+	return r.list(ctx, `SELECT `+taxRateColumns+` FROM tax_rates ORDER BY id`)
+}
+
+func (r *TaxRateRepo) list(ctx context.Context, query string) (domain.TaxRates, error) {
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := domain.TaxRates{}
+	for rows.Next() {
+		var rate domain.TaxRate
+		var productType sql.NullString
+		if err := rows.Scan(&rate.ID, &rate.Name, &productType, &rate.BasisPoints, &rate.Inclusive,
+			&rate.Active, &rate.CreatedAt, &rate.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rate.ProductType = nullStringPtr(productType)
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
+
+func (r *TaxRateRepo) Create(ctx context.Context, rate *domain.TaxRate) error {
+	query := `
+		INSERT INTO tax_rates (name, product_type, basis_points, inclusive, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		rate.Name, rate.ProductType, rate.BasisPoints, rate.Inclusive, rate.Active,
+	).Scan(&rate.ID, &rate.CreatedAt, &rate.UpdatedAt)
+	return translateConflict(err)
+}
+
+func (r *TaxRateRepo) Update(ctx context.Context, rate *domain.TaxRate) error {
+	query := `
+		UPDATE tax_rates
+		SET name = $1, product_type = $2, basis_points = $3, inclusive = $4, active = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6 RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		rate.Name, rate.ProductType, rate.BasisPoints, rate.Inclusive, rate.Active, rate.ID,
+	).Scan(&rate.CreatedAt, &rate.UpdatedAt)
+	return translateErr(err, "tax rate not found")
+}
+
+func (r *TaxRateRepo) Deactivate(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE tax_rates SET active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return requireRow(res, "tax rate not found")
+}
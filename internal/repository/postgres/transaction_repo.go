@@ -5,50 +5,327 @@ import (
 	"bsnack/internal/port"
 	"context"
 	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type TransactionRepo struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewTransactionRepo(db *sql.DB) port.TransactionRepository {
 	return &TransactionRepo{db: db}
 }
 
+// Create inserts the transaction with its discount breakdown and the batches it drew
+// on; run it in a unit of work so they are written together
 func (r *TransactionRepo) Create(ctx context.Context, t *domain.Transaction) error {
 	query := `
-		INSERT INTO transactions (customer_id, product_id, quantity, total_price, transaction_date) 
-		VALUES ($1, $2, $3, $4, $5) RETURNING id`
+		INSERT INTO transactions (order_id, refund_of, customer_id, product_id, quantity, subtotal, discount_total,
+			net_amount, tax_amount, tax_rate_id, tax_basis_points, tax_inclusive, total_price, points_earned,
+			points_balance, point_units, earn_rule_id, bonus_rule_id, customer_tier, store_id, shift_id,
+			transaction_date) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+		RETURNING id`
 
-	return r.db.QueryRowContext(ctx, query,
-		t.CustomerID, t.ProductID, t.Quantity, t.TotalPrice, t.TransactionDate,
+	tier := sql.NullString{String: string(t.CustomerTier), Valid: t.CustomerTier != ""}
+	err := r.db.QueryRowContext(ctx, query,
+		t.OrderID, t.RefundOf, t.CustomerID, t.ProductID, t.Quantity, t.Subtotal, t.DiscountTotal,
+		t.NetAmount, t.TaxAmount, t.TaxRateID, t.TaxBasisPoints, t.TaxInclusive, t.TotalPrice, t.PointsEarned,
+		t.PointsBalance, t.PointUnits, t.EarnRuleID, t.BonusRuleID, tier, t.StoreID, t.ShiftID,
+		t.TransactionDate,
 	).Scan(&t.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range t.Discounts {
+		code := sql.NullString{String: d.Code, Valid: d.Code != ""}
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO transaction_discounts (transaction_id, promotion_id, name, kind, code, amount)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			t.ID, d.PromotionID, d.Name, d.Kind, code, d.Amount)
+		if err != nil {
+			return err
+		}
+	}
+	return insertAllocations(ctx, r.db, "transaction_id", t.ID, t.Batches)
+}
+
+func (r *TransactionRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Transaction, error) {
+	t := &domain.Transaction{}
+	query := `
+		SELECT t.id, t.order_id, t.refund_of, t.customer_id, t.product_id, t.quantity, t.subtotal, t.discount_total,
+			t.net_amount, t.tax_amount, t.tax_rate_id, t.tax_basis_points, t.tax_inclusive, t.total_price, t.points_earned, t.point_units, t.earn_rule_id, t.bonus_rule_id, t.customer_tier, t.store_id, t.shift_id, t.transaction_date
+		FROM transactions t
+		WHERE t.id = $1
+		FOR UPDATE`
+
+	var orderID, refundOf uuid.NullUUID
+	var taxRateID, earnRuleID, bonusRuleID, shiftID sql.NullInt64
+	var tier sql.NullString
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&t.ID, &orderID, &refundOf, &t.CustomerID, &t.ProductID, &t.Quantity, &t.Subtotal, &t.DiscountTotal,
+		&t.NetAmount, &t.TaxAmount, &taxRateID, &t.TaxBasisPoints, &t.TaxInclusive, &t.TotalPrice, &t.PointsEarned, &t.PointUnits, &earnRuleID, &bonusRuleID, &tier, &t.StoreID, &shiftID, &t.TransactionDate,
+	)
+	if err != nil {
+		return nil, translateErr(err, "transaction not found")
+	}
+	if orderID.Valid {
+		t.OrderID = &orderID.UUID
+	}
+	if refundOf.Valid {
+		t.RefundOf = &refundOf.UUID
+	}
+	t.TaxRateID = nullInt64Ptr(taxRateID)
+	t.EarnRuleID = nullInt64Ptr(earnRuleID)
+	t.BonusRuleID = nullInt64Ptr(bonusRuleID)
+	t.ShiftID = nullInt64Ptr(shiftID)
+	t.CustomerTier = domain.CustomerTier(tier.String)
+
+	t.Discounts, err = r.discounts(ctx, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	t.Batches, err = listAllocations(ctx, r.db, "transaction_id", t.ID)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *TransactionRepo) discounts(ctx context.Context, id uuid.UUID) ([]domain.Discount, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT promotion_id, name, kind, COALESCE(code, ''), amount
+		FROM transaction_discounts WHERE transaction_id = $1 ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var discounts []domain.Discount
+	for rows.Next() {
+		var d domain.Discount
+		if err := rows.Scan(&d.PromotionID, &d.Name, &d.Kind, &d.Code, &d.Amount); err != nil {
+			return nil, err
+		}
+		discounts = append(discounts, d)
+	}
+	return discounts, rows.Err()
+}
+
+// GetReceipt loads a refund or a purchase made on its own as a single line receipt,
+// and an order line as the receipt of its whole order
+func (r *TransactionRepo) GetReceipt(ctx context.Context, id uuid.UUID) (*domain.Receipt, error) {
+	var orderID, refundOf uuid.NullUUID
+	var balance sql.NullInt64
+	store := &domain.Store{}
+	receipt := &domain.Receipt{Number: id, Store: store}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT t.order_id, t.refund_of, c.name, COALESCE(s.cashier, ''), t.transaction_date, t.points_earned,
+			t.points_balance, st.id, st.code, st.name, st.address, st.tax_id, st.active
+		FROM transactions t
+		JOIN customers c ON t.customer_id = c.id
+		JOIN stores st ON t.store_id = st.id
+		LEFT JOIN shifts s ON t.shift_id = s.id
+		WHERE t.id = $1`, id,
+	).Scan(&orderID, &refundOf, &receipt.CustomerName, &receipt.Cashier, &receipt.Date, &receipt.PointsEarned,
+		&balance, &store.ID, &store.Code, &store.Name, &store.Address, &store.TaxID, &store.Active)
+	if err != nil {
+		return nil, translateErr(err, "transaction not found")
+	}
+	receipt.Refund = refundOf.Valid
+
+	lines := `t.id = $1`
+	args := []any{id}
+	paidBy := "transaction_id"
+	if orderID.Valid && !refundOf.Valid {
+		receipt.Number = orderID.UUID
+		err := r.db.QueryRowContext(ctx,
+			`SELECT order_date, points_earned, points_balance FROM orders WHERE id = $1`, orderID.UUID,
+		).Scan(&receipt.Date, &receipt.PointsEarned, &balance)
+		if err != nil {
+			return nil, translateErr(err, "order not found")
+		}
+		// the order as sold; refunds of its lines have receipts of their own
+		lines = `t.order_id = $1 AND t.refund_of IS NULL`
+		args = []any{orderID.UUID}
+		paidBy = "order_id"
+	}
+	receipt.PointsBalance = nullIntPtr(balance)
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.id, t.product_id, p.name, p.size, p.flavor, t.quantity, t.subtotal, t.discount_total,
+			t.net_amount, t.tax_amount, t.tax_basis_points, t.tax_inclusive, t.total_price
+		FROM transactions t
+		JOIN products p ON t.product_id = p.id
+		WHERE `+lines+`
+		ORDER BY p.name, p.flavor, p.size`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.Transaction
+	for rows.Next() {
+		var t domain.Transaction
+		if err := rows.Scan(&t.ID, &t.ProductID, &t.ProductName, &t.ProductSize, &t.ProductFlavor, &t.Quantity,
+			&t.Subtotal, &t.DiscountTotal, &t.NetAmount, &t.TaxAmount, &t.TaxBasisPoints, &t.TaxInclusive,
+			&t.TotalPrice); err != nil {
+			return nil, err
+		}
+		items = append(items, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, t := range items {
+		if t.Discounts, err = r.discounts(ctx, t.ID); err != nil {
+			return nil, err
+		}
+		receipt.AddLine(t)
+	}
+
+	receipt.Payments, err = listPayments(ctx, r.db, paidBy, receipt.Number)
+	if err != nil {
+		return nil, err
+	}
+	return receipt, nil
 }
 
-func (r *TransactionRepo) GetReport(ctx context.Context, start, end string) (*domain.SalesReport, error) {
+func (r *TransactionRepo) GetRefundedQuantity(ctx context.Context, id uuid.UUID) (int, error) {
+	var refunded int
+	query := `SELECT COALESCE(-SUM(quantity), 0) FROM transactions WHERE refund_of = $1`
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&refunded)
+	return refunded, err
+}
+
+func (r *TransactionRepo) GetPointsBasis(ctx context.Context, t *domain.Transaction) (int64, int, error) {
+	var (
+		netUnits  int64
+		netPoints int
+	)
+
+	if t.OrderID != nil {
+		// order lines earn nothing themselves; the header holds the points
+		query := `
+			SELECT COALESCE(SUM(t.point_units), 0), o.points_earned + COALESCE(SUM(t.points_earned), 0)
+			FROM orders o
+			LEFT JOIN transactions t ON t.order_id = o.id
+			WHERE o.id = $1
+			GROUP BY o.id, o.points_earned`
+		err := r.db.QueryRowContext(ctx, query, *t.OrderID).Scan(&netUnits, &netPoints)
+		return netUnits, netPoints, err
+	}
+
+	query := `
+		SELECT COALESCE(SUM(point_units), 0), COALESCE(SUM(points_earned), 0)
+		FROM transactions
+		WHERE id = $1 OR refund_of = $1`
+	err := r.db.QueryRowContext(ctx, query, t.ID).Scan(&netUnits, &netPoints)
+	return netUnits, netPoints, err
+}
+
+func (r *TransactionRepo) GetRefundedCoupon(ctx context.Context, t *domain.Transaction) (*int64, error) {
+	// the sale lines of the purchase: the order's lines, or the transaction alone
+	sales := `SELECT id, quantity FROM transactions WHERE id = $1`
+	var purchase any = t.ID
+	if t.OrderID != nil {
+		sales = `SELECT id, quantity FROM transactions WHERE order_id = $1 AND refund_of IS NULL`
+		purchase = *t.OrderID
+	}
+	query := `
+		WITH sales AS (` + sales + `)
+		SELECT d.promotion_id
+		FROM transaction_discounts d
+		WHERE d.transaction_id IN (SELECT id FROM sales) AND d.code IS NOT NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM sales s
+				WHERE s.quantity + COALESCE((SELECT SUM(r.quantity) FROM transactions r WHERE r.refund_of = s.id), 0) > 0)
+		LIMIT 1`
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, query, purchase).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (r *TransactionRepo) RollingSpend(ctx context.Context, since time.Time) (map[int64]domain.Money, error) {
+	// refund rows are negative, so the sum is net of refunds
+	query := `
+		SELECT customer_id, SUM(total_price)
+		FROM transactions
+		WHERE transaction_date > $1
+		GROUP BY customer_id`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	spend := make(map[int64]domain.Money)
+	for rows.Next() {
+		var id int64
+		var total domain.Money
+		if err := rows.Scan(&id, &total); err != nil {
+			return nil, err
+		}
+		spend[id] = total
+	}
+	return spend, rows.Err()
+}
+
+func (r *TransactionRepo) ReassignCustomer(ctx context.Context, fromID, toID int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE transactions SET customer_id = $2 WHERE customer_id = $1`, fromID, toID)
+	return err
+}
+
+func (r *TransactionRepo) GetReport(ctx context.Context, start, end string, storeID *int64) (*domain.SalesReport, error) {
 	report := &domain.SalesReport{
 		StartDate:    start,
 		EndDate:      end,
+		StoreID:      storeID,
 		Transactions: []domain.Transaction{},
+		Orders:       []domain.Order{},
+		Payments:     []domain.PaymentTotal{},
 	}
 
-	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
-	if err != nil {
-		return nil, err
+	// run the report queries on one snapshot, unless already bound to a unit of work
+	var tx DBTX = r.db
+	if db, ok := r.db.(*sql.DB); ok {
+		sqlTx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+		if err != nil {
+			return nil, err
+		}
+		defer sqlTx.Rollback()
+		tx = sqlTx
 	}
-	defer tx.Rollback()
 
 	queryAgg := `
         SELECT 
             COUNT(DISTINCT customer_id), 
             COALESCE(SUM(quantity), 0), 
-            COALESCE(SUM(total_price), 0)
+            COALESCE(SUM(total_price), 0),
+            COALESCE(SUM(net_amount), 0),
+            COALESCE(SUM(tax_amount), 0),
+            COALESCE(SUM(discount_total), 0)
         FROM transactions 
         WHERE transaction_date::date >= $1::date 
-          AND transaction_date::date <= $2::date`
+          AND transaction_date::date <= $2::date
+          AND ($3::int IS NULL OR store_id = $3)`
 
-	err = tx.QueryRowContext(ctx, queryAgg, start, end).Scan(
-		&report.TotalCustomers, &report.TotalProducts, &report.TotalIncome,
+	err := tx.QueryRowContext(ctx, queryAgg, start, end, storeID).Scan(
+		&report.TotalCustomers, &report.TotalProducts, &report.TotalIncome, &report.TotalNet, &report.TotalTax,
+		&report.TotalDiscount,
 	)
 	if err != nil {
 		return nil, err
@@ -60,21 +337,66 @@ func (r *TransactionRepo) GetReport(ctx context.Context, start, end string) (*do
         JOIN products p ON t.product_id = p.id
         WHERE t.transaction_date::date >= $1::date 
           AND t.transaction_date::date <= $2::date
+          AND ($3::int IS NULL OR t.store_id = $3)
         GROUP BY p.name, p.flavor
         ORDER BY SUM(t.quantity) DESC LIMIT 1`
 
 	var bestSeller sql.NullString
-	err = tx.QueryRowContext(ctx, queryBest, start, end).Scan(&bestSeller)
+	err = tx.QueryRowContext(ctx, queryBest, start, end, storeID).Scan(&bestSeller)
 	if err == nil && bestSeller.Valid {
 		report.BestSeller = bestSeller.String
 	} else {
 		report.BestSeller = "No sales yet"
 	}
 
+	// redemptions move stock and points but are not sales, so they are totalled apart
+	queryRedeemed := `
+        SELECT COALESCE(SUM(quantity), 0), COALESCE(SUM(points_spent), 0)
+        FROM redemptions
+        WHERE redeemed_at::date >= $1::date
+          AND redeemed_at::date <= $2::date
+          AND ($3::int IS NULL OR store_id = $3)`
+
+	err = tx.QueryRowContext(ctx, queryRedeemed, start, end, storeID).Scan(&report.RedeemedUnits, &report.PointsRedeemed)
+	if err != nil {
+		return nil, err
+	}
+
+	// payments by method for reconciling the till; refunds are negative cash payouts
+	queryPayments := `
+        SELECT p.method, COUNT(*), SUM(p.amount), SUM(p.tendered), SUM(p.change_given)
+        FROM payments p
+        LEFT JOIN transactions t ON p.transaction_id = t.id
+        LEFT JOIN orders o ON p.order_id = o.id
+        WHERE COALESCE(t.transaction_date, o.order_date)::date >= $1::date
+          AND COALESCE(t.transaction_date, o.order_date)::date <= $2::date
+          AND ($3::int IS NULL OR COALESCE(t.store_id, o.store_id) = $3)
+        GROUP BY p.method
+        ORDER BY p.method`
+
+	paymentRows, err := tx.QueryContext(ctx, queryPayments, start, end, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer paymentRows.Close()
+
+	for paymentRows.Next() {
+		var total domain.PaymentTotal
+		if err := paymentRows.Scan(&total.Method, &total.Count, &total.Amount, &total.Tendered, &total.Change); err != nil {
+			return nil, err
+		}
+		report.Payments = append(report.Payments, total)
+	}
+	if err := paymentRows.Err(); err != nil {
+		return nil, err
+	}
+
 	// compare the month/year of customer creation with the month/year of the transaction.
 	queryList := `
         SELECT 
             t.id, 
+            t.order_id,
+            t.refund_of,
             t.customer_id,
             t.product_id,
             c.name, 
@@ -82,18 +404,30 @@ func (r *TransactionRepo) GetReport(ctx context.Context, start, end string) (*do
             p.size, 
             p.flavor, 
             t.quantity, 
+            t.subtotal,
+            t.discount_total,
+            t.net_amount,
+            t.tax_amount,
+            t.tax_basis_points,
+            t.tax_inclusive,
             t.total_price, 
+            t.points_earned,
+            t.store_id,
+            t.shift_id,
+            COALESCE(s.cashier, ''),
             t.transaction_date,
             (EXTRACT(MONTH FROM c.created_at) = EXTRACT(MONTH FROM t.transaction_date) AND 
              EXTRACT(YEAR FROM c.created_at) = EXTRACT(YEAR FROM t.transaction_date)) as is_new
         FROM transactions t
         JOIN customers c ON t.customer_id = c.id
         JOIN products p ON t.product_id = p.id
+        LEFT JOIN shifts s ON t.shift_id = s.id
         WHERE t.transaction_date::date >= $1::date 
           AND t.transaction_date::date <= $2::date
+          AND ($3::int IS NULL OR t.store_id = $3)
         ORDER BY t.transaction_date DESC`
 
-	rows, err := tx.QueryContext(ctx, queryList, start, end)
+	rows, err := tx.QueryContext(ctx, queryList, start, end, storeID)
 	if err != nil {
 		return nil, err
 	}
@@ -101,8 +435,12 @@ func (r *TransactionRepo) GetReport(ctx context.Context, start, end string) (*do
 
 	for rows.Next() {
 		var trx domain.Transaction
+		var orderID, refundOf uuid.NullUUID
+		var shiftID sql.NullInt64
 		if err := rows.Scan(
 			&trx.ID,
+			&orderID,
+			&refundOf,
 			&trx.CustomerID,
 			&trx.ProductID,
 			&trx.CustomerName,
@@ -110,19 +448,149 @@ func (r *TransactionRepo) GetReport(ctx context.Context, start, end string) (*do
 			&trx.ProductSize,
 			&trx.ProductFlavor,
 			&trx.Quantity,
+			&trx.Subtotal,
+			&trx.DiscountTotal,
+			&trx.NetAmount,
+			&trx.TaxAmount,
+			&trx.TaxBasisPoints,
+			&trx.TaxInclusive,
 			&trx.TotalPrice,
+			&trx.PointsEarned,
+			&trx.StoreID,
+			&shiftID,
+			&trx.Cashier,
 			&trx.TransactionDate,
 			&trx.IsNewCustomer,
 		); err != nil {
 			return nil, err
 		}
+		if orderID.Valid {
+			trx.OrderID = &orderID.UUID
+		}
+		if refundOf.Valid {
+			trx.RefundOf = &refundOf.UUID
+		}
+		trx.ShiftID = nullInt64Ptr(shiftID)
 
 		report.Transactions = append(report.Transactions, trx)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	queryOrders := `
+        SELECT o.id, o.customer_id, c.name, o.total_quantity, o.subtotal, o.discount_total, o.net_amount,
+            o.tax_amount, o.total_price, o.points_earned, o.store_id, o.shift_id, COALESCE(s.cashier, ''),
+            o.order_date
+        FROM orders o
+        JOIN customers c ON o.customer_id = c.id
+        LEFT JOIN shifts s ON o.shift_id = s.id
+        WHERE o.order_date::date >= $1::date 
+          AND o.order_date::date <= $2::date
+          AND ($3::int IS NULL OR o.store_id = $3)
+        ORDER BY o.order_date DESC`
 
-	if err := tx.Commit(); err != nil {
+	orderRows, err := tx.QueryContext(ctx, queryOrders, start, end, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer orderRows.Close()
+
+	for orderRows.Next() {
+		var o domain.Order
+		var shiftID sql.NullInt64
+		if err := orderRows.Scan(
+			&o.ID, &o.CustomerID, &o.CustomerName, &o.TotalQuantity, &o.Subtotal, &o.DiscountTotal, &o.NetAmount,
+			&o.TaxAmount, &o.TotalPrice, &o.PointsEarned, &o.StoreID, &shiftID, &o.Cashier, &o.OrderDate,
+		); err != nil {
+			return nil, err
+		}
+		o.ShiftID = nullInt64Ptr(shiftID)
+		report.Orders = append(report.Orders, o)
+	}
+	if err := orderRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// attach the line rows already loaded above to their order header
+	orderIdx := make(map[uuid.UUID]int, len(report.Orders))
+	for i, o := range report.Orders {
+		orderIdx[o.ID] = i
+		report.Orders[i].Lines = []domain.Transaction{}
+	}
+	for _, trx := range report.Transactions {
+		if trx.OrderID == nil {
+			continue
+		}
+		if i, ok := orderIdx[*trx.OrderID]; ok {
+			report.Orders[i].Lines = append(report.Orders[i].Lines, trx)
+		}
+	}
+
+	return report, nil
+}
+
+func (r *TransactionRepo) GetShiftReport(ctx context.Context, shift *domain.Shift) (*domain.ShiftReport, error) {
+	report := &domain.ShiftReport{Shift: *shift, Payments: []domain.PaymentTotal{}}
+
+	// run the report queries on one snapshot, unless already bound to a unit of work
+	var tx DBTX = r.db
+	if db, ok := r.db.(*sql.DB); ok {
+		sqlTx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+		if err != nil {
+			return nil, err
+		}
+		defer sqlTx.Rollback()
+		tx = sqlTx
+	}
+
+	// an order counts as one sale however many lines it has
+	queryAgg := `
+        SELECT
+            COUNT(DISTINCT COALESCE(order_id, id)) FILTER (WHERE refund_of IS NULL),
+            COALESCE(SUM(quantity) FILTER (WHERE refund_of IS NULL), 0),
+            COALESCE(SUM(total_price) FILTER (WHERE refund_of IS NULL), 0),
+            COALESCE(SUM(total_price) FILTER (WHERE refund_of IS NOT NULL), 0),
+            COALESCE(SUM(net_amount), 0),
+            COALESCE(SUM(tax_amount), 0),
+            COALESCE(SUM(discount_total), 0)
+        FROM transactions
+        WHERE shift_id = $1`
+
+	err := tx.QueryRowContext(ctx, queryAgg, shift.ID).Scan(
+		&report.Sales, &report.ItemsSold, &report.GrossSales, &report.Refunds, &report.TotalNet, &report.TotalTax,
+		&report.TotalDiscount,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	queryPayments := `
+        SELECT p.method, COUNT(*), SUM(p.amount), SUM(p.tendered), SUM(p.change_given)
+        FROM payments p
+        LEFT JOIN transactions t ON p.transaction_id = t.id
+        LEFT JOIN orders o ON p.order_id = o.id
+        WHERE COALESCE(t.shift_id, o.shift_id) = $1
+        GROUP BY p.method
+        ORDER BY p.method`
+
+	rows, err := tx.QueryContext(ctx, queryPayments, shift.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var total domain.PaymentTotal
+		if err := rows.Scan(&total.Method, &total.Count, &total.Amount, &total.Tendered, &total.Change); err != nil {
+			return nil, err
+		}
+		report.Payments = append(report.Payments, total)
+	}
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
+	report.Reconcile()
 	return report, nil
 }
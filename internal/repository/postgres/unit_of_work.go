@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"bsnack/internal/port"
+	"context"
+	"database/sql"
+)
+
+type UnitOfWork struct {
+	db *sql.DB
+}
+
+func NewUnitOfWork(db *sql.DB) port.UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do opens a transaction, binds every repository to it and commits only when fn succeeds
+func (u *UnitOfWork) Do(ctx context.Context, fn func(repos port.Repositories) error) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// rollback is a no-op once the transaction has been committed
+	defer tx.Rollback()
+
+	repos := port.Repositories{
+		Product:     &ProductRepo{db: tx},
+		Customer:    &CustomerRepo{db: tx},
+		Transaction: &TransactionRepo{db: tx},
+		Order:       &OrderRepo{db: tx},
+		Points:      &PointsLedgerRepo{db: tx},
+		Loyalty:     &LoyaltyRuleRepo{db: tx},
+		Redemption:  &RedemptionRepo{db: tx},
+		Promotion:   &PromotionRepo{db: tx},
+		Tax:         &TaxRateRepo{db: tx},
+		Payment:     &PaymentRepo{db: tx},
+		Shift:       &ShiftRepo{db: tx},
+		Store:       &StoreRepo{db: tx},
+		Movement:    &StockMovementRepo{db: tx},
+		Inventory:   &InventoryRepo{db: tx},
+		Stocktake:   &StocktakeRepo{db: tx},
+	}
+
+	if err := fn(repos); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
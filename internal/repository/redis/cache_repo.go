@@ -6,6 +6,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -19,13 +20,17 @@ func NewRedisRepo(client *redis.Client) port.CacheRepository {
 	return &RedisRepo{client: client}
 }
 
-// reportKey generates a unique key based on the date range
-func (r *RedisRepo) reportKey(start, end string) string {
-	return fmt.Sprintf("report:%s:%s", start, end)
+// reportKey generates a unique key based on the store and date range
+func (r *RedisRepo) reportKey(start, end string, storeID *int64) string {
+	store := "all"
+	if storeID != nil {
+		store = strconv.FormatInt(*storeID, 10)
+	}
+	return fmt.Sprintf("report:%s:%s:%s", store, start, end)
 }
 
-func (r *RedisRepo) GetReport(ctx context.Context, start, end string) (*domain.SalesReport, error) {
-	val, err := r.client.Get(ctx, r.reportKey(start, end)).Result()
+func (r *RedisRepo) GetReport(ctx context.Context, start, end string, storeID *int64) (*domain.SalesReport, error) {
+	val, err := r.client.Get(ctx, r.reportKey(start, end, storeID)).Result()
 	if err == redis.Nil {
 		return nil, nil // cache miss
 	}
@@ -40,12 +45,12 @@ func (r *RedisRepo) GetReport(ctx context.Context, start, end string) (*domain.S
 	return &report, nil
 }
 
-func (r *RedisRepo) SetReport(ctx context.Context, start, end string, report *domain.SalesReport, ttl time.Duration) error {
+func (r *RedisRepo) SetReport(ctx context.Context, start, end string, storeID *int64, report *domain.SalesReport, ttl time.Duration) error {
 	data, err := json.Marshal(report)
 	if err != nil {
 		return err
 	}
-	return r.client.Set(ctx, r.reportKey(start, end), data, ttl).Err()
+	return r.client.Set(ctx, r.reportKey(start, end, storeID), data, ttl).Err()
 }
 
 func (r *RedisRepo) InvalidateProducts(ctx context.Context, date string) error {
@@ -4,18 +4,285 @@ import (
 	"bsnack/internal/domain"
 	"bsnack/internal/port"
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 )
 
 type CustomerService struct {
-	repoCust port.CustomerRepository
+	uow        port.UnitOfWork
+	repoCust   port.CustomerRepository
+	repoPoints port.PointsLedgerRepository
+	expiry     domain.PointsExpiryPolicy
 }
 
-func NewCustomerService(rc port.CustomerRepository) *CustomerService {
+func NewCustomerService(
+	uow port.UnitOfWork,
+	rc port.CustomerRepository,
+	rl port.PointsLedgerRepository,
+	expiry domain.PointsExpiryPolicy,
+) *CustomerService {
 	return &CustomerService{
-		repoCust: rc,
+		uow:        uow,
+		repoCust:   rc,
+		repoPoints: rl,
+		expiry:     expiry,
 	}
 }
 
+// CustomerRequest is the editable part of a customer; points only change through sales and redemptions
+type CustomerRequest struct {
+	Name  string  `json:"name"`
+	Phone *string `json:"phone"`
+	Email *string `json:"email"`
+}
+
 func (s *CustomerService) GetAllCustomers(ctx context.Context) ([]domain.Customer, error) {
 	return s.repoCust.ListAll(ctx)
 }
+
+// GetCustomer also reports how many of the customer's points lapse within the warning window
+func (s *CustomerService) GetCustomer(ctx context.Context, id int64) (*domain.Customer, error) {
+	c, err := s.repoCust.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.ExpiringSoon, err = s.expiringSoon(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LookupCustomer finds a customer by one of the unique contact keys
+func (s *CustomerService) LookupCustomer(ctx context.Context, phone, email string) (*domain.Customer, error) {
+	switch {
+	case phone != "":
+		return s.repoCust.GetByPhone(ctx, *NormalizePhone(&phone))
+	case email != "":
+		return s.repoCust.GetByEmail(ctx, *NormalizeEmail(&email))
+	default:
+		return nil, domain.NewValidationError("phone or email is required")
+	}
+}
+
+func (s *CustomerService) CreateCustomer(ctx context.Context, req CustomerRequest) (*domain.Customer, error) {
+	c := &domain.Customer{
+		Name:  strings.TrimSpace(req.Name),
+		Phone: NormalizePhone(req.Phone),
+		Email: NormalizeEmail(req.Email),
+	}
+	if err := s.repoCust.Create(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *CustomerService) UpdateCustomer(ctx context.Context, id int64, req CustomerRequest) (*domain.Customer, error) {
+	c := &domain.Customer{
+		ID:    id,
+		Name:  strings.TrimSpace(req.Name),
+		Phone: NormalizePhone(req.Phone),
+		Email: NormalizeEmail(req.Email),
+	}
+	if err := s.repoCust.Update(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *CustomerService) DeleteCustomer(ctx context.Context, id int64) error {
+	return s.repoCust.Delete(ctx, id)
+}
+
+// MergeCustomers moves the source customer's transactions, orders, redemptions and points onto
+// the target and retires the source, all in one unit of work
+func (s *CustomerService) MergeCustomers(ctx context.Context, targetID, sourceID int64) (*domain.Customer, error) {
+	if targetID == sourceID {
+		return nil, domain.NewValidationError("cannot merge a customer into itself")
+	}
+
+	var merged *domain.Customer
+	err := s.uow.Do(ctx, func(repos port.Repositories) error {
+		// lock both customers in id order, so merges sharing a customer cannot deadlock
+		// and the source's balance cannot change before it moves
+		var source *domain.Customer
+		for _, id := range []int64{min(targetID, sourceID), max(targetID, sourceID)} {
+			c, err := repos.Customer.GetForUpdate(ctx, id)
+			if err != nil {
+				return err
+			}
+			if id == sourceID {
+				source = c
+			}
+		}
+
+		if err := repos.Transaction.ReassignCustomer(ctx, source.ID, targetID); err != nil {
+			return err
+		}
+		if err := repos.Order.ReassignCustomer(ctx, source.ID, targetID); err != nil {
+			return err
+		}
+		if err := repos.Redemption.ReassignCustomer(ctx, source.ID, targetID); err != nil {
+			return err
+		}
+		// the source's ledger moves with its balance so the target still reconciles
+		if err := repos.Points.ReassignCustomer(ctx, source.ID, targetID); err != nil {
+			return err
+		}
+		if err := repos.Customer.UpdatePoints(ctx, targetID, source.Points); err != nil {
+			return err
+		}
+		if err := repos.Customer.MarkMerged(ctx, source.ID, targetID); err != nil {
+			return err
+		}
+
+		var err error
+		merged, err = repos.Customer.GetByID(ctx, targetID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return merged, nil
+}
+
+// GetPointsHistory lists the ledger and checks it against the stored balance
+func (s *CustomerService) GetPointsHistory(ctx context.Context, id int64) (*domain.PointsHistory, error) {
+	customer, err := s.repoCust.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repoPoints.ListByCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ledgerBalance, err := s.repoPoints.Balance(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	expiring, err := s.expiringSoon(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.PointsHistory{
+		CustomerID:    customer.ID,
+		Balance:       customer.Points,
+		LedgerBalance: ledgerBalance,
+		Consistent:    customer.Points == ledgerBalance,
+		ExpiringSoon:  expiring,
+		Entries:       entries,
+	}, nil
+}
+
+// expiringSoon is nil when points never expire. Lots that lapsed but have not been
+// swept yet are included, since they can no longer be relied on.
+func (s *CustomerService) expiringSoon(ctx context.Context, id int64) (*domain.ExpiringPoints, error) {
+	if s.expiry.ValidMonths <= 0 {
+		return nil, nil
+	}
+	cutoff := s.expiry.ExpiringSoonCutoff(time.Now())
+	points, next, err := s.repoPoints.ExpiringBefore(ctx, id, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.ExpiringPoints{Points: points, Before: cutoff, NextExpiry: next}, nil
+}
+
+// ExpirePoints removes the unspent part of every lot that lapsed at or before now and
+// returns the number of points expired. Each lot expires in its own unit of work so one
+// failure does not hold back the rest of the sweep.
+func (s *CustomerService) ExpirePoints(ctx context.Context, now time.Time) (int, error) {
+	lots, err := s.repoPoints.ListExpiredLots(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	var expired int
+	var errs []error
+	for _, lot := range lots {
+		err := s.uow.Do(ctx, func(repos port.Repositories) error {
+			points, err := expireLot(ctx, repos, lot, now)
+			if err != nil {
+				return err
+			}
+			expired += points
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("expire points lot %d: %w", lot.ID, err))
+		}
+	}
+
+	return expired, errors.Join(errs...)
+}
+
+type PointsAdjustmentRequest struct {
+	Points int    `json:"points"`
+	Reason string `json:"reason"`
+}
+
+// AdjustPoints applies a manual correction; the balance may not go below zero
+func (s *CustomerService) AdjustPoints(ctx context.Context, id int64, req PointsAdjustmentRequest) (*domain.PointsEntry, error) {
+	if req.Points == 0 {
+		return nil, domain.NewValidationError("points must not be 0")
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, domain.NewValidationError("reason is required")
+	}
+
+	entry := &domain.PointsEntry{
+		CustomerID: id,
+		Type:       domain.PointsAdjustment,
+		Points:     req.Points,
+		Reason:     strings.TrimSpace(req.Reason),
+	}
+	err := s.uow.Do(ctx, func(repos port.Repositories) error {
+		customer, err := repos.Customer.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		lapsed, err := expireCustomerLots(ctx, repos, id, now)
+		if err != nil {
+			return err
+		}
+		if customer.Points-lapsed+req.Points < 0 {
+			return domain.ErrInsufficientPoints
+		}
+		return postPoints(ctx, repos, s.expiry, now, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// NormalizePhone strips formatting so the unique index sees one spelling per number
+func NormalizePhone(phone *string) *string {
+	if phone == nil {
+		return nil
+	}
+	p := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(*phone)
+	if p == "" {
+		return nil
+	}
+	return &p
+}
+
+func NormalizeEmail(email *string) *string {
+	if email == nil {
+		return nil
+	}
+	e := strings.ToLower(strings.TrimSpace(*email))
+	if e == "" {
+		return nil
+	}
+	return &e
+}
@@ -0,0 +1,324 @@
+package service_test
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"bsnack/internal/service"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMergeCustomers(t *testing.T) {
+	mockCust := new(MockCustomerRepo)
+	mockTrans := new(MockTransactionRepo)
+	mockOrder := new(MockOrderRepo)
+	mockLedger := new(MockPointsRepo)
+	mockRedeem := new(MockRedemptionRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Customer: mockCust, Transaction: mockTrans, Order: mockOrder, Points: mockLedger, Redemption: mockRedeem})
+	svc := service.NewCustomerService(uow, mockCust, mockLedger, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	target := &domain.Customer{ID: 1, Name: "Budi", Points: 100}
+	source := &domain.Customer{ID: 2, Name: "Budi", Points: 250}
+	merged := &domain.Customer{ID: 1, Name: "Budi", Points: 350}
+
+	mockCust.On("GetForUpdate", ctx, int64(1)).Return(target, nil)
+	mockCust.On("GetForUpdate", ctx, int64(2)).Return(source, nil)
+	mockTrans.On("ReassignCustomer", ctx, int64(2), int64(1)).Return(nil)
+	mockOrder.On("ReassignCustomer", ctx, int64(2), int64(1)).Return(nil)
+	mockRedeem.On("ReassignCustomer", ctx, int64(2), int64(1)).Return(nil)
+	mockLedger.On("ReassignCustomer", ctx, int64(2), int64(1)).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(1), 250).Return(nil)
+	mockCust.On("MarkMerged", ctx, int64(2), int64(1)).Return(nil)
+	mockCust.On("GetByID", ctx, int64(1)).Return(merged, nil)
+
+	res, err := svc.MergeCustomers(ctx, 1, 2)
+
+	assert.NoError(t, err)
+	assert.True(t, uow.Committed)
+	assert.Equal(t, 350, res.Points)
+	mockCust.AssertExpectations(t)
+	mockTrans.AssertExpectations(t)
+	mockOrder.AssertExpectations(t)
+	mockRedeem.AssertExpectations(t)
+	mockLedger.AssertExpectations(t)
+}
+
+func TestMergeCustomers_Self(t *testing.T) {
+	svc := service.NewCustomerService(nil, nil, nil, domain.PointsExpiryPolicy{})
+
+	_, err := svc.MergeCustomers(context.TODO(), 3, 3)
+
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
+
+func TestCreateCustomer_NormalizesContacts(t *testing.T) {
+	mockCust := new(MockCustomerRepo)
+	svc := service.NewCustomerService(nil, mockCust, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	phone, email, blank := "0812-3456 7890", " Budi@Example.COM ", " "
+	mockCust.On("Create", ctx, &domain.Customer{Name: "Budi", Phone: strPtr("081234567890"), Email: strPtr("budi@example.com")}).Return(nil)
+
+	c, err := svc.CreateCustomer(ctx, service.CustomerRequest{Name: " Budi ", Phone: &phone, Email: &email})
+	assert.NoError(t, err)
+	assert.Equal(t, "081234567890", *c.Phone)
+
+	assert.Nil(t, service.NormalizeEmail(&blank))
+}
+
+func TestPurchase_ByCustomerID(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Price: domain.NewMoney(5000), Quantity: 10}, nil)
+	mockCust.On("GetByID", ctx, int64(9)).Return(&domain.Customer{ID: 9, Name: "Budi"}, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 1).Return(nil, nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(9), 5).Return(nil)
+	mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+
+	_, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerID: 9, ProductID: 1, Quantity: 1, ShiftID: openShiftID})
+
+	assert.NoError(t, err)
+	mockCust.AssertNotCalled(t, "GetByName")
+	mockCust.AssertNotCalled(t, "Create")
+}
+
+func TestPurchase_UnknownCustomerID(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, nil, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Quantity: 10}, nil)
+	mockCust.On("GetByID", ctx, int64(9)).Return(nil, domain.NewNotFoundError("customer not found"))
+
+	_, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerID: 9, CustomerName: "Budi", ProductID: 1, Quantity: 1, ShiftID: openShiftID})
+
+	// an explicit id never falls back to registering by name
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	mockCust.AssertNotCalled(t, "Create")
+}
+
+func TestAdjustPoints(t *testing.T) {
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Customer: mockCust, Points: mockLedger})
+	svc := service.NewCustomerService(uow, mockCust, mockLedger, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockCust.On("GetForUpdate", ctx, int64(5)).Return(&domain.Customer{ID: 5, Points: 100}, nil)
+	mockLedger.On("ListCustomerExpiredLots", ctx, int64(5), mock.Anything).Return([]domain.PointsEntry{}, nil)
+	mockLedger.On("ConsumeLots", ctx, int64(5), 40).Return(nil)
+	mockLedger.On("Append", ctx, &domain.PointsEntry{
+		CustomerID: 5, Type: domain.PointsAdjustment, Points: -40, Reason: "duplicate earn",
+	}).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), -40).Return(nil)
+
+	entry, err := svc.AdjustPoints(ctx, 5, service.PointsAdjustmentRequest{Points: -40, Reason: " duplicate earn "})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PointsAdjustment, entry.Type)
+	mockLedger.AssertExpectations(t)
+	mockCust.AssertExpectations(t)
+}
+
+func TestAdjustPoints_BelowZero(t *testing.T) {
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Customer: mockCust, Points: mockLedger})
+	svc := service.NewCustomerService(uow, mockCust, mockLedger, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockCust.On("GetForUpdate", ctx, int64(5)).Return(&domain.Customer{ID: 5, Points: 30}, nil)
+	mockLedger.On("ListCustomerExpiredLots", ctx, int64(5), mock.Anything).Return([]domain.PointsEntry{}, nil)
+
+	_, err := svc.AdjustPoints(ctx, 5, service.PointsAdjustmentRequest{Points: -40, Reason: "correction"})
+
+	assert.ErrorIs(t, err, domain.ErrInsufficientPoints)
+	mockLedger.AssertNotCalled(t, "Append")
+}
+
+func TestAdjustPoints_LapsedPointsNotCounted(t *testing.T) {
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Customer: mockCust, Points: mockLedger})
+	svc := service.NewCustomerService(uow, mockCust, mockLedger, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	// 100 points still show on the balance but 80 of them lapsed before the sweep
+	mockCust.On("GetForUpdate", ctx, int64(5)).Return(&domain.Customer{ID: 5, Points: 100}, nil)
+	lot := domain.PointsEntry{ID: 3, CustomerID: 5}
+	mockLedger.On("ListCustomerExpiredLots", ctx, int64(5), mock.Anything).Return([]domain.PointsEntry{lot}, nil)
+	mockLedger.On("ExpireLot", ctx, int64(3), mock.Anything).Return(80, nil)
+	mockLedger.On("Append", ctx, mock.MatchedBy(func(e *domain.PointsEntry) bool {
+		return e.Type == domain.PointsExpire && e.Points == -80
+	})).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), -80).Return(nil)
+
+	_, err := svc.AdjustPoints(ctx, 5, service.PointsAdjustmentRequest{Points: -40, Reason: "correction"})
+
+	assert.ErrorIs(t, err, domain.ErrInsufficientPoints)
+	mockLedger.AssertExpectations(t)
+	mockLedger.AssertNotCalled(t, "ConsumeLots")
+}
+
+func TestAdjustPoints_RequiresReason(t *testing.T) {
+	svc := service.NewCustomerService(nil, nil, nil, domain.PointsExpiryPolicy{})
+
+	_, err := svc.AdjustPoints(context.TODO(), 5, service.PointsAdjustmentRequest{Points: 10, Reason: "  "})
+
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
+
+func TestGetPointsHistory_DetectsDrift(t *testing.T) {
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	svc := service.NewCustomerService(nil, mockCust, mockLedger, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	entries := []domain.PointsEntry{{ID: 2, Points: -200}, {ID: 1, Points: 630}}
+	mockCust.On("GetByID", ctx, int64(5)).Return(&domain.Customer{ID: 5, Points: 430}, nil)
+	mockLedger.On("ListByCustomer", ctx, int64(5)).Return(entries, nil)
+	mockLedger.On("Balance", ctx, int64(5)).Return(430, nil)
+
+	history, err := svc.GetPointsHistory(ctx, 5)
+	assert.NoError(t, err)
+	assert.True(t, history.Consistent)
+	assert.Len(t, history.Entries, 2)
+
+	mockCust.ExpectedCalls = nil
+	mockCust.On("GetByID", ctx, int64(5)).Return(&domain.Customer{ID: 5, Points: 500}, nil)
+
+	history, err = svc.GetPointsHistory(ctx, 5)
+	assert.NoError(t, err)
+	assert.False(t, history.Consistent)
+	assert.Equal(t, 430, history.LedgerBalance)
+}
+
+func TestPurchase_EarnedPointsExpire(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{ValidMonths: 12})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Price: domain.NewMoney(5000), Quantity: 10}, nil)
+	mockCust.On("GetByID", ctx, int64(5)).Return(&domain.Customer{ID: 5}, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 1).Return(nil, nil)
+	mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), 5).Return(nil)
+
+	var entry *domain.PointsEntry
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).
+		Run(func(args mock.Arguments) { entry = args.Get(1).(*domain.PointsEntry) }).Return(nil)
+
+	_, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerID: 5, ProductID: 1, Quantity: 1, ShiftID: openShiftID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, entry.Remaining)
+	if assert.NotNil(t, entry.ExpiresAt) {
+		assert.WithinDuration(t, time.Now().AddDate(1, 0, 0), *entry.ExpiresAt, time.Minute)
+	}
+}
+
+func TestExpirePoints(t *testing.T) {
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Customer: mockCust, Points: mockLedger})
+	svc := service.NewCustomerService(uow, mockCust, mockLedger, domain.PointsExpiryPolicy{ValidMonths: 12})
+	ctx := context.TODO()
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	lots := []domain.PointsEntry{
+		{ID: 1, CustomerID: 5, Points: 100, Remaining: 60},
+		{ID: 2, CustomerID: 7, Points: 50, Remaining: 50},
+	}
+	mockLedger.On("ListExpiredLots", ctx, now).Return(lots, nil)
+	mockLedger.On("ExpireLot", ctx, int64(1), now).Return(60, nil)
+	// lot 2 was spent by a redemption after it was listed
+	mockLedger.On("ExpireLot", ctx, int64(2), now).Return(0, nil)
+	mockLedger.On("Append", ctx, mock.MatchedBy(func(e *domain.PointsEntry) bool {
+		return e.CustomerID == 5 && e.Type == domain.PointsExpire && e.Points == -60
+	})).Return(nil).Once()
+	mockCust.On("UpdatePoints", ctx, int64(5), -60).Return(nil).Once()
+
+	expired, err := svc.ExpirePoints(ctx, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 60, expired)
+	mockLedger.AssertExpectations(t)
+	mockCust.AssertExpectations(t)
+	mockLedger.AssertNotCalled(t, "ConsumeLots", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExpirePoints_ContinuesAfterFailure(t *testing.T) {
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Customer: mockCust, Points: mockLedger})
+	svc := service.NewCustomerService(uow, mockCust, mockLedger, domain.PointsExpiryPolicy{ValidMonths: 12})
+	ctx := context.TODO()
+	now := time.Now()
+
+	lots := []domain.PointsEntry{{ID: 1, CustomerID: 5}, {ID: 2, CustomerID: 7}}
+	mockLedger.On("ListExpiredLots", ctx, now).Return(lots, nil)
+	mockLedger.On("ExpireLot", ctx, int64(1), now).Return(0, errors.New("db down"))
+	mockLedger.On("ExpireLot", ctx, int64(2), now).Return(20, nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(7), -20).Return(nil)
+
+	expired, err := svc.ExpirePoints(ctx, now)
+
+	assert.ErrorContains(t, err, "lot 1")
+	assert.Equal(t, 20, expired)
+	mockCust.AssertExpectations(t)
+}
+
+func TestGetCustomer_ExpiringSoon(t *testing.T) {
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	svc := service.NewCustomerService(nil, mockCust, mockLedger, domain.PointsExpiryPolicy{ValidMonths: 12, WarningDays: 30})
+	ctx := context.TODO()
+
+	next := time.Now().AddDate(0, 0, 10)
+	mockCust.On("GetByID", ctx, int64(5)).Return(&domain.Customer{ID: 5, Points: 300}, nil)
+	mockLedger.On("ExpiringBefore", ctx, int64(5), mock.AnythingOfType("time.Time")).Return(120, &next, nil)
+
+	c, err := svc.GetCustomer(ctx, 5)
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, c.ExpiringSoon) {
+		assert.Equal(t, 120, c.ExpiringSoon.Points)
+		assert.Equal(t, &next, c.ExpiringSoon.NextExpiry)
+		assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), c.ExpiringSoon.Before, time.Minute)
+	}
+}
+
+func TestGetCustomer_NoExpiryPolicy(t *testing.T) {
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	svc := service.NewCustomerService(nil, mockCust, mockLedger, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockCust.On("GetByID", ctx, int64(5)).Return(&domain.Customer{ID: 5, Points: 300}, nil)
+
+	c, err := svc.GetCustomer(ctx, 5)
+
+	assert.NoError(t, err)
+	assert.Nil(t, c.ExpiringSoon)
+	mockLedger.AssertNotCalled(t, "ExpiringBefore", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"strconv"
+	"strings"
+)
+
+// defaultWriteOffReason is recorded when a write-off is made without a reason
+const defaultWriteOffReason = "expired"
+
+// InventoryService tracks how long stock keeps and when to order more: the shelf life
+// of each product type, the batches coming up to their expiry date, write-offs of
+// expired stock, and reorder levels and suggestions
+type InventoryService struct {
+	uow       port.UnitOfWork
+	repo      port.InventoryRepository
+	repoShelf port.ShelfLifeRepository
+	repoLevel port.ReorderLevelRepository
+	repoProd  port.ProductRepository
+	repoStore port.StoreRepository
+}
+
+func NewInventoryService(uow port.UnitOfWork, ri port.InventoryRepository, rsl port.ShelfLifeRepository, rl port.ReorderLevelRepository, rp port.ProductRepository, rs port.StoreRepository) *InventoryService {
+	return &InventoryService{uow: uow, repo: ri, repoShelf: rsl, repoLevel: rl, repoProd: rp, repoStore: rs}
+}
+
+// ShelfLifeRequest sets the shelf life of ProductType, which comes from the path
+type ShelfLifeRequest struct {
+	ProductType string `json:"-"`
+	Days        int    `json:"days"`
+}
+
+func (s *InventoryService) ListShelfLives(ctx context.Context) ([]domain.ShelfLife, error) {
+	return s.repoShelf.ListAll(ctx)
+}
+
+// SetShelfLife creates or replaces a product type's shelf life. Batches received
+// without an expiry date are dated by it from then on, including those in stock.
+func (s *InventoryService) SetShelfLife(ctx context.Context, req ShelfLifeRequest) (*domain.ShelfLife, error) {
+	life := &domain.ShelfLife{ProductType: strings.TrimSpace(req.ProductType), Days: req.Days}
+	if err := s.repoShelf.Set(ctx, life); err != nil {
+		return nil, err
+	}
+	return life, nil
+}
+
+// DeleteShelfLife removes a product type's shelf life; its batches without an expiry
+// date no longer expire
+func (s *InventoryService) DeleteShelfLife(ctx context.Context, productType string) error {
+	return s.repoShelf.Delete(ctx, strings.TrimSpace(productType))
+}
+
+// ListExpiring returns the batches that expire within the window, including those
+// already expired and waiting to be written off
+func (s *InventoryService) ListExpiring(ctx context.Context, f domain.ExpiryFilter) ([]domain.ExpiringBatch, error) {
+	return s.repo.ListExpiring(ctx, f)
+}
+
+// WriteOffRequest takes expired stock off the shelf, at one store and of one product
+// when they are given. Reason defaults to "expired".
+type WriteOffRequest struct {
+	StoreID   *int64 `json:"store_id"`
+	ProductID *int64 `json:"product_id"`
+	Reason    string `json:"reason"`
+	Actor     string `json:"actor"`
+}
+
+// WriteOffExpired removes every expired unit the request covers from stock and returns
+// a write-off record per batch emptied; none when nothing has expired
+func (s *InventoryService) WriteOffExpired(ctx context.Context, req WriteOffRequest) ([]domain.WriteOff, error) {
+	if req.StoreID != nil {
+		if _, err := s.repoStore.GetByID(ctx, *req.StoreID); err != nil {
+			return nil, err
+		}
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		reason = defaultWriteOffReason
+	}
+	actor := strings.TrimSpace(req.Actor)
+
+	var writeOffs []domain.WriteOff
+	err := s.uow.Do(ctx, func(repos port.Repositories) error {
+		var err error
+		writeOffs, err = repos.Inventory.WriteOffExpired(ctx, req.StoreID, req.ProductID, reason, actor)
+		if err != nil {
+			return err
+		}
+		movements := make([]domain.StockMovement, len(writeOffs))
+		for i, w := range writeOffs {
+			movements[i] = domain.StockMovement{
+				BatchID:   w.BatchID,
+				Kind:      domain.MovementWriteOff,
+				Quantity:  -w.Quantity,
+				Reason:    reason,
+				Actor:     actor,
+				Reference: strconv.FormatInt(w.ID, 10),
+			}
+		}
+		return repos.Movement.Record(ctx, movements)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return writeOffs, nil
+}
+
+// ListWriteOffs returns write-offs matching the filter, newest first
+func (s *InventoryService) ListWriteOffs(ctx context.Context, f domain.WriteOffFilter) ([]domain.WriteOff, error) {
+	return s.repo.ListWriteOffs(ctx, f)
+}
+
+type ReorderLevelRequest struct {
+	ReorderPoint    int `json:"reorder_point"`
+	ReorderQuantity int `json:"reorder_quantity"`
+}
+
+// SetReorderLevel creates or replaces the reorder level of a product
+func (s *InventoryService) SetReorderLevel(ctx context.Context, productID int64, req ReorderLevelRequest) (*domain.ReorderLevel, error) {
+	if _, err := s.repoProd.GetByID(ctx, productID); err != nil {
+		return nil, err
+	}
+	level := &domain.ReorderLevel{ProductID: productID, Point: req.ReorderPoint, Quantity: req.ReorderQuantity}
+	if err := s.repoLevel.Set(ctx, level); err != nil {
+		return nil, err
+	}
+	return level, nil
+}
+
+// DeleteReorderLevel stops tracking a product for low stock
+func (s *InventoryService) DeleteReorderLevel(ctx context.Context, productID int64) error {
+	return s.repoLevel.Delete(ctx, productID)
+}
+
+// ListLowStock returns the products at or below their reorder point, at one store or
+// every active store when storeID is nil, the furthest below first
+func (s *InventoryService) ListLowStock(ctx context.Context, storeID *int64) ([]domain.StockLevel, error) {
+	return s.repo.ListLowStock(ctx, storeID)
+}
+
+// ReorderFilter asks for reorder suggestions at StoreID, or every active store when
+// nil, from the sales of the last LookbackDays to cover CoverDays of sales
+type ReorderFilter struct {
+	StoreID      *int64
+	LookbackDays int
+	CoverDays    int
+}
+
+// SuggestReorders returns the products worth reordering: those selling faster than
+// their stock covers and those at their reorder point
+func (s *InventoryService) SuggestReorders(ctx context.Context, f ReorderFilter) ([]domain.ReorderSuggestion, error) {
+	candidates, err := s.repo.ListSalesVelocity(ctx, f.StoreID, f.LookbackDays)
+	if err != nil {
+		return nil, err
+	}
+	suggestions := []domain.ReorderSuggestion{}
+	for _, c := range candidates {
+		c.Suggest(f.LookbackDays, f.CoverDays)
+		if c.SuggestedQuantity > 0 {
+			suggestions = append(suggestions, c)
+		}
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,52 @@
+package service_test
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"bsnack/internal/service"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteOffExpired_DefaultsReason(t *testing.T) {
+	mockInv := new(MockInventoryRepo)
+	moves := &MemoryMovementRepo{}
+	svc := service.NewInventoryService(NewMockUnitOfWork(port.Repositories{Inventory: mockInv, Movement: moves}), mockInv, nil, nil, nil, &StaticStoreRepo{})
+	ctx := context.TODO()
+	storeID := int64(2)
+
+	written := []domain.WriteOff{{ID: 1, BatchID: 4, StoreID: 2, ProductID: 1, Lot: "20251201", ExpiryDate: "2026-01-30", Quantity: 3, Reason: "expired", Actor: "Sari"}}
+	mockInv.On("WriteOffExpired", ctx, &storeID, (*int64)(nil), "expired", "Sari").Return(written, nil)
+
+	writeOffs, err := svc.WriteOffExpired(ctx, service.WriteOffRequest{StoreID: &storeID, Reason: "  ", Actor: " Sari "})
+
+	assert.NoError(t, err)
+	assert.Equal(t, written, writeOffs)
+	assert.Equal(t, []domain.StockMovement{{BatchID: 4, Kind: domain.MovementWriteOff, Quantity: -3, Reason: "expired", Actor: "Sari", Reference: "1"}}, moves.Movements)
+	mockInv.AssertExpectations(t)
+}
+
+func TestSuggestReorders_KeepsProductsToOrder(t *testing.T) {
+	mockInv := new(MockInventoryRepo)
+	svc := service.NewInventoryService(nil, mockInv, nil, nil, nil, &StaticStoreRepo{})
+	ctx := context.TODO()
+	point, qty := 5, 24
+
+	mockInv.On("ListSalesVelocity", ctx, (*int64)(nil), 30).Return([]domain.ReorderSuggestion{
+		{StockLevel: domain.StockLevel{ProductID: 1, Quantity: 10}, UnitsSold: 60},                                            // 2 a day
+		{StockLevel: domain.StockLevel{ProductID: 2, Quantity: 50}, UnitsSold: 30},                                            // covered
+		{StockLevel: domain.StockLevel{ProductID: 3, Quantity: 4, ReorderPoint: &point, ReorderQuantity: &qty}, UnitsSold: 0}, // at its point
+	}, nil)
+
+	suggestions, err := svc.SuggestReorders(ctx, service.ReorderFilter{LookbackDays: 30, CoverDays: 14})
+
+	assert.NoError(t, err)
+	if assert.Len(t, suggestions, 2) {
+		assert.Equal(t, int64(1), suggestions[0].ProductID)
+		assert.Equal(t, 18, suggestions[0].SuggestedQuantity, "28 units for 14 days, less 10 in stock")
+		assert.Equal(t, int64(3), suggestions[1].ProductID)
+		assert.Equal(t, 24, suggestions[1].SuggestedQuantity)
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"strings"
+	"time"
+)
+
+// LoyaltyService administers the earn, bonus and redemption rules and the tiers read by TransactionService
+type LoyaltyService struct {
+	repo     port.LoyaltyRuleRepository
+	repoProd port.ProductRepository
+}
+
+func NewLoyaltyService(rl port.LoyaltyRuleRepository, rp port.ProductRepository) *LoyaltyService {
+	return &LoyaltyService{repo: rl, repoProd: rp}
+}
+
+// EarnRuleRequest creates or replaces an earn rule; Active defaults to true
+type EarnRuleRequest struct {
+	Name          string       `json:"name"`
+	ProductType   *string      `json:"product_type"`
+	SpendPerPoint domain.Money `json:"spend_per_point"`
+	Active        *bool        `json:"active"`
+}
+
+type BonusRuleRequest struct {
+	Name              string         `json:"name"`
+	ProductType       *string        `json:"product_type"`
+	MultiplierPercent int            `json:"multiplier_percent"`
+	StartDate         string         `json:"start_date"`
+	EndDate           string         `json:"end_date"`
+	Weekdays          []time.Weekday `json:"weekdays"`
+	Active            *bool          `json:"active"`
+}
+
+type RedeemRuleRequest struct {
+	Name        string              `json:"name"`
+	ProductID   *int64              `json:"product_id"`
+	ProductSize *domain.ProductSize `json:"product_size"`
+	Points      int                 `json:"points"`
+	Active      *bool               `json:"active"`
+}
+
+type TierRuleRequest struct {
+	MinSpend          domain.Money `json:"min_spend"`
+	MultiplierPercent int          `json:"multiplier_percent"`
+}
+
+// GetRules lists every rule, including retired ones
+func (s *LoyaltyService) GetRules(ctx context.Context) (*domain.LoyaltyRules, error) {
+	return s.repo.ListAll(ctx)
+}
+
+func (s *LoyaltyService) CreateEarnRule(ctx context.Context, req EarnRuleRequest) (*domain.EarnRule, error) {
+	rule := req.rule()
+	if err := s.repo.CreateEarnRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *LoyaltyService) UpdateEarnRule(ctx context.Context, id int64, req EarnRuleRequest) (*domain.EarnRule, error) {
+	rule := req.rule()
+	rule.ID = id
+	if err := s.repo.UpdateEarnRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *LoyaltyService) CreateBonusRule(ctx context.Context, req BonusRuleRequest) (*domain.BonusRule, error) {
+	rule := req.rule()
+	if err := s.repo.CreateBonusRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *LoyaltyService) UpdateBonusRule(ctx context.Context, id int64, req BonusRuleRequest) (*domain.BonusRule, error) {
+	rule := req.rule()
+	rule.ID = id
+	if err := s.repo.UpdateBonusRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *LoyaltyService) CreateRedeemRule(ctx context.Context, req RedeemRuleRequest) (*domain.RedeemRule, error) {
+	rule, err := s.redeemRule(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateRedeemRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *LoyaltyService) UpdateRedeemRule(ctx context.Context, id int64, req RedeemRuleRequest) (*domain.RedeemRule, error) {
+	rule, err := s.redeemRule(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	rule.ID = id
+	if err := s.repo.UpdateRedeemRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// UpdateTier changes a tier's qualifying spend and earn multiplier. Customers move
+// between tiers at the next recalculation, not immediately.
+func (s *LoyaltyService) UpdateTier(ctx context.Context, tier domain.CustomerTier, req TierRuleRequest) (*domain.TierRule, error) {
+	if !tier.IsValid() {
+		return nil, domain.NewNotFoundError("tier not found")
+	}
+	rule := &domain.TierRule{Tier: tier, MinSpend: req.MinSpend, MultiplierPercent: req.MultiplierPercent}
+	if err := s.repo.UpdateTier(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeactivateRule retires a rule so it no longer applies to new sales and redemptions
+func (s *LoyaltyService) DeactivateRule(ctx context.Context, kind domain.LoyaltyRuleKind, id int64) error {
+	if !kind.IsValid() {
+		return domain.NewValidationError("rule kind must be one of earn, bonus, redeem")
+	}
+	return s.repo.Deactivate(ctx, kind, id)
+}
+
+func (s *LoyaltyService) redeemRule(ctx context.Context, req RedeemRuleRequest) (*domain.RedeemRule, error) {
+	if req.ProductID != nil {
+		// a rule for an unknown or archived product would never apply
+		if _, err := s.repoProd.GetByID(ctx, *req.ProductID); err != nil {
+			return nil, err
+		}
+	}
+	return &domain.RedeemRule{
+		Name:        strings.TrimSpace(req.Name),
+		ProductID:   req.ProductID,
+		ProductSize: req.ProductSize,
+		Points:      req.Points,
+		Active:      activeOrDefault(req.Active),
+	}, nil
+}
+
+func (req EarnRuleRequest) rule() *domain.EarnRule {
+	return &domain.EarnRule{
+		Name:          strings.TrimSpace(req.Name),
+		ProductType:   normalizeProductType(req.ProductType),
+		SpendPerPoint: req.SpendPerPoint,
+		Active:        activeOrDefault(req.Active),
+	}
+}
+
+func (req BonusRuleRequest) rule() *domain.BonusRule {
+	return &domain.BonusRule{
+		Name:              strings.TrimSpace(req.Name),
+		ProductType:       normalizeProductType(req.ProductType),
+		MultiplierPercent: req.MultiplierPercent,
+		StartDate:         req.StartDate,
+		EndDate:           req.EndDate,
+		Weekdays:          req.Weekdays,
+		Active:            activeOrDefault(req.Active),
+	}
+}
+
+// normalizeProductType treats a blank type as "every type"
+func normalizeProductType(t *string) *string {
+	if t == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*t)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+func activeOrDefault(active *bool) bool {
+	return active == nil || *active
+}
@@ -0,0 +1,72 @@
+package service_test
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/service"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateEarnRule_DefaultsToActive(t *testing.T) {
+	mockLoyalty := new(MockLoyaltyRepo)
+	svc := service.NewLoyaltyService(mockLoyalty, nil)
+	ctx := context.TODO()
+
+	mockLoyalty.On("CreateEarnRule", ctx, mock.MatchedBy(func(r *domain.EarnRule) bool {
+		return r.Active && r.Name == "Pangsit" && *r.ProductType == "Keripik Pangsit"
+	})).Return(nil)
+
+	rule, err := svc.CreateEarnRule(ctx, service.EarnRuleRequest{
+		Name:          " Pangsit ",
+		ProductType:   strPtr(" Keripik Pangsit "),
+		SpendPerPoint: domain.NewMoney(500),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(500), rule.SpendPerPoint)
+	mockLoyalty.AssertExpectations(t)
+}
+
+func TestCreateEarnRule_BlankTypeIsDefault(t *testing.T) {
+	mockLoyalty := new(MockLoyaltyRepo)
+	svc := service.NewLoyaltyService(mockLoyalty, nil)
+	ctx := context.TODO()
+
+	inactive := false
+	mockLoyalty.On("CreateEarnRule", ctx, mock.MatchedBy(func(r *domain.EarnRule) bool {
+		return r.ProductType == nil && !r.Active
+	})).Return(nil)
+
+	_, err := svc.CreateEarnRule(ctx, service.EarnRuleRequest{
+		Name: "Standard", ProductType: strPtr("  "), SpendPerPoint: domain.NewMoney(1000), Active: &inactive,
+	})
+
+	assert.NoError(t, err)
+	mockLoyalty.AssertExpectations(t)
+}
+
+func TestCreateRedeemRule_UnknownProduct(t *testing.T) {
+	mockLoyalty := new(MockLoyaltyRepo)
+	mockProd := new(MockProductRepo)
+	svc := service.NewLoyaltyService(mockLoyalty, mockProd)
+	ctx := context.TODO()
+
+	productID := int64(99)
+	mockProd.On("GetByID", ctx, productID).Return(nil, domain.NewNotFoundError("product not found"))
+
+	_, err := svc.CreateRedeemRule(ctx, service.RedeemRuleRequest{Name: "Gift", ProductID: &productID, Points: 50})
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	mockLoyalty.AssertNotCalled(t, "CreateRedeemRule", mock.Anything, mock.Anything)
+}
+
+func TestDeactivateRule_UnknownKind(t *testing.T) {
+	svc := service.NewLoyaltyService(nil, nil)
+
+	err := svc.DeactivateRule(context.TODO(), domain.LoyaltyRuleKind("tier"), 1)
+
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
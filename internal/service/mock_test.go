@@ -2,9 +2,13 @@ package service_test
 
 import (
 	"bsnack/internal/domain"
+	"bsnack/internal/port"
 	"context"
+	"slices"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -17,9 +21,17 @@ func (m *MockProductRepo) Create(ctx context.Context, p *domain.Product) error {
 	args := m.Called(ctx, p)
 	return args.Error(0)
 }
-func (m *MockProductRepo) GetByDate(ctx context.Context, date string) ([]domain.Product, error) {
-	args := m.Called(ctx, date)
-	return args.Get(0).([]domain.Product), args.Error(1)
+func (m *MockProductRepo) List(ctx context.Context, f domain.ProductFilter) ([]domain.Product, int, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).([]domain.Product), args.Int(1), args.Error(2)
+}
+func (m *MockProductRepo) Update(ctx context.Context, p *domain.Product) error {
+	args := m.Called(ctx, p)
+	return args.Error(0)
+}
+func (m *MockProductRepo) Archive(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
 }
 func (m *MockProductRepo) GetByID(ctx context.Context, id int64) (*domain.Product, error) {
 	args := m.Called(ctx, id)
@@ -28,16 +40,118 @@ func (m *MockProductRepo) GetByID(ctx context.Context, id int64) (*domain.Produc
 	}
 	return args.Get(0).(*domain.Product), args.Error(1)
 }
-func (m *MockProductRepo) UpdateStock(ctx context.Context, id int64, delta int) error {
-	args := m.Called(ctx, id, delta)
+func (m *MockProductRepo) GetAtStore(ctx context.Context, storeID, id int64) (*domain.Product, error) {
+	args := m.Called(ctx, storeID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Product), args.Error(1)
+}
+func (m *MockProductRepo) GetAtStoreForUpdate(ctx context.Context, storeID, id int64) (*domain.Product, error) {
+	args := m.Called(ctx, storeID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Product), args.Error(1)
+}
+func (m *MockProductRepo) ListBatches(ctx context.Context, id int64, storeID *int64) ([]domain.Batch, error) {
+	args := m.Called(ctx, id, storeID)
+	return args.Get(0).([]domain.Batch), args.Error(1)
+}
+func (m *MockProductRepo) ReceiveBatch(ctx context.Context, b *domain.Batch) error {
+	args := m.Called(ctx, b)
+	return args.Error(0)
+}
+func (m *MockProductRepo) DecrementStock(ctx context.Context, storeID, id int64, qty int) ([]domain.BatchAllocation, error) {
+	args := m.Called(ctx, storeID, id, qty)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.BatchAllocation), args.Error(1)
+}
+func (m *MockProductRepo) RestoreStock(ctx context.Context, batches []domain.BatchAllocation) error {
+	args := m.Called(ctx, batches)
 	return args.Error(0)
 }
 
+// StockProductRepo is a goroutine-safe in-memory product store that mimics the
+// conditional decrement done by Postgres, used for concurrency tests. It holds the
+// stock of a single store.
+type StockProductRepo struct {
+	MockProductRepo
+	mu       sync.Mutex
+	products map[int64]domain.Product
+}
+
+func NewStockProductRepo(products ...domain.Product) *StockProductRepo {
+	r := &StockProductRepo{products: map[int64]domain.Product{}}
+	for _, p := range products {
+		r.products[p.ID] = p
+	}
+	return r
+}
+
+func (r *StockProductRepo) GetAtStore(ctx context.Context, storeID, id int64) (*domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[id]
+	if !ok {
+		return nil, domain.NewNotFoundError("product not found")
+	}
+	return &p, nil
+}
+
+// DecrementStock takes from a single batch per product, numbered like the product
+func (r *StockProductRepo) DecrementStock(ctx context.Context, storeID, id int64, qty int) ([]domain.BatchAllocation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p := r.products[id]
+	if p.Quantity < qty {
+		return nil, domain.ErrInsufficientStock
+	}
+	p.Quantity -= qty
+	r.products[id] = p
+	return []domain.BatchAllocation{{BatchID: id, Quantity: qty}}, nil
+}
+func (r *StockProductRepo) Quantity(id int64) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.products[id].Quantity
+}
+
 // MockCustomerRepo mocks port.CustomerRepository
 type MockCustomerRepo struct {
 	mock.Mock
 }
 
+func (m *MockCustomerRepo) GetByID(ctx context.Context, id int64) (*domain.Customer, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Customer), args.Error(1)
+}
+func (m *MockCustomerRepo) GetForUpdate(ctx context.Context, id int64) (*domain.Customer, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Customer), args.Error(1)
+}
+func (m *MockCustomerRepo) GetByPhone(ctx context.Context, phone string) (*domain.Customer, error) {
+	args := m.Called(ctx, phone)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Customer), args.Error(1)
+}
+func (m *MockCustomerRepo) GetByEmail(ctx context.Context, email string) (*domain.Customer, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Customer), args.Error(1)
+}
 func (m *MockCustomerRepo) GetByName(ctx context.Context, name string) (*domain.Customer, error) {
 	args := m.Called(ctx, name)
 	if args.Get(0) == nil {
@@ -45,17 +159,48 @@ func (m *MockCustomerRepo) GetByName(ctx context.Context, name string) (*domain.
 	}
 	return args.Get(0).(*domain.Customer), args.Error(1)
 }
+func (m *MockCustomerRepo) LockName(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
 func (m *MockCustomerRepo) Create(ctx context.Context, c *domain.Customer) error {
 	args := m.Called(ctx, c)
 	c.ID = 1 // Simulate DB assigning ID
 	return args.Error(0)
 }
+func (m *MockCustomerRepo) Update(ctx context.Context, c *domain.Customer) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+func (m *MockCustomerRepo) Delete(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockCustomerRepo) MarkMerged(ctx context.Context, sourceID, targetID int64) error {
+	args := m.Called(ctx, sourceID, targetID)
+	return args.Error(0)
+}
 func (m *MockCustomerRepo) UpdatePoints(ctx context.Context, id int64, points int) error {
 	args := m.Called(ctx, id, points)
 	return args.Error(0)
 }
 func (m *MockCustomerRepo) ListAll(ctx context.Context) ([]domain.Customer, error) {
-	return nil, nil
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Customer), args.Error(1)
+}
+func (m *MockCustomerRepo) ChangeTier(ctx context.Context, e *domain.TierEvent) error {
+	args := m.Called(ctx, e)
+	return args.Error(0)
+}
+func (m *MockCustomerRepo) ListTierEvents(ctx context.Context, customerID int64) ([]domain.TierEvent, error) {
+	args := m.Called(ctx, customerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.TierEvent), args.Error(1)
 }
 
 // MockTransactionRepo mocks port.TransactionRepository
@@ -67,30 +212,528 @@ func (m *MockTransactionRepo) Create(ctx context.Context, t *domain.Transaction)
 	args := m.Called(ctx, t)
 	return args.Error(0)
 }
-func (m *MockTransactionRepo) GetReport(ctx context.Context, start, end string) (*domain.SalesReport, error) {
-	args := m.Called(ctx, start, end)
+func (m *MockTransactionRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Transaction, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Transaction), args.Error(1)
+}
+func (m *MockTransactionRepo) GetRefundedQuantity(ctx context.Context, id uuid.UUID) (int, error) {
+	args := m.Called(ctx, id)
+	return args.Int(0), args.Error(1)
+}
+func (m *MockTransactionRepo) GetReceipt(ctx context.Context, id uuid.UUID) (*domain.Receipt, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Receipt), args.Error(1)
+}
+func (m *MockTransactionRepo) GetPointsBasis(ctx context.Context, t *domain.Transaction) (int64, int, error) {
+	args := m.Called(ctx, t)
+	return args.Get(0).(int64), args.Int(1), args.Error(2)
+}
+func (m *MockTransactionRepo) GetRefundedCoupon(ctx context.Context, t *domain.Transaction) (*int64, error) {
+	args := m.Called(ctx, t)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*int64), args.Error(1)
+}
+func (m *MockTransactionRepo) ReassignCustomer(ctx context.Context, fromID, toID int64) error {
+	args := m.Called(ctx, fromID, toID)
+	return args.Error(0)
+}
+func (m *MockTransactionRepo) RollingSpend(ctx context.Context, since time.Time) (map[int64]domain.Money, error) {
+	args := m.Called(ctx, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int64]domain.Money), args.Error(1)
+}
+func (m *MockTransactionRepo) GetReport(ctx context.Context, start, end string, storeID *int64) (*domain.SalesReport, error) {
+	args := m.Called(ctx, start, end, storeID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*domain.SalesReport), args.Error(1)
 }
+func (m *MockTransactionRepo) GetShiftReport(ctx context.Context, shift *domain.Shift) (*domain.ShiftReport, error) {
+	args := m.Called(ctx, shift)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ShiftReport), args.Error(1)
+}
+
+// MockRedemptionRepo mocks port.RedemptionRepository
+type MockRedemptionRepo struct {
+	mock.Mock
+}
+
+func (m *MockRedemptionRepo) Create(ctx context.Context, r *domain.Redemption) error {
+	args := m.Called(ctx, r)
+	return args.Error(0)
+}
+func (m *MockRedemptionRepo) List(ctx context.Context, f domain.RedemptionFilter) ([]domain.Redemption, error) {
+	args := m.Called(ctx, f)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Redemption), args.Error(1)
+}
+func (m *MockRedemptionRepo) ReassignCustomer(ctx context.Context, fromID, toID int64) error {
+	args := m.Called(ctx, fromID, toID)
+	return args.Error(0)
+}
+
+// MockOrderRepo mocks port.OrderRepository
+type MockOrderRepo struct {
+	mock.Mock
+}
+
+func (m *MockOrderRepo) Create(ctx context.Context, o *domain.Order) error {
+	args := m.Called(ctx, o)
+	return args.Error(0)
+}
+func (m *MockOrderRepo) ReassignCustomer(ctx context.Context, fromID, toID int64) error {
+	args := m.Called(ctx, fromID, toID)
+	return args.Error(0)
+}
+
+// MockPointsRepo mocks port.PointsLedgerRepository
+type MockPointsRepo struct {
+	mock.Mock
+}
+
+func (m *MockPointsRepo) Append(ctx context.Context, e *domain.PointsEntry) error {
+	args := m.Called(ctx, e)
+	return args.Error(0)
+}
+func (m *MockPointsRepo) ListByCustomer(ctx context.Context, customerID int64) ([]domain.PointsEntry, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).([]domain.PointsEntry), args.Error(1)
+}
+func (m *MockPointsRepo) Balance(ctx context.Context, customerID int64) (int, error) {
+	args := m.Called(ctx, customerID)
+	return args.Int(0), args.Error(1)
+}
+func (m *MockPointsRepo) ReassignCustomer(ctx context.Context, fromID, toID int64) error {
+	args := m.Called(ctx, fromID, toID)
+	return args.Error(0)
+}
+func (m *MockPointsRepo) ConsumeLots(ctx context.Context, customerID int64, points int) error {
+	args := m.Called(ctx, customerID, points)
+	return args.Error(0)
+}
+func (m *MockPointsRepo) ListExpiredLots(ctx context.Context, now time.Time) ([]domain.PointsEntry, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]domain.PointsEntry), args.Error(1)
+}
+func (m *MockPointsRepo) ListCustomerExpiredLots(ctx context.Context, customerID int64, now time.Time) ([]domain.PointsEntry, error) {
+	args := m.Called(ctx, customerID, now)
+	return args.Get(0).([]domain.PointsEntry), args.Error(1)
+}
+func (m *MockPointsRepo) ExpireLot(ctx context.Context, id int64, now time.Time) (int, error) {
+	args := m.Called(ctx, id, now)
+	return args.Int(0), args.Error(1)
+}
+func (m *MockPointsRepo) ExpiringBefore(ctx context.Context, customerID int64, cutoff time.Time) (int, *time.Time, error) {
+	args := m.Called(ctx, customerID, cutoff)
+	var next *time.Time
+	if args.Get(1) != nil {
+		next = args.Get(1).(*time.Time)
+	}
+	return args.Int(0), next, args.Error(2)
+}
+
+// MockLoyaltyRepo mocks port.LoyaltyRuleRepository
+type MockLoyaltyRepo struct {
+	mock.Mock
+}
+
+func (m *MockLoyaltyRepo) ActiveRules(ctx context.Context) (*domain.LoyaltyRules, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoyaltyRules), args.Error(1)
+}
+func (m *MockLoyaltyRepo) ListAll(ctx context.Context) (*domain.LoyaltyRules, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoyaltyRules), args.Error(1)
+}
+func (m *MockLoyaltyRepo) CreateEarnRule(ctx context.Context, rule *domain.EarnRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+func (m *MockLoyaltyRepo) UpdateEarnRule(ctx context.Context, rule *domain.EarnRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+func (m *MockLoyaltyRepo) CreateBonusRule(ctx context.Context, rule *domain.BonusRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+func (m *MockLoyaltyRepo) UpdateBonusRule(ctx context.Context, rule *domain.BonusRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+func (m *MockLoyaltyRepo) CreateRedeemRule(ctx context.Context, rule *domain.RedeemRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+func (m *MockLoyaltyRepo) UpdateRedeemRule(ctx context.Context, rule *domain.RedeemRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+func (m *MockLoyaltyRepo) UpdateTier(ctx context.Context, tier *domain.TierRule) error {
+	args := m.Called(ctx, tier)
+	return args.Error(0)
+}
+func (m *MockLoyaltyRepo) Deactivate(ctx context.Context, kind domain.LoyaltyRuleKind, id int64) error {
+	args := m.Called(ctx, kind, id)
+	return args.Error(0)
+}
+
+// StaticLoyaltyRepo serves a fixed rule set, for tests that only exercise the rules
+type StaticLoyaltyRepo struct {
+	port.LoyaltyRuleRepository
+	Rules domain.LoyaltyRules
+}
+
+func (r *StaticLoyaltyRepo) ActiveRules(ctx context.Context) (*domain.LoyaltyRules, error) {
+	rules := r.Rules
+	return &rules, nil
+}
+
+// defaultLoyalty returns the rules seeded by the loyalty migration:
+// 1 point per Rp 1,000 and 200/300/500 points by size
+func defaultLoyalty() *StaticLoyaltyRepo {
+	small, medium, large := domain.SizeSmall, domain.SizeMedium, domain.SizeLarge
+	return &StaticLoyaltyRepo{Rules: domain.LoyaltyRules{
+		Earn: []domain.EarnRule{{ID: 1, Name: "Standard", SpendPerPoint: domain.NewMoney(1000), Active: true}},
+		Redeem: []domain.RedeemRule{
+			{ID: 1, ProductSize: &small, Points: 200, Active: true},
+			{ID: 2, ProductSize: &medium, Points: 300, Active: true},
+			{ID: 3, ProductSize: &large, Points: 500, Active: true},
+		},
+	}}
+}
+
+// MockPromotionRepo mocks port.PromotionRepository
+type MockPromotionRepo struct {
+	mock.Mock
+}
+
+func (m *MockPromotionRepo) ActivePromotions(ctx context.Context) ([]domain.Promotion, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Promotion), args.Error(1)
+}
+func (m *MockPromotionRepo) ListAll(ctx context.Context) ([]domain.Promotion, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Promotion), args.Error(1)
+}
+func (m *MockPromotionRepo) Create(ctx context.Context, p *domain.Promotion) error {
+	args := m.Called(ctx, p)
+	return args.Error(0)
+}
+func (m *MockPromotionRepo) Update(ctx context.Context, p *domain.Promotion) error {
+	args := m.Called(ctx, p)
+	return args.Error(0)
+}
+func (m *MockPromotionRepo) Deactivate(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockPromotionRepo) UseCoupon(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockPromotionRepo) ReleaseCoupon(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// StaticPromotionRepo serves a fixed set of promotions and records coupon uses and releases
+type StaticPromotionRepo struct {
+	port.PromotionRepository
+	Promotions      []domain.Promotion
+	UsedCoupons     []int64
+	ReleasedCoupons []int64
+	UseErr          error
+}
+
+func (r *StaticPromotionRepo) ActivePromotions(ctx context.Context) ([]domain.Promotion, error) {
+	return slices.Clone(r.Promotions), nil
+}
+func (r *StaticPromotionRepo) ReleaseCoupon(ctx context.Context, id int64) error {
+	r.ReleasedCoupons = append(r.ReleasedCoupons, id)
+	return nil
+}
+func (r *StaticPromotionRepo) UseCoupon(ctx context.Context, id int64) error {
+	if r.UseErr != nil {
+		return r.UseErr
+	}
+	r.UsedCoupons = append(r.UsedCoupons, id)
+	return nil
+}
+
+// MockTaxRateRepo mocks port.TaxRateRepository
+type MockTaxRateRepo struct {
+	mock.Mock
+}
+
+func (m *MockTaxRateRepo) ActiveRates(ctx context.Context) (domain.TaxRates, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(domain.TaxRates), args.Error(1)
+}
+func (m *MockTaxRateRepo) ListAll(ctx context.Context) (domain.TaxRates, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(domain.TaxRates), args.Error(1)
+}
+func (m *MockTaxRateRepo) Create(ctx context.Context, r *domain.TaxRate) error {
+	args := m.Called(ctx, r)
+	return args.Error(0)
+}
+func (m *MockTaxRateRepo) Update(ctx context.Context, r *domain.TaxRate) error {
+	args := m.Called(ctx, r)
+	return args.Error(0)
+}
+func (m *MockTaxRateRepo) Deactivate(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// StaticTaxRepo serves a fixed set of tax rates; the zero value taxes nothing
+type StaticTaxRepo struct {
+	port.TaxRateRepository
+	Rates domain.TaxRates
+}
+
+func (r *StaticTaxRepo) ActiveRates(ctx context.Context) (domain.TaxRates, error) {
+	return slices.Clone(r.Rates), nil
+}
+
+// MemoryPaymentRepo keeps the payments it is given
+type MemoryPaymentRepo struct {
+	Payments []domain.Payment
+}
+
+func (r *MemoryPaymentRepo) Create(ctx context.Context, p *domain.Payment) error {
+	p.ID = int64(len(r.Payments) + 1)
+	r.Payments = append(r.Payments, *p)
+	return nil
+}
+
+// StaticStoreRepo serves every store as an active store
+type StaticStoreRepo struct {
+	port.StoreRepository
+}
+
+func (r *StaticStoreRepo) GetByID(ctx context.Context, id int64) (*domain.Store, error) {
+	return &domain.Store{ID: id, Code: "MAIN", Name: "Main Store", Active: true}, nil
+}
+
+// MockInventoryRepo mocks port.InventoryRepository
+type MockInventoryRepo struct {
+	mock.Mock
+}
+
+func (m *MockInventoryRepo) ListExpiring(ctx context.Context, f domain.ExpiryFilter) ([]domain.ExpiringBatch, error) {
+	args := m.Called(ctx, f)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ExpiringBatch), args.Error(1)
+}
+func (m *MockInventoryRepo) WriteOffExpired(ctx context.Context, storeID, productID *int64, reason, actor string) ([]domain.WriteOff, error) {
+	args := m.Called(ctx, storeID, productID, reason, actor)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.WriteOff), args.Error(1)
+}
+func (m *MockInventoryRepo) ListWriteOffs(ctx context.Context, f domain.WriteOffFilter) ([]domain.WriteOff, error) {
+	args := m.Called(ctx, f)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.WriteOff), args.Error(1)
+}
+func (m *MockInventoryRepo) ListLowStock(ctx context.Context, storeID *int64) ([]domain.StockLevel, error) {
+	args := m.Called(ctx, storeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.StockLevel), args.Error(1)
+}
+func (m *MockInventoryRepo) ListSalesVelocity(ctx context.Context, storeID *int64, lookbackDays int) ([]domain.ReorderSuggestion, error) {
+	args := m.Called(ctx, storeID, lookbackDays)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ReorderSuggestion), args.Error(1)
+}
+
+// MemoryMovementRepo keeps the stock movements it is given
+type MemoryMovementRepo struct {
+	port.StockMovementRepository
+	mu        sync.Mutex
+	Movements []domain.StockMovement
+}
+
+func (r *MemoryMovementRepo) Record(ctx context.Context, movements []domain.StockMovement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Movements = append(r.Movements, movements...)
+	return nil
+}
+
+// MemoryStocktakeRepo keeps one stocktake with the counts it is given
+type MemoryStocktakeRepo struct {
+	Stocktake domain.Stocktake
+}
+
+func (r *MemoryStocktakeRepo) Create(ctx context.Context, s *domain.Stocktake) error {
+	s.ID = 1
+	r.Stocktake = *s
+	return nil
+}
+
+func (r *MemoryStocktakeRepo) GetByID(ctx context.Context, id int64) (*domain.Stocktake, error) {
+	if id != r.Stocktake.ID {
+		return nil, domain.NewNotFoundError("stocktake not found")
+	}
+	s := r.Stocktake
+	s.Lines = slices.Clone(r.Stocktake.Lines)
+	return &s, nil
+}
+
+func (r *MemoryStocktakeRepo) GetForUpdate(ctx context.Context, id int64) (*domain.Stocktake, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r *MemoryStocktakeRepo) SaveCounts(ctx context.Context, id int64, lines []domain.StocktakeLine) error {
+	for _, l := range lines {
+		i := slices.IndexFunc(r.Stocktake.Lines, func(c domain.StocktakeLine) bool { return c.ProductID == l.ProductID })
+		if i < 0 {
+			r.Stocktake.Lines = append(r.Stocktake.Lines, l)
+			continue
+		}
+		r.Stocktake.Lines[i] = l
+	}
+	return nil
+}
+
+func (r *MemoryStocktakeRepo) Finalize(ctx context.Context, s *domain.Stocktake) error {
+	r.Stocktake = *s
+	return nil
+}
+
+// openShiftID is the shift NewMockUnitOfWork keeps open at the main store when it is
+// given no shift repository
+const openShiftID = 1
+
+// MemoryShiftRepo keeps shifts by ID
+type MemoryShiftRepo struct {
+	Shifts map[int64]*domain.Shift
+}
+
+func (r *MemoryShiftRepo) Create(ctx context.Context, s *domain.Shift) error {
+	if r.Shifts == nil {
+		r.Shifts = make(map[int64]*domain.Shift)
+	}
+	s.ID = int64(len(r.Shifts) + 1)
+	s.OpenedAt = time.Now()
+	stored := *s
+	r.Shifts[s.ID] = &stored
+	return nil
+}
+
+func (r *MemoryShiftRepo) GetByID(ctx context.Context, id int64) (*domain.Shift, error) {
+	s, ok := r.Shifts[id]
+	if !ok {
+		return nil, domain.NewNotFoundError("shift not found")
+	}
+	shift := *s
+	return &shift, nil
+}
+
+func (r *MemoryShiftRepo) GetForClose(ctx context.Context, id int64) (*domain.Shift, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r *MemoryShiftRepo) Close(ctx context.Context, s *domain.Shift) error {
+	stored := *s
+	r.Shifts[s.ID] = &stored
+	return nil
+}
 
 // MockCacheRepo mocks port.CacheRepository
 type MockCacheRepo struct {
 	mock.Mock
 }
 
-func (m *MockCacheRepo) GetReport(ctx context.Context, start, end string) (*domain.SalesReport, error) {
-	args := m.Called(ctx, start, end)
+func (m *MockCacheRepo) GetReport(ctx context.Context, start, end string, storeID *int64) (*domain.SalesReport, error) {
+	args := m.Called(ctx, start, end, storeID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*domain.SalesReport), args.Error(1)
 }
-func (m *MockCacheRepo) SetReport(ctx context.Context, start, end string, report *domain.SalesReport, ttl time.Duration) error {
-	args := m.Called(ctx, start, end, report, ttl)
+func (m *MockCacheRepo) SetReport(ctx context.Context, start, end string, storeID *int64, report *domain.SalesReport, ttl time.Duration) error {
+	args := m.Called(ctx, start, end, storeID, report, ttl)
 	return args.Error(0)
 }
 func (m *MockCacheRepo) InvalidateProducts(ctx context.Context, date string) error {
 	return nil
 }
+
+// MockUnitOfWork runs fn directly against the given mocks and records whether it committed
+type MockUnitOfWork struct {
+	repos     port.Repositories
+	mu        sync.Mutex
+	Committed bool
+}
+
+// NewMockUnitOfWork keeps stock movements in memory unless given a Movement repository
+func NewMockUnitOfWork(repos port.Repositories) *MockUnitOfWork {
+	if repos.Movement == nil {
+		repos.Movement = &MemoryMovementRepo{}
+	}
+	if repos.Shift == nil {
+		repos.Shift = &MemoryShiftRepo{Shifts: map[int64]*domain.Shift{
+			openShiftID: {ID: openShiftID, StoreID: domain.MainStoreID, Till: "T1", Cashier: "Sari"},
+		}}
+	}
+	return &MockUnitOfWork{repos: repos}
+}
+
+func (m *MockUnitOfWork) Do(ctx context.Context, fn func(repos port.Repositories) error) error {
+	if err := fn(m.repos); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.Committed = true
+	m.mu.Unlock()
+	return nil
+}
+
+func strPtr(s string) *string { return &s }
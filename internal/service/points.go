@@ -0,0 +1,71 @@
+package service
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"fmt"
+	"time"
+)
+
+// postPoints records a ledger entry and applies it to the customer's balance.
+// Positive entries open a lot (earned points expire under the policy, counted from
+// earnedAt); negative entries spend the oldest-expiring lots first. It must run inside
+// a unit of work so all writes commit together.
+func postPoints(ctx context.Context, repos port.Repositories, expiry domain.PointsExpiryPolicy, earnedAt time.Time, e *domain.PointsEntry) error {
+	if e.Points == 0 {
+		return nil
+	}
+	if e.Points > 0 {
+		e.Remaining = e.Points
+		if e.Type == domain.PointsEarn {
+			e.ExpiresAt = expiry.ExpiresAt(earnedAt)
+		}
+	} else if err := repos.Points.ConsumeLots(ctx, e.CustomerID, -e.Points); err != nil {
+		return err
+	}
+	if err := repos.Points.Append(ctx, e); err != nil {
+		return err
+	}
+	return repos.Customer.UpdatePoints(ctx, e.CustomerID, e.Points)
+}
+
+// expireLot zeroes a lapsed lot and posts what it still held as expired, returning the
+// points removed. It must run inside a unit of work.
+func expireLot(ctx context.Context, repos port.Repositories, lot domain.PointsEntry, now time.Time) (int, error) {
+	// re-read under lock: the lot may have been spent since it was listed
+	points, err := repos.Points.ExpireLot(ctx, lot.ID, now)
+	if err != nil || points == 0 {
+		return 0, err
+	}
+	if err := repos.Points.Append(ctx, &domain.PointsEntry{
+		CustomerID: lot.CustomerID,
+		Type:       domain.PointsExpire,
+		Points:     -points,
+		Reason:     fmt.Sprintf("points earned %s expired", lot.CreatedAt.Format("2006-01-02")),
+	}); err != nil {
+		return 0, err
+	}
+	if err := repos.Customer.UpdatePoints(ctx, lot.CustomerID, -points); err != nil {
+		return 0, err
+	}
+	return points, nil
+}
+
+// expireCustomerLots expires every lot of the customer that lapsed at or before now, so
+// a spend never counts points the nightly sweep has not reached yet.
+func expireCustomerLots(ctx context.Context, repos port.Repositories, customerID int64, now time.Time) (int, error) {
+	lots, err := repos.Points.ListCustomerExpiredLots(ctx, customerID, now)
+	if err != nil {
+		return 0, err
+	}
+	var expired int
+	for _, lot := range lots {
+		points, err := expireLot(ctx, repos, lot, now)
+		if err != nil {
+			return 0, err
+		}
+		expired += points
+	}
+	return expired, nil
+}
@@ -4,20 +4,274 @@ import (
 	"bsnack/internal/domain"
 	"bsnack/internal/port"
 	"context"
+	"strings"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
 )
 
 type ProductService struct {
-	repo port.ProductRepository
+	uow       port.UnitOfWork
+	repo      port.ProductRepository
+	repoStore port.StoreRepository
+	repoMove  port.StockMovementRepository
 }
 
-func NewProductService(repo port.ProductRepository) *ProductService {
-	return &ProductService{repo: repo}
+func NewProductService(uow port.UnitOfWork, repo port.ProductRepository, rs port.StoreRepository, rm port.StockMovementRepository) *ProductService {
+	return &ProductService{uow: uow, repo: repo, repoStore: rs, repoMove: rm}
 }
 
+// productUpdateReason is recorded on the adjustments made by setting a product's quantity
+const productUpdateReason = "product quantity set"
+
+// AddProduct puts the product's quantity in stock at its store, the main store by
+// default, as a batch made on its manufacturing date
 func (s *ProductService) AddProduct(ctx context.Context, p *domain.Product) error {
-	return s.repo.Create(ctx, p)
+	if err := s.stockStore(ctx, p); err != nil {
+		return err
+	}
+	return s.uow.Do(ctx, func(repos port.Repositories) error {
+		if err := repos.Product.Create(ctx, p); err != nil {
+			return err
+		}
+		if p.Quantity == 0 {
+			return nil
+		}
+		// the new product's only batch
+		batches, err := repos.Product.ListBatches(ctx, p.ID, p.StoreID)
+		if err != nil {
+			return err
+		}
+		return repos.Movement.Record(ctx, []domain.StockMovement{
+			{BatchID: batches[0].ID, Kind: domain.MovementRestock, Quantity: p.Quantity},
+		})
+	})
+}
+
+// GetProduct returns the product with its stock at the store, or across every store
+// when storeID is nil
+func (s *ProductService) GetProduct(ctx context.Context, id int64, storeID *int64) (*domain.Product, error) {
+	if storeID != nil {
+		return s.repo.GetAtStore(ctx, *storeID, id)
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// ListProducts returns one page of the active catalog, clamping the page size to maxPageSize
+func (s *ProductService) ListProducts(ctx context.Context, f domain.ProductFilter) (*domain.ProductPage, error) {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PageSize < 1 {
+		f.PageSize = defaultPageSize
+	}
+	if f.PageSize > maxPageSize {
+		f.PageSize = maxPageSize
+	}
+
+	products, total, err := s.repo.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ProductPage{
+		Items:    products,
+		Page:     f.Page,
+		PageSize: f.PageSize,
+		Total:    total,
+	}, nil
+}
+
+// UpdateProduct replaces the product and sets its stock at its store, the main store by
+// default. Stock added is received as a batch made on the product's manufacturing date;
+// stock removed comes out of the batches a sale would take.
+func (s *ProductService) UpdateProduct(ctx context.Context, p *domain.Product) error {
+	if err := s.stockStore(ctx, p); err != nil {
+		return err
+	}
+	return s.uow.Do(ctx, func(repos port.Repositories) error {
+		current, err := repos.Product.GetAtStoreForUpdate(ctx, *p.StoreID, p.ID)
+		if err != nil {
+			return err
+		}
+		if err := repos.Product.Update(ctx, p); err != nil {
+			return err
+		}
+
+		m := domain.StockMovement{Kind: domain.MovementAdjustment, Reason: productUpdateReason}
+		switch delta := p.Quantity - current.Quantity; {
+		case delta > 0:
+			_, err := receiveStock(ctx, repos, &domain.Batch{
+				StoreID:           *p.StoreID,
+				ProductID:         p.ID,
+				Lot:               domain.AdjustmentLot(p.ManufacturingDate),
+				ManufacturingDate: p.ManufacturingDate,
+				Quantity:          delta,
+			}, m)
+			return err
+		case delta < 0:
+			_, err := takeStock(ctx, repos, *p.StoreID, p.ID, -delta, m)
+			return err
+		}
+		return nil
+	})
+}
+
+// BatchRequest receives stock of a product at StoreID, the main store by default. Lot
+// defaults to the manufacturing date as YYYYMMDD.
+type BatchRequest struct {
+	StoreID           int64   `json:"store_id"`
+	Lot               string  `json:"lot"`
+	ManufacturingDate string  `json:"manufacturing_date"`
+	ExpiryDate        *string `json:"expiry_date"`
+	Quantity          int     `json:"quantity"`
+}
+
+// ReceiveBatch restocks a product from a new production run, or tops up a lot the
+// store already holds
+func (s *ProductService) ReceiveBatch(ctx context.Context, id int64, req BatchRequest) (*domain.Batch, error) {
+	b, err := s.batch(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+	err = s.uow.Do(ctx, func(repos port.Repositories) error {
+		_, err := receiveStock(ctx, repos, b, domain.StockMovement{Kind: domain.MovementRestock})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// MovementRequest changes a product's stock by hand. A restock receives Quantity as a
+// batch, like BatchRequest. An adjustment corrects the stock by Quantity: received as a
+// batch when positive, taken from the batches a sale would take when negative.
+type MovementRequest struct {
+	Kind domain.MovementKind `json:"kind"`
+	BatchRequest
+	Reason    string `json:"reason"`
+	Actor     string `json:"actor"`
+	Reference string `json:"reference"`
+}
+
+// MoveStock restocks or adjusts a product's stock at a store and returns the movements
+// recorded, one per batch touched
+func (s *ProductService) MoveStock(ctx context.Context, id int64, req MovementRequest) ([]domain.StockMovement, error) {
+	b, err := s.batch(ctx, id, req.BatchRequest)
+	if err != nil {
+		return nil, err
+	}
+	m := domain.StockMovement{
+		Kind:      req.Kind,
+		Reason:    strings.TrimSpace(req.Reason),
+		Actor:     strings.TrimSpace(req.Actor),
+		Reference: strings.TrimSpace(req.Reference),
+	}
+
+	var movements []domain.StockMovement
+	err = s.uow.Do(ctx, func(repos port.Repositories) error {
+		var err error
+		if req.Quantity > 0 {
+			movements, err = receiveStock(ctx, repos, b, m)
+		} else {
+			movements, err = takeStock(ctx, repos, b.StoreID, id, -req.Quantity, m)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// ListMovements returns the product's stock movements matching the filter, oldest first
+func (s *ProductService) ListMovements(ctx context.Context, f domain.MovementFilter) ([]domain.StockMovement, error) {
+	if _, err := s.repo.GetByID(ctx, f.ProductID); err != nil {
+		return nil, err
+	}
+	return s.repoMove.List(ctx, f)
+}
+
+// batch builds the batch a request receives, defaulting its store and lot, and checks
+// that the product and store exist
+func (s *ProductService) batch(ctx context.Context, id int64, req BatchRequest) (*domain.Batch, error) {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	b := &domain.Batch{
+		StoreID:           req.StoreID,
+		ProductID:         id,
+		Lot:               strings.TrimSpace(req.Lot),
+		ManufacturingDate: req.ManufacturingDate,
+		ExpiryDate:        req.ExpiryDate,
+		Quantity:          req.Quantity,
+	}
+	if b.StoreID == 0 {
+		b.StoreID = domain.MainStoreID
+	}
+	if _, err := s.repoStore.GetByID(ctx, b.StoreID); err != nil {
+		return nil, err
+	}
+	if b.Lot == "" {
+		b.Lot = domain.DefaultLot(b.ManufacturingDate)
+	}
+	return b, nil
+}
+
+// receiveStock adds the batch to stock and records it as movement m
+func receiveStock(ctx context.Context, repos port.Repositories, b *domain.Batch, m domain.StockMovement) ([]domain.StockMovement, error) {
+	// ReceiveBatch sets Quantity to the lot's new total
+	m.Quantity = b.Quantity
+	if err := repos.Product.ReceiveBatch(ctx, b); err != nil {
+		return nil, err
+	}
+	m.BatchID = b.ID
+	movements := []domain.StockMovement{m}
+	if err := repos.Movement.Record(ctx, movements); err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// takeStock removes qty units of the product at the store, from the batches a sale would
+// take, and records a movement like m for each batch
+func takeStock(ctx context.Context, repos port.Repositories, storeID, id int64, qty int, m domain.StockMovement) ([]domain.StockMovement, error) {
+	batches, err := repos.Product.DecrementStock(ctx, storeID, id, qty)
+	if err != nil {
+		return nil, err
+	}
+	movements := domain.TakenMovements(m.Kind, batches, m.Reference)
+	for i := range movements {
+		movements[i].Reason, movements[i].Actor = m.Reason, m.Actor
+	}
+	if err := repos.Movement.Record(ctx, movements); err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// ListBatches returns the product's batches with stock left, at one store or every
+// store when storeID is nil, in the order sales take from them
+func (s *ProductService) ListBatches(ctx context.Context, id int64, storeID *int64) ([]domain.Batch, error) {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListBatches(ctx, id, storeID)
+}
+
+// stockStore defaults the store whose stock a write sets and checks that it exists
+func (s *ProductService) stockStore(ctx context.Context, p *domain.Product) error {
+	if p.StoreID == nil {
+		id := domain.MainStoreID
+		p.StoreID = &id
+	}
+	_, err := s.repoStore.GetByID(ctx, *p.StoreID)
+	return err
 }
 
-func (s *ProductService) GetProductsByDate(ctx context.Context, date string) ([]domain.Product, error) {
-	return s.repo.GetByDate(ctx, date)
+func (s *ProductService) ArchiveProduct(ctx context.Context, id int64) error {
+	return s.repo.Archive(ctx, id)
 }
@@ -0,0 +1,131 @@
+package service_test
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"bsnack/internal/service"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListProducts_DefaultsPagination(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	svc := service.NewProductService(nil, mockProd, &StaticStoreRepo{}, nil)
+	ctx := context.TODO()
+
+	expected := domain.ProductFilter{Type: "Keripik", Page: 1, PageSize: 20}
+	mockProd.On("List", ctx, expected).Return([]domain.Product{{ID: 1}}, 41, nil)
+
+	page, err := svc.ListProducts(ctx, domain.ProductFilter{Type: "Keripik"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Page)
+	assert.Equal(t, 20, page.PageSize)
+	assert.Equal(t, 41, page.Total)
+	assert.Len(t, page.Items, 1)
+}
+
+func TestListProducts_ClampsPageSize(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	svc := service.NewProductService(nil, mockProd, &StaticStoreRepo{}, nil)
+	ctx := context.TODO()
+
+	mockProd.On("List", ctx, domain.ProductFilter{Page: 3, PageSize: 100}).Return([]domain.Product{}, 0, nil)
+
+	page, err := svc.ListProducts(ctx, domain.ProductFilter{Page: 3, PageSize: 5000})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 100, page.PageSize)
+	mockProd.AssertExpectations(t)
+}
+
+func TestUpdateProduct_RestockIsReceivedAsBatch(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	moves := &MemoryMovementRepo{}
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Movement: moves})
+	svc := service.NewProductService(uow, mockProd, &StaticStoreRepo{}, nil)
+	ctx := context.TODO()
+
+	p := &domain.Product{ID: 1, Name: "Keripik Pangsit", Quantity: 15, ManufacturingDate: "2025-12-01"}
+	mockProd.On("GetAtStoreForUpdate", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Quantity: 10}, nil)
+	mockProd.On("Update", ctx, p).Return(nil)
+	mockProd.On("ReceiveBatch", ctx, &domain.Batch{StoreID: domain.MainStoreID, ProductID: 1, Lot: "20251201-ADJ",
+		ManufacturingDate: "2025-12-01", Quantity: 5}).Return(nil)
+
+	err := svc.UpdateProduct(ctx, p)
+
+	assert.NoError(t, err)
+	assert.True(t, uow.Committed)
+	mockProd.AssertExpectations(t)
+	if assert.Len(t, moves.Movements, 1) {
+		assert.Equal(t, domain.MovementAdjustment, moves.Movements[0].Kind)
+		assert.Equal(t, 5, moves.Movements[0].Quantity)
+	}
+}
+
+func TestUpdateProduct_LowerQuantityTakesFromBatches(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	moves := &MemoryMovementRepo{}
+	svc := service.NewProductService(NewMockUnitOfWork(port.Repositories{Product: mockProd, Movement: moves}), mockProd, &StaticStoreRepo{}, nil)
+	ctx := context.TODO()
+
+	p := &domain.Product{ID: 1, Quantity: 4, ManufacturingDate: "2025-12-01"}
+	mockProd.On("GetAtStoreForUpdate", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Quantity: 10}, nil)
+	mockProd.On("Update", ctx, p).Return(nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 6).Return([]domain.BatchAllocation{{BatchID: 3, Quantity: 6}}, nil)
+
+	assert.NoError(t, svc.UpdateProduct(ctx, p))
+	mockProd.AssertNotCalled(t, "ReceiveBatch", mock.Anything, mock.Anything)
+	if assert.Len(t, moves.Movements, 1) {
+		assert.Equal(t, -6, moves.Movements[0].Quantity)
+		assert.Equal(t, int64(3), moves.Movements[0].BatchID)
+	}
+}
+
+func TestReceiveBatch_DefaultsLotAndStore(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	svc := service.NewProductService(NewMockUnitOfWork(port.Repositories{Product: mockProd}), mockProd, &StaticStoreRepo{}, nil)
+	ctx := context.TODO()
+
+	mockProd.On("GetByID", ctx, int64(1)).Return(&domain.Product{ID: 1}, nil)
+	mockProd.On("ReceiveBatch", ctx, mock.AnythingOfType("*domain.Batch")).Return(nil)
+
+	b, err := svc.ReceiveBatch(ctx, 1, service.BatchRequest{Lot: " ", ManufacturingDate: "2025-12-01", Quantity: 24})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.MainStoreID, b.StoreID)
+	assert.Equal(t, "20251201", b.Lot)
+	assert.Equal(t, 24, b.Quantity)
+}
+
+func TestMoveStock_AdjustmentDownTakesFromBatches(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	moves := &MemoryMovementRepo{}
+	svc := service.NewProductService(NewMockUnitOfWork(port.Repositories{Product: mockProd, Movement: moves}), mockProd, &StaticStoreRepo{}, nil)
+	ctx := context.TODO()
+
+	mockProd.On("GetByID", ctx, int64(1)).Return(&domain.Product{ID: 1}, nil)
+	mockProd.On("DecrementStock", ctx, int64(2), int64(1), 3).Return([]domain.BatchAllocation{
+		{BatchID: 7, Lot: "A", Quantity: 1},
+		{BatchID: 8, Lot: "B", Quantity: 2},
+	}, nil)
+
+	movements, err := svc.MoveStock(ctx, 1, service.MovementRequest{
+		Kind:         domain.MovementAdjustment,
+		BatchRequest: service.BatchRequest{StoreID: 2, Quantity: -3},
+		Reason:       " damaged in transit ",
+		Actor:        "Sari",
+		Reference:    "DN-0042",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.StockMovement{
+		{BatchID: 7, Lot: "A", Kind: domain.MovementAdjustment, Quantity: -1, Reason: "damaged in transit", Actor: "Sari", Reference: "DN-0042"},
+		{BatchID: 8, Lot: "B", Kind: domain.MovementAdjustment, Quantity: -2, Reason: "damaged in transit", Actor: "Sari", Reference: "DN-0042"},
+	}, movements)
+	assert.Equal(t, movements, moves.Movements)
+	mockProd.AssertNotCalled(t, "ReceiveBatch", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"strings"
+)
+
+// PromotionService administers the promotions and coupons applied by TransactionService
+type PromotionService struct {
+	repo     port.PromotionRepository
+	repoProd port.ProductRepository
+}
+
+func NewPromotionService(rp port.PromotionRepository, rprod port.ProductRepository) *PromotionService {
+	return &PromotionService{repo: rp, repoProd: rprod}
+}
+
+// PromotionRequest creates or replaces a promotion. Only the fields of its kind are
+// used; Code makes it a coupon and Active defaults to true.
+type PromotionRequest struct {
+	Name           string               `json:"name"`
+	Kind           domain.PromotionKind `json:"kind"`
+	ProductID      *int64               `json:"product_id"`
+	ProductType    *string              `json:"product_type"`
+	Percent        int                  `json:"percent"`
+	Amount         domain.Money         `json:"amount"`
+	BuyQuantity    int                  `json:"buy_quantity"`
+	FreeQuantity   int                  `json:"free_quantity"`
+	BundleQuantity int                  `json:"bundle_quantity"`
+	BundlePrice    domain.Money         `json:"bundle_price"`
+	Code           *string              `json:"code"`
+	UsageLimit     *int                 `json:"usage_limit"`
+	StartDate      string               `json:"start_date"`
+	EndDate        string               `json:"end_date"`
+	Active         *bool                `json:"active"`
+}
+
+// ListPromotions lists every promotion, including retired ones
+func (s *PromotionService) ListPromotions(ctx context.Context) ([]domain.Promotion, error) {
+	return s.repo.ListAll(ctx)
+}
+
+func (s *PromotionService) CreatePromotion(ctx context.Context, req PromotionRequest) (*domain.Promotion, error) {
+	p, err := s.promotion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *PromotionService) UpdatePromotion(ctx context.Context, id int64, req PromotionRequest) (*domain.Promotion, error) {
+	p, err := s.promotion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	p.ID = id
+	if err := s.repo.Update(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// DeactivatePromotion retires a promotion so it no longer applies to new sales
+func (s *PromotionService) DeactivatePromotion(ctx context.Context, id int64) error {
+	return s.repo.Deactivate(ctx, id)
+}
+
+func (s *PromotionService) promotion(ctx context.Context, req PromotionRequest) (*domain.Promotion, error) {
+	if req.ProductID != nil {
+		// a promotion for an unknown or archived product would never apply
+		if _, err := s.repoProd.GetByID(ctx, *req.ProductID); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &domain.Promotion{
+		Name:        strings.TrimSpace(req.Name),
+		Kind:        req.Kind,
+		ProductID:   req.ProductID,
+		ProductType: normalizeProductType(req.ProductType),
+		UsageLimit:  req.UsageLimit,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		Active:      activeOrDefault(req.Active),
+	}
+	if req.Code != nil {
+		code := domain.NormalizeCouponCode(*req.Code)
+		p.Code = &code
+	}
+
+	// keep only the terms of the promotion's kind
+	switch req.Kind {
+	case domain.PromotionPercentage:
+		p.Percent = req.Percent
+	case domain.PromotionFixed:
+		p.Amount = req.Amount
+	case domain.PromotionBuyXGetY:
+		p.BuyQuantity, p.FreeQuantity = req.BuyQuantity, req.FreeQuantity
+	case domain.PromotionBundle:
+		p.BundleQuantity, p.BundlePrice = req.BundleQuantity, req.BundlePrice
+	}
+	return p, nil
+}
@@ -0,0 +1,54 @@
+package service_test
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/service"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreatePromotion_NormalizesCoupon(t *testing.T) {
+	mockPromo := new(MockPromotionRepo)
+	svc := service.NewPromotionService(mockPromo, nil)
+	ctx := context.TODO()
+
+	limit := 100
+	mockPromo.On("Create", ctx, mock.MatchedBy(func(p *domain.Promotion) bool {
+		// only the terms of the kind are kept
+		return *p.Code == "HEMAT10" && p.Percent == 10 && p.Amount == 0 && p.Active && p.ProductType == nil
+	})).Return(nil)
+
+	promo, err := svc.CreatePromotion(ctx, service.PromotionRequest{
+		Name:        "Hemat",
+		Kind:        domain.PromotionPercentage,
+		ProductType: strPtr(" "),
+		Percent:     10,
+		Amount:      domain.NewMoney(5000),
+		Code:        strPtr(" hemat10 "),
+		UsageLimit:  &limit,
+		StartDate:   "2025-12-01",
+		EndDate:     "2025-12-31",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 100, *promo.UsageLimit)
+	mockPromo.AssertExpectations(t)
+}
+
+func TestCreatePromotion_UnknownProduct(t *testing.T) {
+	mockPromo := new(MockPromotionRepo)
+	mockProd := new(MockProductRepo)
+	svc := service.NewPromotionService(mockPromo, mockProd)
+	ctx := context.TODO()
+
+	productID := int64(99)
+	mockProd.On("GetByID", ctx, productID).Return(nil, domain.NewNotFoundError("product not found"))
+
+	_, err := svc.CreatePromotion(ctx, service.PromotionRequest{Name: "Gone", Kind: domain.PromotionFixed, ProductID: &productID})
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	mockPromo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"strings"
+	"time"
+)
+
+// ShiftService opens and closes cashier shifts and reports what each took
+type ShiftService struct {
+	uow       port.UnitOfWork
+	repo      port.ShiftRepository
+	repoTrans port.TransactionRepository
+}
+
+func NewShiftService(uow port.UnitOfWork, rs port.ShiftRepository, rt port.TransactionRepository) *ShiftService {
+	return &ShiftService{uow: uow, repo: rs, repoTrans: rt}
+}
+
+// OpenShiftRequest opens a shift on a till at StoreID, the main store by default
+type OpenShiftRequest struct {
+	StoreID      int64        `json:"store_id"`
+	Till         string       `json:"till"`
+	Cashier      string       `json:"cashier"`
+	OpeningFloat domain.Money `json:"opening_float"`
+}
+
+type CloseShiftRequest struct {
+	CountedCash domain.Money `json:"counted_cash"`
+}
+
+// OpenShift starts a shift on a till with the cash float put in the drawer
+func (s *ShiftService) OpenShift(ctx context.Context, req OpenShiftRequest) (*domain.Shift, error) {
+	shift := &domain.Shift{
+		StoreID:      req.StoreID,
+		Till:         strings.TrimSpace(req.Till),
+		Cashier:      strings.TrimSpace(req.Cashier),
+		OpeningFloat: req.OpeningFloat,
+	}
+	err := s.uow.Do(ctx, func(repos port.Repositories) error {
+		store, err := saleStore(ctx, repos, req.StoreID, nil)
+		if err != nil {
+			return err
+		}
+		shift.StoreID = store.ID
+		return repos.Shift.Create(ctx, shift)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+// CloseShift waits for sales in flight on the shift, then records the cash counted
+// in the drawer against what the shift should have taken and returns the Z-report
+func (s *ShiftService) CloseShift(ctx context.Context, id int64, req CloseShiftRequest) (*domain.ShiftReport, error) {
+	var report *domain.ShiftReport
+	err := s.uow.Do(ctx, func(repos port.Repositories) error {
+		shift, err := repos.Shift.GetForClose(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !shift.IsOpen() {
+			return domain.NewConflictError("shift is already closed")
+		}
+
+		report, err = repos.Transaction.GetShiftReport(ctx, shift)
+		if err != nil {
+			return err
+		}
+		report.Shift.Close(report.ExpectedCash, req.CountedCash, time.Now())
+		return repos.Shift.Close(ctx, &report.Shift)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetShiftReport returns the takings of the shift so far, or its Z-report once closed
+func (s *ShiftService) GetShiftReport(ctx context.Context, id int64) (*domain.ShiftReport, error) {
+	shift, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repoTrans.GetShiftReport(ctx, shift)
+}
@@ -0,0 +1,43 @@
+package service_test
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"bsnack/internal/service"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCloseShift_RecordsVariance(t *testing.T) {
+	shifts := &MemoryShiftRepo{}
+	mockTrans := new(MockTransactionRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Shift: shifts, Store: &StaticStoreRepo{}, Transaction: mockTrans})
+	svc := service.NewShiftService(uow, shifts, mockTrans)
+	ctx := context.TODO()
+
+	shift, err := svc.OpenShift(ctx, service.OpenShiftRequest{Till: " T1 ", Cashier: "Sari", OpeningFloat: domain.NewMoney(200000)})
+	assert.NoError(t, err)
+	assert.Equal(t, "T1", shift.Till)
+
+	takings := &domain.ShiftReport{Shift: *shift, Payments: []domain.PaymentTotal{
+		{Method: domain.PaymentCard, Count: 1, Amount: domain.NewMoney(50000)},
+		{Method: domain.PaymentCash, Count: 3, Amount: domain.NewMoney(64000)},
+	}}
+	takings.Reconcile()
+	mockTrans.On("GetShiftReport", ctx, mock.AnythingOfType("*domain.Shift")).Return(takings, nil)
+
+	report, err := svc.CloseShift(ctx, shift.ID, service.CloseShiftRequest{CountedCash: domain.NewMoney(263500)})
+
+	assert.NoError(t, err)
+	assert.True(t, uow.Committed)
+	// the float plus cash sales; card takings never reach the drawer
+	assert.Equal(t, domain.NewMoney(264000), report.ExpectedCash)
+	assert.Equal(t, domain.NewMoney(-500), *report.Shift.Variance)
+	assert.False(t, shifts.Shifts[shift.ID].IsOpen())
+
+	_, err = svc.CloseShift(ctx, shift.ID, service.CloseShiftRequest{})
+	assert.EqualError(t, err, "shift is already closed")
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stocktakeReason is recorded on the adjustments a stocktake posts
+const stocktakeReason = "stocktake"
+
+// StocktakeService runs physical counts of a store's shelves and corrects the stock in
+// the system to what was counted
+type StocktakeService struct {
+	uow  port.UnitOfWork
+	repo port.StocktakeRepository
+}
+
+func NewStocktakeService(uow port.UnitOfWork, rs port.StocktakeRepository) *StocktakeService {
+	return &StocktakeService{uow: uow, repo: rs}
+}
+
+// OpenStocktakeRequest opens a count at StoreID, the main store by default
+type OpenStocktakeRequest struct {
+	StoreID int64  `json:"store_id"`
+	Actor   string `json:"actor"`
+}
+
+// StocktakeCount is the number of units of a product found on the shelves
+type StocktakeCount struct {
+	ProductID int64 `json:"product_id"`
+	Counted   int   `json:"counted"`
+}
+
+type StocktakeCountsRequest struct {
+	Counts []StocktakeCount `json:"counts"`
+	Actor  string           `json:"actor"`
+}
+
+type FinalizeStocktakeRequest struct {
+	Actor string `json:"actor"`
+}
+
+// OpenStocktake starts a count at an active store that has none open
+func (s *StocktakeService) OpenStocktake(ctx context.Context, req OpenStocktakeRequest) (*domain.Stocktake, error) {
+	st := &domain.Stocktake{OpenedBy: strings.TrimSpace(req.Actor), Lines: []domain.StocktakeLine{}}
+	err := s.uow.Do(ctx, func(repos port.Repositories) error {
+		store, err := saleStore(ctx, repos, req.StoreID, nil)
+		if err != nil {
+			return err
+		}
+		st.StoreID = store.ID
+		return repos.Stocktake.Create(ctx, st)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// GetStocktake returns the stocktake with the variance of each count
+func (s *StocktakeService) GetStocktake(ctx context.Context, id int64) (*domain.Stocktake, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// SubmitCounts records counts on an open stocktake against the store's stock at the
+// moment they are submitted. A product counted again takes the new count and a new
+// snapshot, so a shelf can be recounted until the stocktake is finalized.
+func (s *StocktakeService) SubmitCounts(ctx context.Context, id int64, req StocktakeCountsRequest) (*domain.Stocktake, error) {
+	actor := strings.TrimSpace(req.Actor)
+	var st *domain.Stocktake
+	err := s.uow.Do(ctx, func(repos port.Repositories) error {
+		current, err := openStocktake(ctx, repos, id)
+		if err != nil {
+			return err
+		}
+		lines := make([]domain.StocktakeLine, len(req.Counts))
+		for i, c := range req.Counts {
+			// archived and unknown products cannot be counted
+			product, err := repos.Product.GetAtStore(ctx, current.StoreID, c.ProductID)
+			if err != nil {
+				return err
+			}
+			lines[i] = domain.StocktakeLine{ProductID: c.ProductID, Counted: c.Counted, CountedBy: actor}
+			lines[i].Reconcile(product.Quantity)
+		}
+		if err := repos.Stocktake.SaveCounts(ctx, current.ID, lines); err != nil {
+			return err
+		}
+		st, err = repos.Stocktake.GetByID(ctx, current.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// FinalizeStocktake closes the stocktake and posts each count's variance against the
+// stock snapshotted when it was counted, so sales and receipts made since then are not
+// booked as shrinkage or surplus. Units found are received as a batch made on the
+// product's newest manufacturing date; units missing come out of the batches a sale
+// would take, never more than the store still holds. Expired units are not stock and
+// are corrected by writing them off.
+func (s *StocktakeService) FinalizeStocktake(ctx context.Context, id int64, req FinalizeStocktakeRequest) (*domain.Stocktake, error) {
+	var st *domain.Stocktake
+	err := s.uow.Do(ctx, func(repos port.Repositories) error {
+		var err error
+		if st, err = openStocktake(ctx, repos, id); err != nil {
+			return err
+		}
+
+		m := domain.StockMovement{
+			Kind:      domain.MovementAdjustment,
+			Reason:    stocktakeReason,
+			Actor:     strings.TrimSpace(req.Actor),
+			Reference: strconv.FormatInt(st.ID, 10),
+		}
+		for _, line := range st.Lines {
+			switch {
+			case line.Variance > 0:
+				var product *domain.Product
+				if product, err = repos.Product.GetAtStore(ctx, st.StoreID, line.ProductID); err != nil {
+					return err
+				}
+				_, err = receiveStock(ctx, repos, &domain.Batch{
+					StoreID:           st.StoreID,
+					ProductID:         product.ID,
+					Lot:               domain.AdjustmentLot(product.ManufacturingDate),
+					ManufacturingDate: product.ManufacturingDate,
+					Quantity:          line.Variance,
+				}, m)
+			case line.Variance < 0:
+				// units sold since the count already left; shrink only what is still on hand
+				var product *domain.Product
+				if product, err = repos.Product.GetAtStoreForUpdate(ctx, st.StoreID, line.ProductID); err != nil {
+					return err
+				}
+				if missing := min(-line.Variance, product.Quantity); missing > 0 {
+					_, err = takeStock(ctx, repos, st.StoreID, line.ProductID, missing, m)
+				}
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		st.FinalizedBy, st.FinalizedAt = m.Actor, &now
+		return repos.Stocktake.Finalize(ctx, st)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// openStocktake locks the stocktake for counting or finalizing
+func openStocktake(ctx context.Context, repos port.Repositories, id int64) (*domain.Stocktake, error) {
+	st, err := repos.Stocktake.GetForUpdate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !st.IsOpen() {
+		return nil, domain.NewConflictError("stocktake is already finalized")
+	}
+	return st, nil
+}
@@ -0,0 +1,112 @@
+package service_test
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"bsnack/internal/service"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFinalizeStocktake_PostsVariances(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	moves := &MemoryMovementRepo{}
+	counts := &MemoryStocktakeRepo{Stocktake: domain.Stocktake{ID: 4, StoreID: 2, Lines: []domain.StocktakeLine{
+		{ProductID: 1, Counted: 12, System: 10, Variance: 2},
+		{ProductID: 2, Counted: 5, System: 8, Variance: -3},
+		{ProductID: 3, Counted: 7, System: 7},
+	}}}
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Stocktake: counts, Movement: moves})
+	svc := service.NewStocktakeService(uow, counts)
+	ctx := context.TODO()
+
+	// four units sold since the count are not booked as missing
+	mockProd.On("GetAtStore", ctx, int64(2), int64(1)).Return(&domain.Product{ID: 1, Quantity: 6, ManufacturingDate: "2025-12-01"}, nil)
+	// two units found are received, three missing are taken
+	mockProd.On("ReceiveBatch", ctx, &domain.Batch{StoreID: 2, ProductID: 1, Lot: "20251201-ADJ",
+		ManufacturingDate: "2025-12-01", Quantity: 2}).Return(nil)
+	mockProd.On("GetAtStoreForUpdate", ctx, int64(2), int64(2)).Return(&domain.Product{ID: 2, Quantity: 8}, nil)
+	mockProd.On("DecrementStock", ctx, int64(2), int64(2), 3).Return([]domain.BatchAllocation{{BatchID: 9, Quantity: 3}}, nil)
+
+	st, err := svc.FinalizeStocktake(ctx, 4, service.FinalizeStocktakeRequest{Actor: " Sari "})
+
+	assert.NoError(t, err)
+	assert.True(t, uow.Committed)
+	assert.False(t, st.IsOpen())
+	assert.Equal(t, "Sari", st.FinalizedBy)
+	assert.Equal(t, []int{2, -3, 0}, []int{st.Lines[0].Variance, st.Lines[1].Variance, st.Lines[2].Variance})
+	if assert.Len(t, moves.Movements, 2) {
+		assert.Equal(t, 2, moves.Movements[0].Quantity)
+		assert.Equal(t, domain.StockMovement{BatchID: 9, Kind: domain.MovementAdjustment, Quantity: -3,
+			Reason: "stocktake", Actor: "Sari", Reference: "4"}, moves.Movements[1])
+	}
+	assert.False(t, counts.Stocktake.IsOpen())
+	mockProd.AssertExpectations(t)
+}
+
+func TestFinalizeStocktake_SoldSinceCount(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	moves := &MemoryMovementRepo{}
+	counts := &MemoryStocktakeRepo{Stocktake: domain.Stocktake{ID: 4, StoreID: 2, Lines: []domain.StocktakeLine{
+		{ProductID: 2, Counted: 5, System: 8, Variance: -3},
+		{ProductID: 3, Counted: 0, System: 2, Variance: -2},
+	}}}
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Stocktake: counts, Movement: moves})
+	svc := service.NewStocktakeService(uow, counts)
+	ctx := context.TODO()
+
+	// six of product 2 and both of product 3 sold between submitting and finalizing
+	mockProd.On("GetAtStoreForUpdate", ctx, int64(2), int64(2)).Return(&domain.Product{ID: 2, Quantity: 2}, nil)
+	mockProd.On("GetAtStoreForUpdate", ctx, int64(2), int64(3)).Return(&domain.Product{ID: 3, Quantity: 0}, nil)
+	mockProd.On("DecrementStock", ctx, int64(2), int64(2), 2).Return([]domain.BatchAllocation{{BatchID: 9, Quantity: 2}}, nil)
+
+	_, err := svc.FinalizeStocktake(ctx, 4, service.FinalizeStocktakeRequest{Actor: "Sari"})
+
+	assert.NoError(t, err)
+	assert.True(t, uow.Committed)
+	assert.False(t, counts.Stocktake.IsOpen())
+	if assert.Len(t, moves.Movements, 1) {
+		assert.Equal(t, -2, moves.Movements[0].Quantity)
+	}
+	mockProd.AssertExpectations(t)
+	mockProd.AssertNotCalled(t, "DecrementStock", ctx, int64(2), int64(3), mock.Anything)
+}
+
+func TestSubmitCounts_FinalizedStocktake(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	finalized := time.Now()
+	counts := &MemoryStocktakeRepo{Stocktake: domain.Stocktake{ID: 4, StoreID: 2, FinalizedAt: &finalized}}
+	svc := service.NewStocktakeService(NewMockUnitOfWork(port.Repositories{Product: mockProd, Stocktake: counts}), counts)
+
+	_, err := svc.SubmitCounts(context.TODO(), 4, service.StocktakeCountsRequest{
+		Counts: []service.StocktakeCount{{ProductID: 1, Counted: 3}},
+		Actor:  "Sari",
+	})
+
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	mockProd.AssertNotCalled(t, "GetAtStore", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSubmitCounts_RecountReplaces(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	counts := &MemoryStocktakeRepo{Stocktake: domain.Stocktake{ID: 4, StoreID: 2, Lines: []domain.StocktakeLine{
+		{ProductID: 1, Counted: 12, System: 10, Variance: 2, CountedBy: "Budi"},
+	}}}
+	svc := service.NewStocktakeService(NewMockUnitOfWork(port.Repositories{Product: mockProd, Stocktake: counts}), counts)
+	ctx := context.TODO()
+
+	// the recount is compared with the stock when it was made
+	mockProd.On("GetAtStore", ctx, int64(2), int64(1)).Return(&domain.Product{ID: 1, Quantity: 11}, nil)
+
+	st, err := svc.SubmitCounts(ctx, 4, service.StocktakeCountsRequest{
+		Counts: []service.StocktakeCount{{ProductID: 1, Counted: 9}},
+		Actor:  "Sari",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.StocktakeLine{{ProductID: 1, Counted: 9, System: 11, Variance: -2, CountedBy: "Sari"}}, st.Lines)
+}
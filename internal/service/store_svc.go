@@ -0,0 +1,59 @@
+package service
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"strings"
+)
+
+// StoreService administers the outlets sales and stock are recorded against
+type StoreService struct {
+	repo port.StoreRepository
+}
+
+func NewStoreService(rs port.StoreRepository) *StoreService {
+	return &StoreService{repo: rs}
+}
+
+// StoreRequest creates or replaces a store; Active defaults to true
+type StoreRequest struct {
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	TaxID   string `json:"tax_id"`
+	Active  *bool  `json:"active"`
+}
+
+// ListStores lists every store, including inactive ones
+func (s *StoreService) ListStores(ctx context.Context) ([]domain.Store, error) {
+	return s.repo.ListAll(ctx)
+}
+
+func (s *StoreService) CreateStore(ctx context.Context, req StoreRequest) (*domain.Store, error) {
+	store := newStore(req)
+	if err := s.repo.Create(ctx, store); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// UpdateStore replaces a store's details; deactivating it stops its sales but keeps its history
+func (s *StoreService) UpdateStore(ctx context.Context, id int64, req StoreRequest) (*domain.Store, error) {
+	store := newStore(req)
+	store.ID = id
+	if err := s.repo.Update(ctx, store); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func newStore(req StoreRequest) *domain.Store {
+	return &domain.Store{
+		Code:    strings.ToUpper(strings.TrimSpace(req.Code)),
+		Name:    strings.TrimSpace(req.Name),
+		Address: strings.TrimSpace(req.Address),
+		TaxID:   strings.TrimSpace(req.TaxID),
+		Active:  activeOrDefault(req.Active),
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"strings"
+)
+
+// TaxService administers the PPN rates applied by TransactionService
+type TaxService struct {
+	repo port.TaxRateRepository
+}
+
+func NewTaxService(rt port.TaxRateRepository) *TaxService {
+	return &TaxService{repo: rt}
+}
+
+// TaxRateRequest creates or replaces a tax rate; a missing ProductType makes it the
+// default rate and Active defaults to true
+type TaxRateRequest struct {
+	Name        string  `json:"name"`
+	ProductType *string `json:"product_type"`
+	BasisPoints int     `json:"basis_points"`
+	Inclusive   bool    `json:"inclusive"`
+	Active      *bool   `json:"active"`
+}
+
+// ListTaxRates lists every rate, including retired ones
+func (s *TaxService) ListTaxRates(ctx context.Context) (domain.TaxRates, error) {
+	return s.repo.ListAll(ctx)
+}
+
+// CreateTaxRate adds a rate. Only one active rate may cover a product type, so the
+// current one must be retired first.
+func (s *TaxService) CreateTaxRate(ctx context.Context, req TaxRateRequest) (*domain.TaxRate, error) {
+	rate := taxRate(req)
+	if err := s.repo.Create(ctx, rate); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+// UpdateTaxRate replaces a rate. Sales keep the rate they were made with.
+func (s *TaxService) UpdateTaxRate(ctx context.Context, id int64, req TaxRateRequest) (*domain.TaxRate, error) {
+	rate := taxRate(req)
+	rate.ID = id
+	if err := s.repo.Update(ctx, rate); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+// DeactivateTaxRate retires a rate so it no longer applies to new sales
+func (s *TaxService) DeactivateTaxRate(ctx context.Context, id int64) error {
+	return s.repo.Deactivate(ctx, id)
+}
+
+func taxRate(req TaxRateRequest) *domain.TaxRate {
+	return &domain.TaxRate{
+		Name:        strings.TrimSpace(req.Name),
+		ProductType: normalizeProductType(req.ProductType),
+		BasisPoints: req.BasisPoints,
+		Inclusive:   req.Inclusive,
+		Active:      activeOrDefault(req.Active),
+	}
+}
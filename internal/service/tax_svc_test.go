@@ -0,0 +1,39 @@
+package service_test
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/service"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateTaxRate_BlankTypeIsDefault(t *testing.T) {
+	mockTax := new(MockTaxRateRepo)
+	svc := service.NewTaxService(mockTax)
+	ctx := context.TODO()
+
+	mockTax.On("Create", ctx, mock.MatchedBy(func(r *domain.TaxRate) bool {
+		return r.ProductType == nil && r.Name == "PPN 12%" && r.Active
+	})).Return(nil)
+
+	rate, err := svc.CreateTaxRate(ctx, service.TaxRateRequest{Name: " PPN 12% ", ProductType: strPtr(" "), BasisPoints: 1200})
+
+	assert.NoError(t, err)
+	assert.False(t, rate.Inclusive)
+	mockTax.AssertExpectations(t)
+}
+
+func TestUpdateTaxRate_NotFound(t *testing.T) {
+	mockTax := new(MockTaxRateRepo)
+	svc := service.NewTaxService(mockTax)
+	ctx := context.TODO()
+
+	mockTax.On("Update", ctx, mock.AnythingOfType("*domain.TaxRate")).Return(domain.NewNotFoundError("tax rate not found"))
+
+	_, err := svc.UpdateTaxRate(ctx, 9, service.TaxRateRequest{Name: "PPN", BasisPoints: 1100})
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
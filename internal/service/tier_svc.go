@@ -0,0 +1,82 @@
+package service
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// tierWindowMonths is the rolling period whose spend decides a customer's tier
+const tierWindowMonths = 12
+
+// TierService keeps customers' loyalty tiers in line with their recent spend
+type TierService struct {
+	uow         port.UnitOfWork
+	repoCust    port.CustomerRepository
+	repoTrans   port.TransactionRepository
+	repoLoyalty port.LoyaltyRuleRepository
+}
+
+func NewTierService(
+	uow port.UnitOfWork,
+	rc port.CustomerRepository,
+	rt port.TransactionRepository,
+	rl port.LoyaltyRuleRepository,
+) *TierService {
+	return &TierService{
+		uow:         uow,
+		repoCust:    rc,
+		repoTrans:   rt,
+		repoLoyalty: rl,
+	}
+}
+
+// RecalculateTiers moves every customer to the tier their net spend over the last
+// tierWindowMonths qualifies for, recording an event for each change. Customers are
+// updated one at a time so a failure does not hold back the rest of the run.
+func (s *TierService) RecalculateTiers(ctx context.Context, now time.Time) ([]domain.TierEvent, error) {
+	rules, err := s.repoLoyalty.ActiveRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	spend, err := s.repoTrans.RollingSpend(ctx, now.AddDate(0, -tierWindowMonths, 0))
+	if err != nil {
+		return nil, err
+	}
+	customers, err := s.repoCust.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []domain.TierEvent{}
+	var errs []error
+	for _, c := range customers {
+		tier := rules.TierFor(spend[c.ID])
+		if tier == c.Tier {
+			continue
+		}
+
+		e := &domain.TierEvent{CustomerID: c.ID, FromTier: c.Tier, ToTier: tier, RollingSpend: spend[c.ID]}
+		err := s.uow.Do(ctx, func(repos port.Repositories) error {
+			return repos.Customer.ChangeTier(ctx, e)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("change tier of customer %d: %w", c.ID, err))
+			continue
+		}
+		events = append(events, *e)
+	}
+
+	return events, errors.Join(errs...)
+}
+
+// GetTierHistory lists a customer's tier changes, newest first
+func (s *TierService) GetTierHistory(ctx context.Context, customerID int64) ([]domain.TierEvent, error) {
+	if _, err := s.repoCust.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+	return s.repoCust.ListTierEvents(ctx, customerID)
+}
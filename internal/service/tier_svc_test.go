@@ -0,0 +1,95 @@
+package service_test
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/port"
+	"bsnack/internal/service"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func tieredLoyalty() *StaticLoyaltyRepo {
+	repo := defaultLoyalty()
+	repo.Rules.Tiers = []domain.TierRule{
+		{Tier: domain.TierBronze, MinSpend: 0, MultiplierPercent: 100},
+		{Tier: domain.TierSilver, MinSpend: domain.NewMoney(1_000_000), MultiplierPercent: 125},
+		{Tier: domain.TierGold, MinSpend: domain.NewMoney(5_000_000), MultiplierPercent: 150},
+	}
+	return repo
+}
+
+func TestRecalculateTiers(t *testing.T) {
+	mockCust := new(MockCustomerRepo)
+	mockTrans := new(MockTransactionRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Customer: mockCust})
+	svc := service.NewTierService(uow, mockCust, mockTrans, tieredLoyalty())
+	ctx := context.TODO()
+	now := time.Date(2025, 6, 1, 2, 0, 0, 0, time.UTC)
+
+	mockTrans.On("RollingSpend", ctx, time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)).Return(map[int64]domain.Money{
+		1: domain.NewMoney(6_000_000),
+		2: domain.NewMoney(1_200_000),
+	}, nil)
+	mockCust.On("ListAll", ctx).Return([]domain.Customer{
+		{ID: 1, Tier: domain.TierSilver},
+		{ID: 2, Tier: domain.TierSilver},
+		{ID: 3, Tier: domain.TierGold}, // no purchases in the window
+	}, nil)
+	mockCust.On("ChangeTier", ctx, mock.AnythingOfType("*domain.TierEvent")).Return(nil).Twice()
+
+	events, err := svc.RecalculateTiers(ctx, now)
+
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, domain.TierEvent{CustomerID: 1, FromTier: domain.TierSilver, ToTier: domain.TierGold,
+			RollingSpend: domain.NewMoney(6_000_000)}, events[0])
+		assert.Equal(t, domain.TierEvent{CustomerID: 3, FromTier: domain.TierGold, ToTier: domain.TierBronze}, events[1])
+	}
+	mockCust.AssertExpectations(t)
+}
+
+func TestRecalculateTiers_ContinuesAfterFailure(t *testing.T) {
+	mockCust := new(MockCustomerRepo)
+	mockTrans := new(MockTransactionRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Customer: mockCust})
+	svc := service.NewTierService(uow, mockCust, mockTrans, tieredLoyalty())
+	ctx := context.TODO()
+
+	mockTrans.On("RollingSpend", ctx, mock.Anything).Return(map[int64]domain.Money{
+		1: domain.NewMoney(1_500_000),
+		2: domain.NewMoney(1_500_000),
+	}, nil)
+	mockCust.On("ListAll", ctx).Return([]domain.Customer{
+		{ID: 1, Tier: domain.TierBronze},
+		{ID: 2, Tier: domain.TierBronze},
+	}, nil)
+	mockCust.On("ChangeTier", ctx, mock.MatchedBy(func(e *domain.TierEvent) bool { return e.CustomerID == 1 })).
+		Return(errors.New("db down"))
+	mockCust.On("ChangeTier", ctx, mock.MatchedBy(func(e *domain.TierEvent) bool { return e.CustomerID == 2 })).
+		Return(nil)
+
+	events, err := svc.RecalculateTiers(ctx, time.Now())
+
+	assert.ErrorContains(t, err, "customer 1")
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, int64(2), events[0].CustomerID)
+	}
+}
+
+func TestGetTierHistory_UnknownCustomer(t *testing.T) {
+	mockCust := new(MockCustomerRepo)
+	svc := service.NewTierService(NewMockUnitOfWork(port.Repositories{}), mockCust, new(MockTransactionRepo), tieredLoyalty())
+	ctx := context.TODO()
+
+	mockCust.On("GetByID", ctx, int64(9)).Return(nil, domain.NewNotFoundError("customer not found"))
+
+	_, err := svc.GetTierHistory(ctx, 9)
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	mockCust.AssertNotCalled(t, "ListTierEvents", mock.Anything, mock.Anything)
+}
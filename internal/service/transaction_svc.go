@@ -6,152 +6,724 @@ import (
 	"bsnack/pkg/logger"
 	"context"
 	"errors"
-	"math"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type TransactionService struct {
-	repoProd  port.ProductRepository
-	repoCust  port.CustomerRepository
-	repoTrans port.TransactionRepository
-	repoCache port.CacheRepository
+	uow        port.UnitOfWork
+	repoProd   port.ProductRepository
+	repoCust   port.CustomerRepository
+	repoTrans  port.TransactionRepository
+	repoRedeem port.RedemptionRepository
+	repoCache  port.CacheRepository
+	expiry     domain.PointsExpiryPolicy
 }
 
 func NewTransactionService(
+	uow port.UnitOfWork,
 	rp port.ProductRepository,
 	rc port.CustomerRepository,
 	rt port.TransactionRepository,
+	rr port.RedemptionRepository,
 	cache port.CacheRepository,
+	expiry domain.PointsExpiryPolicy,
 ) *TransactionService {
 	return &TransactionService{
-		repoProd:  rp,
-		repoCust:  rc,
-		repoTrans: rt,
-		repoCache: cache,
+		uow:        uow,
+		repoProd:   rp,
+		repoCust:   rc,
+		repoTrans:  rt,
+		repoRedeem: rr,
+		repoCache:  cache,
+		expiry:     expiry,
 	}
 }
 
+// PurchaseRequest identifies the customer by customer_id, or by customer_name for walk-ins
+// who are registered on their first purchase. ShiftID is the till's open shift, which the
+// sale is tagged with along with its cashier; the sale is made at the shift's store, and
+// StoreID, when given, must match it.
 type PurchaseRequest struct {
-	CustomerName    string `json:"customer_name"`
-	ProductID       int64  `json:"product_id"`
-	Quantity        int    `json:"quantity"`
-	TransactionDate string `json:"transaction_date"`
+	CustomerID      int64            `json:"customer_id"`
+	CustomerName    string           `json:"customer_name"`
+	StoreID         int64            `json:"store_id"`
+	ProductID       int64            `json:"product_id"`
+	Quantity        int              `json:"quantity"`
+	CouponCode      string           `json:"coupon_code"`
+	Payments        []PaymentRequest `json:"payments"`
+	ShiftID         int64            `json:"shift_id"`
+	TransactionDate string           `json:"transaction_date"`
+}
+
+// PaymentRequest is one tender. Amount is what the customer hands over, which for
+// cash may be more than is due.
+type PaymentRequest struct {
+	Method    domain.PaymentMethod `json:"method"`
+	Amount    domain.Money         `json:"amount"`
+	Reference string               `json:"reference"`
 }
 
-func (s *TransactionService) Purchase(ctx context.Context, req PurchaseRequest) error {
+// Purchase deducts stock, applies promotions, grants points on the discounted total and
+// records the sale in a single unit of work. Stock comes out of the batch that expires
+// first, then the oldest. It returns the recorded transaction.
+func (s *TransactionService) Purchase(ctx context.Context, req PurchaseRequest) (*domain.Transaction, error) {
 	if req.Quantity <= 0 {
-		return errors.New("quantity must be greater than 0")
+		return nil, domain.NewValidationError("quantity must be greater than 0")
 	}
 
-	product, err := s.repoProd.GetByID(ctx, req.ProductID)
+	txDate, err := parseTransactionDate(req.TransactionDate)
 	if err != nil {
-		return errors.New("product not found")
+		return nil, err
 	}
 
-	if product.Quantity < req.Quantity {
-		return errors.New("insufficient stock")
-	}
+	var tx *domain.Transaction
+	err = s.uow.Do(ctx, func(repos port.Repositories) error {
+		shift, err := openShift(ctx, repos, req.ShiftID)
+		if err != nil {
+			return err
+		}
+		store, err := saleStore(ctx, repos, req.StoreID, shift)
+		if err != nil {
+			return err
+		}
 
-	customer, err := s.repoCust.GetByName(ctx, req.CustomerName)
-	if err != nil || customer == nil {
-		customer = &domain.Customer{Name: req.CustomerName, Points: 0}
-		if err := s.repoCust.Create(ctx, customer); err != nil {
+		product, err := repos.Product.GetAtStore(ctx, store.ID, req.ProductID)
+		if err != nil {
 			return err
 		}
-	}
 
-	totalPrice := product.Price * float64(req.Quantity)
+		customer, err := resolveCustomer(ctx, repos.Customer, req.CustomerID, req.CustomerName)
+		if err != nil {
+			return err
+		}
+
+		rules, err := repos.Loyalty.ActiveRules(ctx)
+		if err != nil {
+			return err
+		}
+		taxRates, err := repos.Tax.ActiveRates(ctx)
+		if err != nil {
+			return err
+		}
 
-	// rule: 1 Point per Rp 1,000
-	pointsEarned := int(math.Floor(totalPrice / 1000))
+		cart := []domain.CartLine{{ProductID: product.ID, ProductType: product.Type, Price: product.Price, Quantity: req.Quantity}}
+		if err := applyPromotions(ctx, repos, cart, req.CouponCode, txDate); err != nil {
+			return err
+		}
+		line := &cart[0]
 
-	if err := s.repoProd.UpdateStock(ctx, product.ID, -req.Quantity); err != nil {
-		return err
-	}
-	if err := s.repoCust.UpdatePoints(ctx, customer.ID, pointsEarned); err != nil {
-		return err
-	}
-	var txDate time.Time
-	if req.TransactionDate != "" {
-		parsedDate, err := time.Parse("2006-01-02", req.TransactionDate)
+		batches, err := repos.Product.DecrementStock(ctx, store.ID, product.ID, req.Quantity)
 		if err != nil {
-			return errors.New("invalid transaction_date format (use YYYY-MM-DD)")
+			return err
 		}
-		txDate = parsedDate
-	} else {
-		txDate = time.Now()
-	}
 
-	tx := &domain.Transaction{
-		CustomerID:      customer.ID,
-		ProductID:       product.ID,
-		Quantity:        req.Quantity,
-		TotalPrice:      totalPrice,
-		TransactionDate: txDate,
+		tx = &domain.Transaction{
+			CustomerID:      customer.ID,
+			CustomerName:    customer.Name,
+			ProductID:       product.ID,
+			ProductName:     product.Name,
+			ProductSize:     string(product.Size),
+			ProductFlavor:   product.Flavor,
+			Quantity:        req.Quantity,
+			Subtotal:        line.Subtotal(),
+			DiscountTotal:   line.DiscountTotal(),
+			Discounts:       line.Discounts,
+			Batches:         batches,
+			CustomerTier:    customer.Tier,
+			StoreID:         store.ID,
+			TransactionDate: txDate,
+		}
+		tx.ShiftID, tx.Cashier = &shift.ID, shift.Cashier
+		tx.ApplyTax(taxRates.For(product.Type), line.Subtotal()-line.DiscountTotal())
+
+		// points are earned on what the customer pays, tax included
+		accrual := rules.Accrue(tx.TotalPrice, product.Type, customer.Tier, txDate)
+		pointsEarned := domain.PointsFromUnits(accrual.Units)
+		tx.PointsEarned = pointsEarned
+		balance := customer.Points + pointsEarned
+		tx.PointsBalance = &balance
+		tx.PointUnits = accrual.Units
+		tx.EarnRuleID = accrual.EarnRuleID
+		tx.BonusRuleID = accrual.BonusRuleID
+		if err := repos.Transaction.Create(ctx, tx); err != nil {
+			return err
+		}
+		if err := recordMovements(ctx, repos, domain.MovementSale, tx.Batches, tx.ID.String(), tx.Cashier); err != nil {
+			return err
+		}
+		if tx.Payments, err = takePayments(ctx, repos, tx.TotalPrice, req.Payments, &tx.ID, nil); err != nil {
+			return err
+		}
+
+		return postPoints(ctx, repos, s.expiry, txDate, &domain.PointsEntry{
+			CustomerID:    customer.ID,
+			Type:          domain.PointsEarn,
+			Points:        pointsEarned,
+			TransactionID: &tx.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
-	return s.repoTrans.Create(ctx, tx)
+
+	return tx, nil
 }
 
-// Redeem handles point exchange for products
-func (s *TransactionService) Redeem(ctx context.Context, customerName string, productID int64) error {
-	product, err := s.repoProd.GetByID(ctx, productID)
-	if err != nil {
-		return err
+type OrderLineRequest struct {
+	ProductID int64 `json:"product_id"`
+	Quantity  int   `json:"quantity"`
+}
+
+type CheckoutRequest struct {
+	CustomerID      int64              `json:"customer_id"`
+	CustomerName    string             `json:"customer_name"`
+	StoreID         int64              `json:"store_id"`
+	Items           []OrderLineRequest `json:"items"`
+	CouponCode      string             `json:"coupon_code"`
+	Payments        []PaymentRequest   `json:"payments"`
+	ShiftID         int64              `json:"shift_id"`
+	TransactionDate string             `json:"transaction_date"`
+}
+
+// Checkout records a multi-line order: one header plus a transaction row per line.
+// Promotions are priced across the whole cart, so bundles can mix lines. Each line
+// accrues under its own rules, but points are rounded once on the order total.
+func (s *TransactionService) Checkout(ctx context.Context, req CheckoutRequest) (*domain.Order, error) {
+	if len(req.Items) == 0 {
+		return nil, domain.NewValidationError("order must contain at least one item")
+	}
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, domain.NewValidationError("quantity must be greater than 0")
+		}
 	}
 
-	var cost int
-	switch product.Size {
-	case domain.SizeSmall:
-		cost = 200
-	case domain.SizeMedium:
-		cost = 300
-	case domain.SizeLarge:
-		cost = 500
-	default:
-		return errors.New("invalid product size")
+	orderDate, err := parseTransactionDate(req.TransactionDate)
+	if err != nil {
+		return nil, err
 	}
 
-	customer, err := s.repoCust.GetByName(ctx, customerName)
+	order := &domain.Order{OrderDate: orderDate}
+	err = s.uow.Do(ctx, func(repos port.Repositories) error {
+		shift, err := openShift(ctx, repos, req.ShiftID)
+		if err != nil {
+			return err
+		}
+		order.ShiftID, order.Cashier = &shift.ID, shift.Cashier
+		store, err := saleStore(ctx, repos, req.StoreID, shift)
+		if err != nil {
+			return err
+		}
+		order.StoreID = store.ID
+
+		customer, err := resolveCustomer(ctx, repos.Customer, req.CustomerID, req.CustomerName)
+		if err != nil {
+			return err
+		}
+		order.CustomerID = customer.ID
+		order.CustomerName = customer.Name
+
+		rules, err := repos.Loyalty.ActiveRules(ctx)
+		if err != nil {
+			return err
+		}
+		taxRates, err := repos.Tax.ActiveRates(ctx)
+		if err != nil {
+			return err
+		}
+
+		products := make([]*domain.Product, len(req.Items))
+		cart := make([]domain.CartLine, len(req.Items))
+		for i, item := range req.Items {
+			product, err := repos.Product.GetAtStore(ctx, store.ID, item.ProductID)
+			if err != nil {
+				return err
+			}
+			products[i] = product
+			cart[i] = domain.CartLine{ProductID: product.ID, ProductType: product.Type, Price: product.Price, Quantity: item.Quantity}
+		}
+		if err := applyPromotions(ctx, repos, cart, req.CouponCode, orderDate); err != nil {
+			return err
+		}
+
+		var units int64
+		lines := make([]domain.Transaction, 0, len(req.Items))
+		for i, item := range req.Items {
+			product, priced := products[i], &cart[i]
+			batches, err := repos.Product.DecrementStock(ctx, store.ID, product.ID, item.Quantity)
+			if err != nil {
+				return err
+			}
+
+			line := domain.Transaction{
+				CustomerID:      customer.ID,
+				CustomerName:    customer.Name,
+				ProductID:       product.ID,
+				ProductName:     product.Name,
+				ProductSize:     string(product.Size),
+				ProductFlavor:   product.Flavor,
+				Quantity:        item.Quantity,
+				Subtotal:        priced.Subtotal(),
+				DiscountTotal:   priced.DiscountTotal(),
+				Discounts:       priced.Discounts,
+				Batches:         batches,
+				CustomerTier:    customer.Tier,
+				StoreID:         store.ID,
+				ShiftID:         order.ShiftID,
+				Cashier:         order.Cashier,
+				TransactionDate: orderDate,
+			}
+			line.ApplyTax(taxRates.For(product.Type), priced.Subtotal()-priced.DiscountTotal())
+
+			accrual := rules.Accrue(line.TotalPrice, product.Type, customer.Tier, orderDate)
+			line.PointUnits = accrual.Units
+			line.EarnRuleID = accrual.EarnRuleID
+			line.BonusRuleID = accrual.BonusRuleID
+			units += accrual.Units
+			order.TotalQuantity += line.Quantity
+			order.Subtotal += line.Subtotal
+			order.DiscountTotal += line.DiscountTotal
+			order.NetAmount += line.NetAmount
+			order.TaxAmount += line.TaxAmount
+			order.TotalPrice += line.TotalPrice
+			lines = append(lines, line)
+		}
+
+		order.PointsEarned = domain.PointsFromUnits(units)
+		balance := customer.Points + order.PointsEarned
+		order.PointsBalance = &balance
+		if err := repos.Order.Create(ctx, order); err != nil {
+			return err
+		}
+
+		for i := range lines {
+			lines[i].OrderID = &order.ID
+			if err := repos.Transaction.Create(ctx, &lines[i]); err != nil {
+				return err
+			}
+			if err := recordMovements(ctx, repos, domain.MovementSale, lines[i].Batches, lines[i].ID.String(), lines[i].Cashier); err != nil {
+				return err
+			}
+		}
+		order.Lines = lines
+		if order.Payments, err = takePayments(ctx, repos, order.TotalPrice, req.Payments, nil, &order.ID); err != nil {
+			return err
+		}
+
+		return postPoints(ctx, repos, s.expiry, orderDate, &domain.PointsEntry{
+			CustomerID: customer.ID,
+			Type:       domain.PointsEarn,
+			Points:     order.PointsEarned,
+			OrderID:    &order.ID,
+		})
+	})
 	if err != nil {
-		return errors.New("customer not found")
+		return nil, err
 	}
 
-	if customer.Points < cost {
-		logger.Warn("redemption failed: insufficient points",
-			"customer", customerName,
-			"points", customer.Points,
-			"required", cost)
-		return errors.New("insufficient points")
+	return order, nil
+}
+
+// Refund reverses quantity units of a completed sale; a quantity of 0 refunds everything
+// not yet refunded. Stock goes back to the batches it was sold from, the points earned
+// on the refunded amount are clawed back and a negative transaction linked to the
+// original is recorded at the store that made the sale. A fully refunded purchase gives
+// back its coupon use. The cash paid out counts against shiftID, open at that store.
+func (s *TransactionService) Refund(ctx context.Context, id uuid.UUID, quantity int, shiftID int64) (*domain.Transaction, error) {
+	if quantity < 0 {
+		return nil, domain.NewValidationError("quantity must not be negative")
 	}
 
-	if err := s.repoCust.UpdatePoints(ctx, customer.ID, -cost); err != nil {
-		return err
+	var refund *domain.Transaction
+	err := s.uow.Do(ctx, func(repos port.Repositories) error {
+		shift, err := openShift(ctx, repos, shiftID)
+		if err != nil {
+			return err
+		}
+
+		original, err := repos.Transaction.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if original.RefundOf != nil {
+			return domain.NewConflictError("cannot refund a refund transaction")
+		}
+		if shift.StoreID != original.StoreID {
+			return domain.NewConflictError("refunds are made at the store of the sale")
+		}
+
+		refunded, err := repos.Transaction.GetRefundedQuantity(ctx, original.ID)
+		if err != nil {
+			return err
+		}
+		remaining := original.Quantity - refunded
+		if remaining == 0 {
+			return domain.NewConflictError("transaction already fully refunded")
+		}
+		if quantity == 0 {
+			quantity = remaining
+		}
+		if quantity > remaining {
+			return domain.NewValidationError("refund quantity exceeds remaining quantity")
+		}
+
+		// the refund returns what was paid for the units, so discounts and tax are reversed pro rata
+		amount := original.TotalPrice.MulDiv(quantity, original.Quantity)
+		tax := original.TaxAmount.MulDiv(quantity, original.Quantity)
+		subtotal := original.Subtotal.MulDiv(quantity, original.Quantity)
+		shelf := amount // the discounted shelf price, which excludes tax added on top of it
+		if !original.TaxInclusive {
+			shelf -= tax
+		}
+		units := original.PointUnits * int64(quantity) / int64(original.Quantity)
+
+		// claw back only what the purchase would no longer have earned under the rules
+		// it was made with, so partial refunds never take more points than were granted
+		netUnits, netPoints, err := repos.Transaction.GetPointsBasis(ctx, original)
+		if err != nil {
+			return err
+		}
+		clawback := netPoints - domain.PointsFromUnits(netUnits-units)
+
+		refund = &domain.Transaction{
+			OrderID:         original.OrderID,
+			RefundOf:        &original.ID,
+			CustomerID:      original.CustomerID,
+			ProductID:       original.ProductID,
+			Quantity:        -quantity,
+			Subtotal:        -subtotal,
+			DiscountTotal:   -(subtotal - shelf),
+			NetAmount:       -(amount - tax),
+			TaxAmount:       -tax,
+			TaxRateID:       original.TaxRateID,
+			TaxBasisPoints:  original.TaxBasisPoints,
+			TaxInclusive:    original.TaxInclusive,
+			TotalPrice:      -amount,
+			PointsEarned:    -clawback,
+			PointUnits:      -units,
+			EarnRuleID:      original.EarnRuleID,
+			BonusRuleID:     original.BonusRuleID,
+			CustomerTier:    original.CustomerTier,
+			StoreID:         original.StoreID,
+			TransactionDate: time.Now(),
+		}
+		refund.ShiftID, refund.Cashier = &shift.ID, shift.Cashier
+		returned := domain.ReturnBatches(original.Batches, refunded, quantity)
+		if err := repos.Product.RestoreStock(ctx, returned); err != nil {
+			return err
+		}
+		for _, a := range returned {
+			a.Quantity = -a.Quantity
+			refund.Batches = append(refund.Batches, a)
+		}
+		if err := repos.Transaction.Create(ctx, refund); err != nil {
+			return err
+		}
+		if err := recordMovements(ctx, repos, domain.MovementRefund, refund.Batches, refund.ID.String(), refund.Cashier); err != nil {
+			return err
+		}
+		// a purchase refunded in full no longer counts against its coupon's usage limit
+		coupon, err := repos.Transaction.GetRefundedCoupon(ctx, original)
+		if err != nil {
+			return err
+		}
+		if coupon != nil {
+			if err := repos.Promotion.ReleaseCoupon(ctx, *coupon); err != nil {
+				return err
+			}
+		}
+		// refunds are paid out in cash
+		if amount != 0 {
+			payout := domain.Payment{TransactionID: &refund.ID, Method: domain.PaymentCash, Amount: -amount, Tendered: -amount}
+			if err := repos.Payment.Create(ctx, &payout); err != nil {
+				return err
+			}
+			refund.Payments = []domain.Payment{payout}
+		}
+		return postPoints(ctx, repos, s.expiry, time.Now(), &domain.PointsEntry{
+			CustomerID:    original.CustomerID,
+			Type:          domain.PointsRefund,
+			Points:        -clawback,
+			TransactionID: &refund.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
-	if err := s.repoProd.UpdateStock(ctx, product.ID, -1); err != nil {
-		return err
+
+	return refund, nil
+}
+
+// RedeemRequest hands the product out at StoreID, the main store by default
+type RedeemRequest struct {
+	CustomerID   int64  `json:"customer_id"`
+	CustomerName string `json:"customer_name"`
+	ProductID    int64  `json:"product_id"`
+	StoreID      int64  `json:"store_id"`
+}
+
+// Redeem exchanges points for one unit of a product, priced by the active redeem rules,
+// and records the redemption alongside the ledger entry that paid for it
+func (s *TransactionService) Redeem(ctx context.Context, req RedeemRequest) (*domain.Redemption, error) {
+	var redemption *domain.Redemption
+	err := s.uow.Do(ctx, func(repos port.Repositories) error {
+		store, err := saleStore(ctx, repos, req.StoreID, nil)
+		if err != nil {
+			return err
+		}
+		product, err := repos.Product.GetAtStore(ctx, store.ID, req.ProductID)
+		if err != nil {
+			return err
+		}
+
+		rules, err := repos.Loyalty.ActiveRules(ctx)
+		if err != nil {
+			return err
+		}
+		rule := rules.RedeemRuleFor(product)
+		if rule == nil {
+			return domain.NewValidationError("product cannot be redeemed for points")
+		}
+		cost := rule.Points
+
+		customerID := req.CustomerID
+		if customerID <= 0 {
+			named, err := repos.Customer.GetByName(ctx, req.CustomerName)
+			if err != nil {
+				return err
+			}
+			customerID = named.ID
+		}
+		// lock the customer so concurrent redemptions cannot both pass the balance check
+		customer, err := repos.Customer.GetForUpdate(ctx, customerID)
+		if err != nil {
+			return err
+		}
+		lapsed, err := expireCustomerLots(ctx, repos, customer.ID, time.Now())
+		if err != nil {
+			return err
+		}
+		customer.Points -= lapsed
+
+		if customer.Points < cost {
+			logger.Warn("redemption failed: insufficient points",
+				"customer_id", customer.ID,
+				"points", customer.Points,
+				"required", cost)
+			return domain.ErrInsufficientPoints
+		}
+
+		entry := &domain.PointsEntry{
+			CustomerID:   customer.ID,
+			Type:         domain.PointsRedeem,
+			Points:       -cost,
+			RedeemRuleID: &rule.ID,
+		}
+		if err := postPoints(ctx, repos, s.expiry, time.Now(), entry); err != nil {
+			return err
+		}
+		batches, err := repos.Product.DecrementStock(ctx, store.ID, product.ID, 1)
+		if err != nil {
+			return err
+		}
+
+		redemption = &domain.Redemption{
+			CustomerID:    customer.ID,
+			CustomerName:  customer.Name,
+			ProductID:     product.ID,
+			ProductName:   product.Name,
+			ProductSize:   string(product.Size),
+			ProductFlavor: product.Flavor,
+			Quantity:      1,
+			PointsSpent:   cost,
+			RedeemRuleID:  &rule.ID,
+			PointsEntryID: entry.ID,
+			Batches:       batches,
+			StoreID:       store.ID,
+			RedeemedAt:    time.Now(),
+		}
+		if err := repos.Redemption.Create(ctx, redemption); err != nil {
+			return err
+		}
+		return recordMovements(ctx, repos, domain.MovementRedemption, batches, strconv.FormatInt(redemption.ID, 10), "")
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return redemption, nil
+}
+
+// ListRedemptions returns redemptions matching the filter, newest first
+func (s *TransactionService) ListRedemptions(ctx context.Context, f domain.RedemptionFilter) ([]domain.Redemption, error) {
+	return s.repoRedeem.List(ctx, f)
 }
 
-// GetReport uses Cache-Aside pattern
-func (s *TransactionService) GetReport(ctx context.Context, start, end string) (*domain.SalesReport, error) {
+// GetReceipt returns the receipt of the sale the transaction belongs to
+func (s *TransactionService) GetReceipt(ctx context.Context, id uuid.UUID) (*domain.Receipt, error) {
+	return s.repoTrans.GetReceipt(ctx, id)
+}
+
+// GetReport uses Cache-Aside pattern. A nil storeID reports every store together.
+func (s *TransactionService) GetReport(ctx context.Context, start, end string, storeID *int64) (*domain.SalesReport, error) {
 	if end == "" {
 		now := time.Now()
 		end = now.Format("2006-01-02")
 	}
 
-	cached, err := s.repoCache.GetReport(ctx, start, end)
+	cached, err := s.repoCache.GetReport(ctx, start, end, storeID)
 	if err == nil && cached != nil {
 		return cached, nil
 	}
 
-	report, err := s.repoTrans.GetReport(ctx, start, end)
+	report, err := s.repoTrans.GetReport(ctx, start, end, storeID)
 	if err != nil {
 		return nil, err
 	}
 
-	_ = s.repoCache.SetReport(ctx, start, end, report, 5*time.Minute)
+	_ = s.repoCache.SetReport(ctx, start, end, storeID, report, 5*time.Minute)
 
 	return report, nil
 }
+
+// applyPromotions prices the cart with the active promotions and counts a use of the
+// coupon, if one was applied. It must run inside the sale's unit of work so a sale
+// that fails does not use up the coupon.
+func applyPromotions(ctx context.Context, repos port.Repositories, cart []domain.CartLine, code string, at time.Time) error {
+	promos, err := repos.Promotion.ActivePromotions(ctx)
+	if err != nil {
+		return err
+	}
+	coupon, err := domain.ApplyPromotions(cart, promos, code, at)
+	if err != nil || coupon == nil {
+		return err
+	}
+	return repos.Promotion.UseCoupon(ctx, coupon.ID)
+}
+
+// openShift loads the shift a sale is made on and holds it open until the sale commits.
+// Every sale, order and refund is made on an open shift.
+func openShift(ctx context.Context, repos port.Repositories, id int64) (*domain.Shift, error) {
+	if id <= 0 {
+		return nil, domain.NewValidationError("shift_id is required")
+	}
+	shift, err := repos.Shift.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !shift.IsOpen() {
+		return nil, domain.NewConflictError("shift is closed")
+	}
+	return shift, nil
+}
+
+// saleStore returns the store a sale is made at: the shift's store, else the requested
+// store, else the main store. Inactive stores cannot sell.
+func saleStore(ctx context.Context, repos port.Repositories, id int64, shift *domain.Shift) (*domain.Store, error) {
+	if shift != nil {
+		if id != 0 && id != shift.StoreID {
+			return nil, domain.NewConflictError("shift belongs to another store")
+		}
+		id = shift.StoreID
+	}
+	if id == 0 {
+		id = domain.MainStoreID
+	}
+	store, err := repos.Store.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !store.Active {
+		return nil, domain.NewConflictError("store is inactive")
+	}
+	return store, nil
+}
+
+// recordMovements adds the stock taken from, or on refunds put back into, each batch
+// to the ledger
+func recordMovements(ctx context.Context, repos port.Repositories, kind domain.MovementKind, batches []domain.BatchAllocation, reference, actor string) error {
+	movements := domain.TakenMovements(kind, batches, reference)
+	for i := range movements {
+		movements[i].Actor = actor
+	}
+	return repos.Movement.Record(ctx, movements)
+}
+
+// takePayments settles the tenders against the sale's total and records them against
+// the purchase or order. A sale given no tenders is taken as paid in exact cash.
+func takePayments(ctx context.Context, repos port.Repositories, total domain.Money, reqs []PaymentRequest, transactionID, orderID *uuid.UUID) ([]domain.Payment, error) {
+	tenders := make([]domain.Payment, 0, max(len(reqs), 1))
+	for _, req := range reqs {
+		tenders = append(tenders, domain.Payment{Method: req.Method, Tendered: req.Amount, Reference: strings.TrimSpace(req.Reference)})
+	}
+	if len(tenders) == 0 && total > 0 {
+		tenders = append(tenders, domain.Payment{Method: domain.PaymentCash, Tendered: total})
+	}
+
+	payments, err := domain.SettlePayments(total, tenders)
+	if err != nil {
+		return nil, err
+	}
+	for i := range payments {
+		payments[i].TransactionID = transactionID
+		payments[i].OrderID = orderID
+		if err := repos.Payment.Create(ctx, &payments[i]); err != nil {
+			return nil, err
+		}
+	}
+	return payments, nil
+}
+
+// parseTransactionDate accepts an optional YYYY-MM-DD date and defaults to now
+func parseTransactionDate(date string) (time.Time, error) {
+	if date == "" {
+		return time.Now(), nil
+	}
+	parsedDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return time.Time{}, domain.NewValidationError("invalid transaction_date format (use YYYY-MM-DD)")
+	}
+	return parsedDate, nil
+}
+
+// resolveCustomer prefers the stable customer id and falls back to the walk-in name
+func resolveCustomer(ctx context.Context, repo port.CustomerRepository, id int64, name string) (*domain.Customer, error) {
+	if id > 0 {
+		return repo.GetByID(ctx, id)
+	}
+	return findOrCreateCustomer(ctx, repo, name)
+}
+
+// findOrCreateCustomer registers the customer on their first purchase. Names are not
+// unique, so the create runs under a name lock and re-checks after taking it.
+func findOrCreateCustomer(ctx context.Context, repo port.CustomerRepository, name string) (*domain.Customer, error) {
+	customer, err := repo.GetByName(ctx, name)
+	if err == nil {
+		return customer, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	if err := repo.LockName(ctx, name); err != nil {
+		return nil, err
+	}
+	// a concurrent first purchase may have registered the name while we waited
+	customer, err = repo.GetByName(ctx, name)
+	if err == nil {
+		return customer, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	customer = &domain.Customer{Name: name, Points: 0}
+	if err := repo.Create(ctx, customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
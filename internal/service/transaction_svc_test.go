@@ -2,11 +2,15 @@ package service_test
 
 import (
 	"bsnack/internal/domain"
+	"bsnack/internal/port"
 	"bsnack/internal/service"
 	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -14,106 +18,1006 @@ import (
 func TestPurchase_Success_NewCustomer(t *testing.T) {
 	mockProd := new(MockProductRepo)
 	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
 	mockTrans := new(MockTransactionRepo)
 	mockCache := new(MockCacheRepo)
+	moves := &MemoryMovementRepo{}
 
-	svc := service.NewTransactionService(mockProd, mockCust, mockTrans, mockCache)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Points: mockLedger, Movement: moves})
+
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, mockCache, domain.PointsExpiryPolicy{})
 	ctx := context.TODO()
 
 	req := service.PurchaseRequest{
+		ShiftID:      openShiftID,
 		CustomerName: "Budi",
 		ProductID:    1,
 		Quantity:     2,
 	}
 
-	product := &domain.Product{ID: 1, Price: 10000, Quantity: 10} // 10k price
+	product := &domain.Product{ID: 1, Price: domain.NewMoney(10000), Quantity: 10} // 10k price
 
-	mockProd.On("GetByID", ctx, int64(1)).Return(product, nil)
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(product, nil)
 
 	// customer not found -> register new customer
-	mockCust.On("GetByName", ctx, "Budi").Return(nil, errors.New("not found"))
+	mockCust.On("GetByName", ctx, "Budi").Return(nil, domain.NewNotFoundError("customer not found"))
+	mockCust.On("LockName", ctx, "Budi").Return(nil)
 	mockCust.On("Create", ctx, mock.AnythingOfType("*domain.Customer")).Return(nil)
 
-	// deduct Stock (qty 2)
-	mockProd.On("UpdateStock", ctx, int64(1), -2).Return(nil)
+	// deduct Stock (qty 2) from the two batches that sell first
+	batches := []domain.BatchAllocation{{BatchID: 3, Lot: "L0901", Quantity: 1}, {BatchID: 4, Lot: "L0915", Quantity: 1}}
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 2).Return(batches, nil)
 
 	// calculation: (10,000 * 2) / 1000 = 20 points
+	mockLedger.On("Append", ctx, mock.MatchedBy(func(e *domain.PointsEntry) bool {
+		return e.CustomerID == 1 && e.Type == domain.PointsEarn && e.Points == 20 && e.TransactionID != nil
+	})).Return(nil)
 	mockCust.On("UpdatePoints", ctx, int64(1), 20).Return(nil)
 
 	mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
 
-	err := svc.Purchase(ctx, req)
+	tx, err := svc.Purchase(ctx, req)
 
 	assert.NoError(t, err)
+	assert.True(t, uow.Committed)
+	assert.Equal(t, batches, tx.Batches)
+	// the sale's movements are signed by the shift's cashier
+	taken := domain.TakenMovements(domain.MovementSale, batches, tx.ID.String())
+	for i := range taken {
+		taken[i].Actor = "Sari"
+	}
+	assert.Equal(t, taken, moves.Movements)
 	mockProd.AssertExpectations(t)
 	mockCust.AssertExpectations(t)
 	mockTrans.AssertExpectations(t)
 }
 
-func TestPurchase_InsufficientStock(t *testing.T) {
+func TestPurchase_TransactionFailure_RollsBack(t *testing.T) {
 	mockProd := new(MockProductRepo)
-	svc := service.NewTransactionService(mockProd, nil, nil, nil)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
 
-	product := &domain.Product{ID: 1, Quantity: 1}
-	mockProd.On("GetByID", context.TODO(), int64(1)).Return(product, nil)
+	product := &domain.Product{ID: 1, Price: domain.NewMoney(10000), Quantity: 10}
+	customer := &domain.Customer{ID: 5, Name: "Budi"}
 
-	req := service.PurchaseRequest{ProductID: 1, Quantity: 2}
-	err := svc.Purchase(context.TODO(), req)
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(product, nil)
+	mockCust.On("GetByName", ctx, "Budi").Return(customer, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 1).Return(nil, nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), 10).Return(nil)
+	mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(errors.New("db down"))
 
-	assert.Error(t, err)
-	assert.Equal(t, "insufficient stock", err.Error())
+	_, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerName: "Budi", ProductID: 1, Quantity: 1, ShiftID: openShiftID})
+
+	assert.EqualError(t, err, "db down")
+	assert.False(t, uow.Committed)
+}
+
+func TestPurchase_InvalidQuantity(t *testing.T) {
+	svc := service.NewTransactionService(nil, nil, nil, nil, nil, nil, domain.PointsExpiryPolicy{})
+
+	_, err := svc.Purchase(context.TODO(), service.PurchaseRequest{ProductID: 1, Quantity: 0, ShiftID: openShiftID})
+
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	assert.Equal(t, "quantity must be greater than 0", err.Error())
+}
+
+func TestPurchase_CustomerLookupFailure(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, nil, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Quantity: 10}, nil)
+	mockCust.On("GetByName", ctx, "Budi").Return(nil, errors.New("connection reset"))
+
+	_, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerName: "Budi", ProductID: 1, Quantity: 1, ShiftID: openShiftID})
+
+	// only a missing customer is auto-registered; other failures abort the purchase
+	assert.EqualError(t, err, "connection reset")
+	mockCust.AssertNotCalled(t, "Create")
+}
+
+func TestPurchase_NameRegisteredWhileWaitingForLock(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Price: domain.NewMoney(10000), Quantity: 10}, nil)
+	// a concurrent first purchase registers Budi between the lookup and the lock
+	mockCust.On("GetByName", ctx, "Budi").Return(nil, domain.NewNotFoundError("customer not found")).Once()
+	mockCust.On("LockName", ctx, "Budi").Return(nil)
+	mockCust.On("GetByName", ctx, "Budi").Return(&domain.Customer{ID: 5, Name: "Budi"}, nil).Once()
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 1).Return(nil, nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), 10).Return(nil)
+	mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+
+	tx, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerName: "Budi", ProductID: 1, Quantity: 1, ShiftID: openShiftID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), tx.CustomerID)
+	mockCust.AssertNotCalled(t, "Create")
+	mockCust.AssertExpectations(t)
+}
+
+func TestPurchase_AmbiguousNameNeedsCustomerID(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, nil, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Quantity: 10}, nil)
+	mockCust.On("GetByName", ctx, "Budi").Return(nil, domain.NewConflictError("several customers are named Budi, use customer_id"))
+
+	_, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerName: "Budi", ProductID: 1, Quantity: 1, ShiftID: openShiftID})
+
+	// a shared name is never resolved by registering yet another Budi
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	mockCust.AssertNotCalled(t, "LockName")
+	mockCust.AssertNotCalled(t, "Create")
+}
+
+func TestPurchase_OnlyExpiredStock(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, nil, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	// the shelf holds two units, but both expired, so none count as stock
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Price: domain.NewMoney(10000)}, nil)
+	mockCust.On("GetByName", ctx, "Budi").Return(&domain.Customer{ID: 5}, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 2).Return(nil, domain.ErrExpiredStock)
+
+	_, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerName: "Budi", ProductID: 1, Quantity: 2, ShiftID: openShiftID})
+
+	assert.ErrorIs(t, err, domain.ErrExpiredStock)
+	assert.False(t, uow.Committed)
+}
+
+func TestPurchase_StockDepletedDuringPurchase(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, nil, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	// the read still sees stock, but another till sold it before the decrement
+	product := &domain.Product{ID: 1, Price: domain.NewMoney(10000), Quantity: 1}
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(product, nil)
+	mockCust.On("GetByName", ctx, "Budi").Return(&domain.Customer{ID: 5}, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 1).Return(nil, domain.ErrInsufficientStock)
+
+	_, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerName: "Budi", ProductID: 1, Quantity: 1, ShiftID: openShiftID})
+
+	assert.ErrorIs(t, err, domain.ErrInsufficientStock)
+	assert.False(t, uow.Committed)
+	mockCust.AssertNotCalled(t, "UpdatePoints")
+}
+
+func TestPurchase_OnlyExpiredStockLeft(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, nil, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	// the units on the shelf are past their expiry date
+	product := &domain.Product{ID: 1, Price: domain.NewMoney(10000), Quantity: 3}
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(product, nil)
+	mockCust.On("GetByName", ctx, "Budi").Return(&domain.Customer{ID: 5}, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 2).Return(nil, domain.ErrExpiredStock)
+
+	_, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerName: "Budi", ProductID: 1, Quantity: 2, ShiftID: openShiftID})
+
+	assert.ErrorIs(t, err, domain.ErrExpiredStock)
+	assert.False(t, uow.Committed)
+}
+
+// TestPurchase_ConcurrentLastUnits covers only the service's orchestration: stock sits in
+// an in-memory repo behind a mutex. The SQL row lock and shortfall mapping of
+// DecrementStock are tested in the postgres package.
+func TestPurchase_ConcurrentLastUnits(t *testing.T) {
+	const stock, buyers = 5, 50
+
+	prodRepo := NewStockProductRepo(domain.Product{ID: 1, Price: domain.NewMoney(10000), Quantity: stock})
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	svc := service.NewTransactionService(NewMockUnitOfWork(port.Repositories{Product: prodRepo, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Points: mockLedger}), prodRepo, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockCust.On("GetByName", ctx, "Budi").Return(&domain.Customer{ID: 5}, nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), 10).Return(nil)
+	mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int32
+		rejected  atomic.Int32
+	)
+	for range buyers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerName: "Budi", ProductID: 1, Quantity: 1, ShiftID: openShiftID})
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.Is(err, domain.ErrInsufficientStock):
+				rejected.Add(1)
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(stock), succeeded.Load())
+	assert.Equal(t, int32(buyers-stock), rejected.Load())
+	assert.Equal(t, 0, prodRepo.Quantity(1))
+	mockTrans.AssertNumberOfCalls(t, "Create", stock)
+}
+
+func TestCheckout_PointsOnOrderTotal(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	mockOrder := new(MockOrderRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Order: mockOrder, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockCust.On("GetByName", ctx, "Budi").Return(&domain.Customer{ID: 5, Name: "Budi"}, nil)
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Price: domain.NewMoney(600), Quantity: 10}, nil)
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(2)).Return(&domain.Product{ID: 2, Price: domain.NewMoney(700), Quantity: 10}, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 1).Return(nil, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(2), 1).Return(nil, nil)
+	mockOrder.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+	mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+
+	// per line neither 600 nor 700 earns a point, the 1,300 total earns one
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), 1).Return(nil)
+
+	order, err := svc.Checkout(ctx, service.CheckoutRequest{
+		ShiftID:      openShiftID,
+		CustomerName: "Budi",
+		Items: []service.OrderLineRequest{
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 2, Quantity: 1},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, uow.Committed)
+	assert.Equal(t, domain.NewMoney(1300), order.TotalPrice)
+	assert.Equal(t, 2, order.TotalQuantity)
+	assert.Equal(t, 1, order.PointsEarned)
+	assert.Len(t, order.Lines, 2)
+	mockTrans.AssertNumberOfCalls(t, "Create", 2)
+	mockCust.AssertExpectations(t)
+}
+
+func TestCheckout_EmptyOrder(t *testing.T) {
+	svc := service.NewTransactionService(nil, nil, nil, nil, nil, nil, domain.PointsExpiryPolicy{})
+
+	_, err := svc.Checkout(context.TODO(), service.CheckoutRequest{CustomerName: "Budi", ShiftID: openShiftID})
+
+	assert.EqualError(t, err, "order must contain at least one item")
+}
+
+func TestRefund_Partial(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	moves := &MemoryMovementRepo{}
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Points: mockLedger, Movement: moves})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	// 3 x 1,500 = 4,500 earned 4 points, taken from two batches
+	original := &domain.Transaction{StoreID: domain.MainStoreID, ID: uuid.New(), CustomerID: 5, ProductID: 1, Quantity: 3, TotalPrice: domain.NewMoney(4500), PointsEarned: 4, PointUnits: 4_500_000,
+		Batches: []domain.BatchAllocation{{BatchID: 7, Quantity: 2}, {BatchID: 8, Quantity: 1}}}
+	mockTrans.On("GetByID", ctx, original.ID).Return(original, nil)
+	mockTrans.On("GetRefundedQuantity", ctx, original.ID).Return(0, nil)
+	mockTrans.On("GetPointsBasis", ctx, original).Return(int64(4_500_000), 4, nil)
+	mockTrans.On("GetRefundedCoupon", ctx, original).Return(nil, nil)
+	mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+	mockProd.On("RestoreStock", ctx, []domain.BatchAllocation{{BatchID: 8, Quantity: 1}}).Return(nil)
+
+	// the remaining 3,000 still earns 3 points, so only 1 is clawed back
+	mockLedger.On("ConsumeLots", ctx, int64(5), 1).Return(nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), -1).Return(nil)
+
+	refund, err := svc.Refund(ctx, original.ID, 1, openShiftID)
+
+	assert.NoError(t, err)
+	assert.True(t, uow.Committed)
+	assert.Equal(t, domain.PaymentCash, refund.Payments[0].Method)
+	assert.Equal(t, domain.NewMoney(-1500), refund.Payments[0].Amount)
+	assert.Equal(t, &original.ID, refund.RefundOf)
+	assert.Equal(t, -1, refund.Quantity)
+	assert.Equal(t, domain.NewMoney(-1500), refund.TotalPrice)
+	assert.Equal(t, -1, refund.PointsEarned)
+	assert.Equal(t, []domain.BatchAllocation{{BatchID: 8, Quantity: -1}}, refund.Batches)
+	// the unit is put back into the batch it was sold from
+	assert.Equal(t, []domain.StockMovement{{BatchID: 8, Kind: domain.MovementRefund, Quantity: 1, Actor: "Sari", Reference: refund.ID.String()}}, moves.Movements)
+	mockProd.AssertExpectations(t)
+	mockCust.AssertExpectations(t)
+}
+
+func TestRefund_FullRemaining(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	promos := &StaticPromotionRepo{}
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: promos, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	// one of three units was already refunded, leaving 3,000 and 3 points
+	original := &domain.Transaction{StoreID: domain.MainStoreID, ID: uuid.New(), CustomerID: 5, ProductID: 1, Quantity: 3, TotalPrice: domain.NewMoney(4500), PointsEarned: 4, PointUnits: 4_500_000,
+		Batches: []domain.BatchAllocation{{BatchID: 7, Quantity: 2}, {BatchID: 8, Quantity: 1}}}
+	mockTrans.On("GetByID", ctx, original.ID).Return(original, nil)
+	mockTrans.On("GetRefundedQuantity", ctx, original.ID).Return(1, nil)
+	mockTrans.On("GetPointsBasis", ctx, original).Return(int64(3_000_000), 3, nil)
+	// the sale was made with coupon 4, whose use is given back now nothing is left of it
+	coupon := int64(4)
+	mockTrans.On("GetRefundedCoupon", ctx, original).Return(&coupon, nil)
+	mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+	// the first refund put batch 8 back, so the rest goes to batch 7
+	mockProd.On("RestoreStock", ctx, []domain.BatchAllocation{{BatchID: 7, Quantity: 2}}).Return(nil)
+	mockLedger.On("ConsumeLots", ctx, int64(5), 3).Return(nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), -3).Return(nil)
+
+	refund, err := svc.Refund(ctx, original.ID, 0, openShiftID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, -2, refund.Quantity)
+	assert.Equal(t, []int64{4}, promos.ReleasedCoupons)
+	mockProd.AssertExpectations(t)
+	mockCust.AssertExpectations(t)
+}
+
+func TestRefund_ExceedsRemaining(t *testing.T) {
+	mockTrans := new(MockTransactionRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Transaction: mockTrans})
+	svc := service.NewTransactionService(uow, nil, nil, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	original := &domain.Transaction{StoreID: domain.MainStoreID, ID: uuid.New(), Quantity: 2, TotalPrice: domain.NewMoney(2000)}
+	mockTrans.On("GetByID", ctx, original.ID).Return(original, nil)
+	mockTrans.On("GetRefundedQuantity", ctx, original.ID).Return(1, nil)
+
+	_, err := svc.Refund(ctx, original.ID, 2, openShiftID)
+
+	assert.EqualError(t, err, "refund quantity exceeds remaining quantity")
+	assert.False(t, uow.Committed)
+	mockTrans.AssertNotCalled(t, "Create")
 }
 
 func TestRedeem_Success(t *testing.T) {
 	mockProd := new(MockProductRepo)
 	mockCust := new(MockCustomerRepo)
-	svc := service.NewTransactionService(mockProd, mockCust, nil, nil)
+	mockLedger := new(MockPointsRepo)
+	mockRedeem := new(MockRedemptionRepo)
+	svc := service.NewTransactionService(NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Points: mockLedger, Redemption: mockRedeem}), mockProd, mockCust, nil, mockRedeem, nil, domain.PointsExpiryPolicy{})
 	ctx := context.TODO()
 
 	product := &domain.Product{ID: 1, Size: domain.SizeSmall, Quantity: 10}
 	customer := &domain.Customer{ID: 5, Name: "Fery", Points: 250} // Has 250
 
-	mockProd.On("GetByID", ctx, int64(1)).Return(product, nil)
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(product, nil)
 	mockCust.On("GetByName", ctx, "Fery").Return(customer, nil)
+	mockCust.On("GetForUpdate", ctx, int64(5)).Return(customer, nil)
+	mockLedger.On("ListCustomerExpiredLots", ctx, int64(5), mock.Anything).Return([]domain.PointsEntry{}, nil)
+
+	// the cost is spent from the oldest-expiring points first
+	mockLedger.On("ConsumeLots", ctx, int64(5), 200).Return(nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil).
+		Run(func(args mock.Arguments) { args.Get(1).(*domain.PointsEntry).ID = 77 })
 
 	mockCust.On("UpdatePoints", ctx, int64(5), -200).Return(nil)
-	mockProd.On("UpdateStock", ctx, int64(1), -1).Return(nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 1).Return(nil, nil)
+
+	// the redemption is recorded against the ledger entry that paid for it
+	mockRedeem.On("Create", ctx, mock.MatchedBy(func(r *domain.Redemption) bool {
+		return r.CustomerID == 5 && r.ProductID == 1 && r.Quantity == 1 && r.PointsSpent == 200 &&
+			*r.RedeemRuleID == 1 && r.PointsEntryID == 77
+	})).Return(nil)
 
-	err := svc.Redeem(ctx, "Fery", 1)
+	redemption, err := svc.Redeem(ctx, service.RedeemRequest{CustomerName: "Fery", ProductID: 1})
 	assert.NoError(t, err)
+	assert.Equal(t, 200, redemption.PointsSpent)
+	mockLedger.AssertExpectations(t)
+	mockRedeem.AssertExpectations(t)
+}
+
+func TestRedeem_ProductRuleOverridesSize(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	loyalty := defaultLoyalty()
+	productID := int64(1)
+	loyalty.Rules.Redeem = append(loyalty.Rules.Redeem, domain.RedeemRule{ID: 9, ProductID: &productID, Points: 120, Active: true})
+	mockRedeem := new(MockRedemptionRepo)
+	svc := service.NewTransactionService(NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: loyalty, Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Points: mockLedger, Redemption: mockRedeem}), mockProd, mockCust, nil, mockRedeem, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, productID).Return(&domain.Product{ID: 1, Size: domain.SizeLarge, Quantity: 10}, nil)
+	mockCust.On("GetForUpdate", ctx, int64(5)).Return(&domain.Customer{ID: 5, Points: 150}, nil)
+	mockLedger.On("ListCustomerExpiredLots", ctx, int64(5), mock.Anything).Return([]domain.PointsEntry{}, nil)
+	mockLedger.On("ConsumeLots", ctx, int64(5), 120).Return(nil)
+	// the ledger records which rule priced the redemption
+	mockLedger.On("Append", ctx, mock.MatchedBy(func(e *domain.PointsEntry) bool {
+		return e.Points == -120 && e.RedeemRuleID != nil && *e.RedeemRuleID == 9
+	})).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), -120).Return(nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, productID, 1).Return(nil, nil)
+	mockRedeem.On("Create", ctx, mock.AnythingOfType("*domain.Redemption")).Return(nil)
+
+	redemption, err := svc.Redeem(ctx, service.RedeemRequest{CustomerID: 5, ProductID: 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9), *redemption.RedeemRuleID)
+	mockLedger.AssertExpectations(t)
+}
+
+func TestRedeem_NoRule(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	svc := service.NewTransactionService(NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: &StaticLoyaltyRepo{}, Store: &StaticStoreRepo{}}), mockProd, nil, nil, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Size: domain.SizeSmall, Quantity: 10}, nil)
+
+	_, err := svc.Redeem(ctx, service.RedeemRequest{CustomerID: 5, ProductID: 1})
+
+	assert.EqualError(t, err, "product cannot be redeemed for points")
 }
 
 func TestRedeem_InsufficientPoints(t *testing.T) {
 	mockProd := new(MockProductRepo)
 	mockCust := new(MockCustomerRepo)
-	svc := service.NewTransactionService(mockProd, mockCust, nil, nil)
+	mockLedger := new(MockPointsRepo)
+	svc := service.NewTransactionService(NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Points: mockLedger}), mockProd, mockCust, nil, nil, nil, domain.PointsExpiryPolicy{})
 	ctx := context.TODO()
 
 	product := &domain.Product{ID: 1, Size: domain.SizeSmall}
 	customer := &domain.Customer{ID: 5, Points: 50}
 
-	mockProd.On("GetByID", ctx, int64(1)).Return(product, nil)
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(product, nil)
 	mockCust.On("GetByName", ctx, "Fery").Return(customer, nil)
+	mockCust.On("GetForUpdate", ctx, int64(5)).Return(customer, nil)
+	mockLedger.On("ListCustomerExpiredLots", ctx, int64(5), mock.Anything).Return([]domain.PointsEntry{}, nil)
 
-	err := svc.Redeem(ctx, "Fery", 1)
+	_, err := svc.Redeem(ctx, service.RedeemRequest{CustomerName: "Fery", ProductID: 1})
 
 	assert.Error(t, err)
 	assert.Equal(t, "insufficient points", err.Error())
+	assert.ErrorIs(t, err, domain.ErrInsufficientPoints)
+}
+
+func TestRedeem_LapsedPointsNotSpendable(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	svc := service.NewTransactionService(NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Points: mockLedger}), mockProd, mockCust, nil, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Size: domain.SizeSmall}, nil)
+	// the balance still shows 250 but 100 of it lapsed before the nightly sweep ran
+	mockCust.On("GetForUpdate", ctx, int64(5)).Return(&domain.Customer{ID: 5, Points: 250}, nil)
+	lot := domain.PointsEntry{ID: 3, CustomerID: 5}
+	mockLedger.On("ListCustomerExpiredLots", ctx, int64(5), mock.Anything).Return([]domain.PointsEntry{lot}, nil)
+	mockLedger.On("ExpireLot", ctx, int64(3), mock.Anything).Return(100, nil)
+	mockLedger.On("Append", ctx, mock.MatchedBy(func(e *domain.PointsEntry) bool {
+		return e.Type == domain.PointsExpire && e.Points == -100
+	})).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), -100).Return(nil)
+
+	_, err := svc.Redeem(ctx, service.RedeemRequest{CustomerID: 5, ProductID: 1})
+
+	assert.ErrorIs(t, err, domain.ErrInsufficientPoints)
+	mockLedger.AssertExpectations(t)
+	mockLedger.AssertNotCalled(t, "ConsumeLots")
+}
+
+func TestPurchase_PointsBoundary(t *testing.T) {
+	cases := []struct {
+		name   string
+		price  domain.Money
+		qty    int
+		points int
+	}{
+		{"one sen short", domain.Money(33333), 3, 0}, // 999.99
+		{"just over", domain.Money(33334), 3, 1},     // 1,000.02
+		{"exact multiple", domain.NewMoney(250), 8, 2},
+		{"below next point", domain.NewMoney(19999), 1, 19},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockProd := new(MockProductRepo)
+			mockCust := new(MockCustomerRepo)
+			mockLedger := new(MockPointsRepo)
+			mockTrans := new(MockTransactionRepo)
+			uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Points: mockLedger})
+			svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+			ctx := context.TODO()
+
+			mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Price: tc.price, Quantity: tc.qty}, nil)
+			mockCust.On("GetByName", ctx, "Budi").Return(&domain.Customer{ID: 5}, nil)
+			mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), tc.qty).Return(nil, nil)
+			mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+			if tc.points > 0 {
+				mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+				mockCust.On("UpdatePoints", ctx, int64(5), tc.points).Return(nil)
+			}
+
+			_, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerName: "Budi", ProductID: 1, Quantity: tc.qty, ShiftID: openShiftID})
+
+			assert.NoError(t, err)
+			mockCust.AssertExpectations(t)
+			if tc.points == 0 {
+				// nothing earned, so no ledger entry either
+				mockLedger.AssertNotCalled(t, "Append")
+			}
+		})
+	}
+}
+
+func TestCheckout_PointsWithoutFloatDrift(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	mockOrder := new(MockOrderRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Order: mockOrder, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	// as float64, 0.01 + 936.06 + 63.93 sums to 999.9999999999999 and earned no point
+	prices := map[int64]domain.Money{1: 1, 2: 93606, 3: 6393}
+	for id, price := range prices {
+		mockProd.On("GetAtStore", ctx, domain.MainStoreID, id).Return(&domain.Product{ID: id, Price: price, Quantity: 1}, nil)
+		mockProd.On("DecrementStock", ctx, domain.MainStoreID, id, 1).Return(nil, nil)
+	}
+	mockCust.On("GetByName", ctx, "Budi").Return(&domain.Customer{ID: 5}, nil)
+	mockOrder.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+	mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), 1).Return(nil)
+
+	order, err := svc.Checkout(ctx, service.CheckoutRequest{
+		ShiftID:      openShiftID,
+		CustomerName: "Budi",
+		Items:        []service.OrderLineRequest{{ProductID: 1, Quantity: 1}, {ProductID: 2, Quantity: 1}, {ProductID: 3, Quantity: 1}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(1000), order.TotalPrice)
+	mockCust.AssertExpectations(t)
+}
+
+func TestPurchase_RecordsAppliedRules(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	loyalty := defaultLoyalty()
+	loyalty.Rules.Bonus = []domain.BonusRule{{ID: 7, MultiplierPercent: 200, StartDate: "2025-12-01", EndDate: "2025-12-31", Active: true}}
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: loyalty, Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Type: "Makaroni", Price: domain.NewMoney(2500), Quantity: 10}, nil)
+	mockCust.On("GetByID", ctx, int64(5)).Return(&domain.Customer{ID: 5}, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 1).Return(nil, nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), 5).Return(nil)
+
+	// double points in December: 2,500 earns 5 instead of 2
+	mockTrans.On("Create", ctx, mock.MatchedBy(func(tx *domain.Transaction) bool {
+		return tx.PointsEarned == 5 && *tx.EarnRuleID == 1 && *tx.BonusRuleID == 7
+	})).Return(nil)
+
+	_, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerID: 5, ProductID: 1, Quantity: 1, TransactionDate: "2025-12-10", ShiftID: openShiftID})
+
+	assert.NoError(t, err)
+	mockTrans.AssertExpectations(t)
+	mockCust.AssertExpectations(t)
+}
+
+func TestPurchase_TierMultiplier(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: tieredLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Type: "Makaroni", Price: domain.NewMoney(10000), Quantity: 10}, nil)
+	mockCust.On("GetByID", ctx, int64(5)).Return(&domain.Customer{ID: 5, Tier: domain.TierGold}, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 1).Return(nil, nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), 15).Return(nil)
+
+	// Gold earns 150%: 10,000 earns 15 instead of 10
+	mockTrans.On("Create", ctx, mock.MatchedBy(func(tx *domain.Transaction) bool {
+		return tx.PointsEarned == 15 && tx.CustomerTier == domain.TierGold
+	})).Return(nil)
+
+	_, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerID: 5, ProductID: 1, Quantity: 1, TransactionDate: "2025-11-05", ShiftID: openShiftID})
+
+	assert.NoError(t, err)
+	mockTrans.AssertExpectations(t)
+	mockCust.AssertExpectations(t)
+}
+
+func TestPurchase_AppliesPromotionAndCoupon(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	code := "HEMAT"
+	promos := &StaticPromotionRepo{Promotions: []domain.Promotion{
+		{ID: 1, Name: "Diskon 10%", Kind: domain.PromotionPercentage, Percent: 10, StartDate: "2025-12-01", EndDate: "2025-12-31", Active: true},
+		{ID: 2, Name: "Kupon", Kind: domain.PromotionFixed, Amount: domain.NewMoney(500), Code: &code, StartDate: "2025-12-01", EndDate: "2025-12-31", Active: true},
+	}}
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: promos, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Type: "Makaroni", Price: domain.NewMoney(10000), Quantity: 10}, nil)
+	mockCust.On("GetByID", ctx, int64(5)).Return(&domain.Customer{ID: 5}, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 2).Return(nil, nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	// points are earned on what was paid: 20,000 - 2,000 - 2 x 500 = 17,000
+	mockCust.On("UpdatePoints", ctx, int64(5), 17).Return(nil)
+	mockTrans.On("Create", ctx, mock.MatchedBy(func(tx *domain.Transaction) bool {
+		return tx.Subtotal == domain.NewMoney(20000) && tx.DiscountTotal == domain.NewMoney(3000) &&
+			tx.TotalPrice == domain.NewMoney(17000) && len(tx.Discounts) == 2 && tx.Discounts[1].Code == "HEMAT"
+	})).Return(nil)
+
+	_, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerID: 5, ProductID: 1, Quantity: 2, CouponCode: "hemat", TransactionDate: "2025-12-10", ShiftID: openShiftID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2}, promos.UsedCoupons)
+	mockTrans.AssertExpectations(t)
+	mockCust.AssertExpectations(t)
+}
+
+func TestPurchase_CouponLimitReached(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	code := "HEMAT"
+	promos := &StaticPromotionRepo{
+		Promotions: []domain.Promotion{{ID: 2, Kind: domain.PromotionFixed, Amount: domain.NewMoney(500), Code: &code,
+			StartDate: "2025-12-01", EndDate: "2025-12-31", Active: true}},
+		UseErr: domain.NewConflictError("coupon usage limit reached"),
+	}
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: promos, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, nil, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Price: domain.NewMoney(10000), Quantity: 10}, nil)
+	mockCust.On("GetByID", ctx, int64(5)).Return(&domain.Customer{ID: 5}, nil)
+
+	_, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerID: 5, ProductID: 1, Quantity: 1, CouponCode: "HEMAT", TransactionDate: "2025-12-10", ShiftID: openShiftID})
+
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.False(t, uow.Committed)
+	mockProd.AssertNotCalled(t, "DecrementStock", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCheckout_BundleAcrossLines(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	mockOrder := new(MockOrderRepo)
+	productType := "Keripik Pangsit"
+	promos := &StaticPromotionRepo{Promotions: []domain.Promotion{{ID: 1, Name: "3 for 25k", Kind: domain.PromotionBundle,
+		ProductType: &productType, BundleQuantity: 3, BundlePrice: domain.NewMoney(25000),
+		StartDate: "2025-12-01", EndDate: "2025-12-31", Active: true}}}
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: promos, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{},
+		Customer: mockCust, Transaction: mockTrans, Order: mockOrder, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockCust.On("GetByID", ctx, int64(5)).Return(&domain.Customer{ID: 5}, nil)
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Type: productType, Flavor: "Balado", Price: domain.NewMoney(10000), Quantity: 10}, nil)
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(2)).Return(&domain.Product{ID: 2, Type: productType, Flavor: "Original", Price: domain.NewMoney(10000), Quantity: 10}, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, mock.Anything, mock.Anything).Return(nil, nil)
+	mockOrder.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+	mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), 25).Return(nil)
+
+	order, err := svc.Checkout(ctx, service.CheckoutRequest{CustomerID: 5, TransactionDate: "2025-12-10", ShiftID: openShiftID, Items: []service.OrderLineRequest{
+		{ProductID: 1, Quantity: 2},
+		{ProductID: 2, Quantity: 1},
+	}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(30000), order.Subtotal)
+	assert.Equal(t, domain.NewMoney(5000), order.DiscountTotal)
+	assert.Equal(t, domain.NewMoney(25000), order.TotalPrice)
+	assert.Equal(t, order.Lines[0].TotalPrice+order.Lines[1].TotalPrice, order.TotalPrice)
+	mockCust.AssertExpectations(t)
+}
+
+func TestPurchase_ExclusiveTax(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	taxes := &StaticTaxRepo{Rates: domain.TaxRates{
+		{ID: 1, Name: "PPN 11%", BasisPoints: 1100, Inclusive: true, Active: true},
+		{ID: 2, Name: "PPN 11%", ProductType: strPtr("Makaroni"), BasisPoints: 1100, Active: true},
+	}}
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: taxes, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Type: "Makaroni", Price: domain.NewMoney(10000), Quantity: 10}, nil)
+	mockCust.On("GetByID", ctx, int64(5)).Return(&domain.Customer{ID: 5, Name: "Budi", Points: 100}, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 2).Return(nil, nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	// points are earned on the 22,200 paid, tax included
+	mockCust.On("UpdatePoints", ctx, int64(5), 22).Return(nil)
+	mockTrans.On("Create", ctx, mock.MatchedBy(func(tx *domain.Transaction) bool {
+		return tx.NetAmount == domain.NewMoney(20000) && tx.TaxAmount == domain.NewMoney(2200) &&
+			tx.TotalPrice == domain.NewMoney(22200) && *tx.TaxRateID == 2 && !tx.TaxInclusive
+	})).Return(nil)
+
+	tx, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerID: 5, ProductID: 1, Quantity: 2, TransactionDate: "2025-12-10", ShiftID: openShiftID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Budi", tx.CustomerName)
+	assert.Equal(t, 122, *tx.PointsBalance)
+	mockTrans.AssertExpectations(t)
+	mockCust.AssertExpectations(t)
+}
+
+func TestCheckout_InclusiveTaxTotals(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	mockOrder := new(MockOrderRepo)
+	taxes := &StaticTaxRepo{Rates: domain.TaxRates{{ID: 1, Name: "PPN 11%", BasisPoints: 1100, Inclusive: true, Active: true}}}
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: taxes, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{},
+		Customer: mockCust, Transaction: mockTrans, Order: mockOrder, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockCust.On("GetByID", ctx, int64(5)).Return(&domain.Customer{ID: 5}, nil)
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Price: domain.NewMoney(11100), Quantity: 10}, nil)
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(2)).Return(&domain.Product{ID: 2, Price: domain.NewMoney(22200), Quantity: 10}, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, mock.Anything, mock.Anything).Return(nil, nil)
+	mockOrder.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+	mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), 33).Return(nil)
+
+	order, err := svc.Checkout(ctx, service.CheckoutRequest{CustomerID: 5, ShiftID: openShiftID, Items: []service.OrderLineRequest{
+		{ProductID: 1, Quantity: 1},
+		{ProductID: 2, Quantity: 1},
+	}})
+
+	assert.NoError(t, err)
+	// shelf prices already include the tax, so the customer pays them unchanged
+	assert.Equal(t, domain.NewMoney(33300), order.TotalPrice)
+	assert.Equal(t, domain.NewMoney(30000), order.NetAmount)
+	assert.Equal(t, domain.NewMoney(3300), order.TaxAmount)
+	assert.Equal(t, domain.NewMoney(2200), order.Lines[1].TaxAmount)
+}
+
+func TestRefund_ReversesTax(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	// 2 x 10,000 plus 11% PPN added on top
+	rateID := int64(2)
+	original := &domain.Transaction{StoreID: domain.MainStoreID, ID: uuid.New(), CustomerID: 5, ProductID: 1, Quantity: 2, Subtotal: domain.NewMoney(20000),
+		NetAmount: domain.NewMoney(20000), TaxAmount: domain.NewMoney(2200), TaxRateID: &rateID, TaxBasisPoints: 1100,
+		TotalPrice: domain.NewMoney(22200), PointsEarned: 22, PointUnits: 22_200_000,
+		Batches: []domain.BatchAllocation{{BatchID: 7, Quantity: 2}}}
+	mockTrans.On("GetByID", ctx, original.ID).Return(original, nil)
+	mockTrans.On("GetRefundedQuantity", ctx, original.ID).Return(0, nil)
+	mockTrans.On("GetPointsBasis", ctx, original).Return(int64(22_200_000), 22, nil)
+	mockTrans.On("GetRefundedCoupon", ctx, original).Return(nil, nil)
+	mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+	mockProd.On("RestoreStock", ctx, []domain.BatchAllocation{{BatchID: 7, Quantity: 1}}).Return(nil)
+	mockLedger.On("ConsumeLots", ctx, int64(5), 11).Return(nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), -11).Return(nil)
+
+	refund, err := svc.Refund(ctx, original.ID, 1, openShiftID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(-10000), refund.NetAmount)
+	assert.Equal(t, domain.NewMoney(-1100), refund.TaxAmount)
+	assert.Equal(t, domain.NewMoney(-11100), refund.TotalPrice)
+	assert.Zero(t, refund.DiscountTotal, "tax added on top is not a discount")
+	assert.Equal(t, &rateID, refund.TaxRateID)
+}
+
+func TestPurchase_SplitTenderWithChange(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	payments := &MemoryPaymentRepo{}
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: payments, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Price: domain.NewMoney(12000), Quantity: 10}, nil)
+	mockCust.On("GetByID", ctx, int64(5)).Return(&domain.Customer{ID: 5}, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 3).Return(nil, nil)
+	mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), 36).Return(nil)
+
+	tx, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerID: 5, ProductID: 1, Quantity: 3, ShiftID: openShiftID, Payments: []service.PaymentRequest{
+		{Method: domain.PaymentEWallet, Amount: domain.NewMoney(16000), Reference: " OVO-881 "},
+		{Method: domain.PaymentCash, Amount: domain.NewMoney(50000)},
+	}})
+
+	assert.NoError(t, err)
+	if assert.Len(t, payments.Payments, 2) {
+		assert.Equal(t, "OVO-881", payments.Payments[0].Reference)
+		assert.Equal(t, &tx.ID, payments.Payments[1].TransactionID)
+		// 36,000 due: 16,000 by e-wallet, 20,000 of the 50,000 cash
+		assert.Equal(t, domain.NewMoney(20000), payments.Payments[1].Amount)
+		assert.Equal(t, domain.NewMoney(30000), payments.Payments[1].Change)
+	}
+	assert.Equal(t, payments.Payments, tx.Payments)
+}
+
+func TestPurchase_UnderpaidRollsBack(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockTrans := new(MockTransactionRepo)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Customer: mockCust, Transaction: mockTrans})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Price: domain.NewMoney(12000), Quantity: 10}, nil)
+	mockCust.On("GetByID", ctx, int64(5)).Return(&domain.Customer{ID: 5}, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 1).Return(nil, nil)
+	mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+
+	_, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerID: 5, ProductID: 1, Quantity: 1, ShiftID: openShiftID, Payments: []service.PaymentRequest{
+		{Method: domain.PaymentCard, Amount: domain.NewMoney(10000)},
+	}})
+
+	assert.EqualError(t, err, "payments do not cover the total")
+	assert.False(t, uow.Committed)
+}
+
+func TestCheckout_DefaultsToExactCash(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	mockOrder := new(MockOrderRepo)
+	payments := &MemoryPaymentRepo{}
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: payments, Store: &StaticStoreRepo{},
+		Customer: mockCust, Transaction: mockTrans, Order: mockOrder, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockCust.On("GetByID", ctx, int64(5)).Return(&domain.Customer{ID: 5}, nil)
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Price: domain.NewMoney(10000), Quantity: 10}, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 2).Return(nil, nil)
+	mockOrder.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+	mockTrans.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), 20).Return(nil)
+
+	order, err := svc.Checkout(ctx, service.CheckoutRequest{CustomerID: 5, Items: []service.OrderLineRequest{{ProductID: 1, Quantity: 2}}, ShiftID: openShiftID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Payment{{ID: 1, OrderID: &order.ID, Method: domain.PaymentCash,
+		Amount: domain.NewMoney(20000), Tendered: domain.NewMoney(20000)}}, payments.Payments)
 }
 
 func TestGetReport_CacheHit(t *testing.T) {
 	mockCache := new(MockCacheRepo)
 	mockTrans := new(MockTransactionRepo)
-	svc := service.NewTransactionService(nil, nil, mockTrans, mockCache)
+	svc := service.NewTransactionService(nil, nil, nil, mockTrans, nil, mockCache, domain.PointsExpiryPolicy{})
 	ctx := context.TODO()
 
-	cachedReport := &domain.SalesReport{TotalIncome: 50000}
+	cachedReport := &domain.SalesReport{TotalIncome: domain.NewMoney(50000)}
 
-	mockCache.On("GetReport", ctx, "2025-01-01", "2025-01-31").Return(cachedReport, nil)
+	mockCache.On("GetReport", ctx, "2025-01-01", "2025-01-31", (*int64)(nil)).Return(cachedReport, nil)
 
-	res, err := svc.GetReport(ctx, "2025-01-01", "2025-01-31")
+	res, err := svc.GetReport(ctx, "2025-01-01", "2025-01-31", nil)
 
 	assert.NoError(t, err)
-	assert.Equal(t, 50000.0, res.TotalIncome)
+	assert.Equal(t, domain.NewMoney(50000), res.TotalIncome)
 	mockTrans.AssertNotCalled(t, "GetReport")
 }
+
+func TestPurchase_TaggedWithShift(t *testing.T) {
+	mockProd := new(MockProductRepo)
+	mockCust := new(MockCustomerRepo)
+	mockLedger := new(MockPointsRepo)
+	mockTrans := new(MockTransactionRepo)
+	shifts := &MemoryShiftRepo{}
+	shift := &domain.Shift{Till: "T1", Cashier: "Sari"}
+	shifts.Create(context.TODO(), shift)
+	uow := NewMockUnitOfWork(port.Repositories{Product: mockProd, Loyalty: defaultLoyalty(), Promotion: &StaticPromotionRepo{}, Tax: &StaticTaxRepo{}, Payment: &MemoryPaymentRepo{}, Store: &StaticStoreRepo{}, Shift: shifts, Customer: mockCust, Transaction: mockTrans, Points: mockLedger})
+	svc := service.NewTransactionService(uow, mockProd, mockCust, mockTrans, nil, nil, domain.PointsExpiryPolicy{})
+	ctx := context.TODO()
+
+	mockProd.On("GetAtStore", ctx, domain.MainStoreID, int64(1)).Return(&domain.Product{ID: 1, Price: domain.NewMoney(12000), Quantity: 10}, nil)
+	mockCust.On("GetByID", ctx, int64(5)).Return(&domain.Customer{ID: 5}, nil)
+	mockProd.On("DecrementStock", ctx, domain.MainStoreID, int64(1), 1).Return(nil, nil)
+	mockTrans.On("Create", ctx, mock.MatchedBy(func(tx *domain.Transaction) bool {
+		return tx.ShiftID != nil && *tx.ShiftID == shift.ID
+	})).Return(nil)
+	mockLedger.On("Append", ctx, mock.AnythingOfType("*domain.PointsEntry")).Return(nil)
+	mockCust.On("UpdatePoints", ctx, int64(5), 12).Return(nil)
+
+	tx, err := svc.Purchase(ctx, service.PurchaseRequest{CustomerID: 5, ProductID: 1, Quantity: 1, ShiftID: shift.ID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Sari", tx.Cashier)
+	mockTrans.AssertExpectations(t)
+}
+
+func TestPurchase_ClosedShiftRejected(t *testing.T) {
+	shifts := &MemoryShiftRepo{}
+	shift := &domain.Shift{Till: "T1", Cashier: "Sari"}
+	shifts.Create(context.TODO(), shift)
+	shift.Close(0, 0, shift.OpenedAt)
+	shifts.Close(context.TODO(), shift)
+	uow := NewMockUnitOfWork(port.Repositories{Shift: shifts})
+	svc := service.NewTransactionService(uow, nil, nil, nil, nil, nil, domain.PointsExpiryPolicy{})
+
+	_, err := svc.Purchase(context.TODO(), service.PurchaseRequest{CustomerID: 5, ProductID: 1, Quantity: 1, ShiftID: shift.ID})
+
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.False(t, uow.Committed)
+}
+
+func TestPurchase_ShiftAtAnotherStoreRejected(t *testing.T) {
+	shifts := &MemoryShiftRepo{}
+	shift := &domain.Shift{StoreID: 2, Till: "T1", Cashier: "Sari"}
+	shifts.Create(context.TODO(), shift)
+	uow := NewMockUnitOfWork(port.Repositories{Shift: shifts, Store: &StaticStoreRepo{}})
+	svc := service.NewTransactionService(uow, nil, nil, nil, nil, nil, domain.PointsExpiryPolicy{})
+
+	_, err := svc.Purchase(context.TODO(), service.PurchaseRequest{CustomerID: 5, ProductID: 1, Quantity: 1, StoreID: domain.MainStoreID, ShiftID: shift.ID})
+
+	assert.EqualError(t, err, "shift belongs to another store")
+	assert.False(t, uow.Committed)
+}
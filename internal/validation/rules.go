@@ -0,0 +1,367 @@
+package validation
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/service"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// column limits from the schema
+const (
+	maxNameLen      = 100
+	maxTypeLen      = 100
+	maxFlavorLen    = 50
+	maxEmailLen     = 254
+	maxCodeLen      = 50
+	maxReferenceLen = 100
+	maxTillLen      = 50
+	maxStoreCodeLen = 20
+	maxAddressLen   = 255
+	maxTaxIDLen     = 30
+	maxLotLen       = 50
+	maxReasonLen    = 255
+	maxShelfLife    = 3650
+)
+
+var (
+	phonePattern = regexp.MustCompile(`^\+?[0-9]{8,15}$`)
+	emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
+func Product(p *domain.Product) error {
+	var v Validator
+	v.Required(p.Name, "name")
+	v.MaxLen(p.Name, maxNameLen, "name")
+	v.Required(p.Type, "type")
+	v.MaxLen(p.Type, maxTypeLen, "type")
+	v.Required(p.Flavor, "flavor")
+	v.MaxLen(p.Flavor, maxFlavorLen, "flavor")
+	v.Check(p.Size.IsValid(), "size", "must be one of Small, Medium, Large")
+	v.Check(p.Price > 0, "price", "must be greater than 0")
+	v.Check(p.Quantity >= 0, "quantity", "must not be negative")
+	v.Date(p.ManufacturingDate, "manufacturing_date", false)
+	return v.Err()
+}
+
+func Batch(req *service.BatchRequest) error {
+	var v Validator
+	batchDates(&v, req)
+	v.Check(req.Quantity > 0, "quantity", "must be greater than 0")
+	return v.Err()
+}
+
+func StockMovement(req *service.MovementRequest) error {
+	var v Validator
+	v.Check(req.Kind == domain.MovementRestock || req.Kind == domain.MovementAdjustment, "kind", "must be one of restock, adjustment")
+	switch {
+	case req.Quantity > 0:
+		// stock added is received as a batch
+		batchDates(&v, &req.BatchRequest)
+	case req.Kind == domain.MovementRestock:
+		v.Check(false, "quantity", "must be greater than 0")
+	default:
+		v.Check(req.Quantity != 0, "quantity", "must not be 0")
+	}
+	if req.Kind == domain.MovementAdjustment {
+		v.Required(req.Reason, "reason")
+	}
+	v.MaxLen(req.Reason, maxReasonLen, "reason")
+	v.Required(req.Actor, "actor")
+	v.MaxLen(req.Actor, maxNameLen, "actor")
+	v.MaxLen(strings.TrimSpace(req.Reference), maxReferenceLen, "reference")
+	return v.Err()
+}
+
+func ShelfLife(req *service.ShelfLifeRequest) error {
+	var v Validator
+	v.Required(req.ProductType, "product_type")
+	v.MaxLen(strings.TrimSpace(req.ProductType), maxTypeLen, "product_type")
+	v.Check(req.Days > 0 && req.Days <= maxShelfLife, "days", "must be between 1 and "+strconv.Itoa(maxShelfLife))
+	return v.Err()
+}
+
+func ReorderLevel(req *service.ReorderLevelRequest) error {
+	var v Validator
+	v.Check(req.ReorderPoint >= 0, "reorder_point", "must not be negative")
+	v.Check(req.ReorderQuantity > 0, "reorder_quantity", "must be greater than 0")
+	return v.Err()
+}
+
+func WriteOff(req *service.WriteOffRequest) error {
+	var v Validator
+	if req.StoreID != nil {
+		v.Check(*req.StoreID > 0, "store_id", "must be a positive id")
+	}
+	if req.ProductID != nil {
+		v.Check(*req.ProductID > 0, "product_id", "must be a positive id")
+	}
+	v.MaxLen(req.Reason, maxReasonLen, "reason")
+	v.Required(req.Actor, "actor")
+	v.MaxLen(req.Actor, maxNameLen, "actor")
+	return v.Err()
+}
+
+func OpenStocktake(req *service.OpenStocktakeRequest) error {
+	var v Validator
+	v.Required(req.Actor, "actor")
+	v.MaxLen(req.Actor, maxNameLen, "actor")
+	return v.Err()
+}
+
+func StocktakeCounts(req *service.StocktakeCountsRequest) error {
+	var v Validator
+	v.Check(len(req.Counts) > 0, "counts", "must contain at least one count")
+	seen := make(map[int64]bool, len(req.Counts))
+	for i, c := range req.Counts {
+		v.Check(c.ProductID > 0, fmt.Sprintf("counts[%d].product_id", i), "is required")
+		v.Check(!seen[c.ProductID], fmt.Sprintf("counts[%d].product_id", i), "is counted twice")
+		v.Check(c.Counted >= 0, fmt.Sprintf("counts[%d].counted", i), "must not be negative")
+		seen[c.ProductID] = true
+	}
+	v.Required(req.Actor, "actor")
+	v.MaxLen(req.Actor, maxNameLen, "actor")
+	return v.Err()
+}
+
+func FinalizeStocktake(req *service.FinalizeStocktakeRequest) error {
+	var v Validator
+	v.Required(req.Actor, "actor")
+	v.MaxLen(req.Actor, maxNameLen, "actor")
+	return v.Err()
+}
+
+func Purchase(req *service.PurchaseRequest) error {
+	var v Validator
+	customerRef(&v, req.CustomerID, req.CustomerName)
+	v.Check(req.ProductID > 0, "product_id", "is required")
+	v.Check(req.Quantity > 0, "quantity", "must be greater than 0")
+	v.MaxLen(req.CouponCode, maxCodeLen, "coupon_code")
+	payments(&v, req.Payments)
+	v.Check(req.ShiftID > 0, "shift_id", "is required")
+	v.Date(req.TransactionDate, "transaction_date", true)
+	return v.Err()
+}
+
+func Checkout(req *service.CheckoutRequest) error {
+	var v Validator
+	customerRef(&v, req.CustomerID, req.CustomerName)
+	v.Check(len(req.Items) > 0, "items", "must contain at least one item")
+	for i, item := range req.Items {
+		v.Check(item.ProductID > 0, fmt.Sprintf("items[%d].product_id", i), "is required")
+		v.Check(item.Quantity > 0, fmt.Sprintf("items[%d].quantity", i), "must be greater than 0")
+	}
+	v.MaxLen(req.CouponCode, maxCodeLen, "coupon_code")
+	payments(&v, req.Payments)
+	v.Check(req.ShiftID > 0, "shift_id", "is required")
+	v.Date(req.TransactionDate, "transaction_date", true)
+	return v.Err()
+}
+
+// Refund checks a refund; a quantity of 0 refunds everything not yet refunded
+func Refund(quantity int, shiftID int64) error {
+	var v Validator
+	v.Check(quantity >= 0, "quantity", "must not be negative")
+	v.Check(shiftID > 0, "shift_id", "is required")
+	return v.Err()
+}
+
+func Redeem(req *service.RedeemRequest) error {
+	var v Validator
+	customerRef(&v, req.CustomerID, req.CustomerName)
+	v.Check(req.ProductID > 0, "product_id", "is required")
+	return v.Err()
+}
+
+func Customer(req *service.CustomerRequest) error {
+	var v Validator
+	v.Required(req.Name, "name")
+	v.MaxLen(req.Name, maxNameLen, "name")
+	if phone := service.NormalizePhone(req.Phone); phone != nil {
+		v.Check(phonePattern.MatchString(*phone), "phone", "must be 8 to 15 digits, optionally starting with +")
+	}
+	if email := service.NormalizeEmail(req.Email); email != nil {
+		v.Check(len(*email) <= maxEmailLen && emailPattern.MatchString(*email), "email", "must be a valid email address")
+	}
+	return v.Err()
+}
+
+func PointsAdjustment(req *service.PointsAdjustmentRequest) error {
+	var v Validator
+	v.Check(req.Points != 0, "points", "must not be 0")
+	v.Required(req.Reason, "reason")
+	return v.Err()
+}
+
+// maxMultiplierPercent caps bonus rules at 10x points to catch typos such as 2000 for 200
+const maxMultiplierPercent = 1000
+
+func EarnRule(req *service.EarnRuleRequest) error {
+	var v Validator
+	v.Required(req.Name, "name")
+	v.MaxLen(req.Name, maxNameLen, "name")
+	if req.ProductType != nil {
+		v.MaxLen(*req.ProductType, maxTypeLen, "product_type")
+	}
+	v.Check(req.SpendPerPoint > 0, "spend_per_point", "must be greater than 0")
+	return v.Err()
+}
+
+func BonusRule(req *service.BonusRuleRequest) error {
+	var v Validator
+	v.Required(req.Name, "name")
+	v.MaxLen(req.Name, maxNameLen, "name")
+	if req.ProductType != nil {
+		v.MaxLen(*req.ProductType, maxTypeLen, "product_type")
+	}
+	v.Check(req.MultiplierPercent > 0 && req.MultiplierPercent <= maxMultiplierPercent,
+		"multiplier_percent", "must be between 1 and "+strconv.Itoa(maxMultiplierPercent))
+	v.Date(req.StartDate, "start_date", false)
+	v.Date(req.EndDate, "end_date", false)
+	// YYYY-MM-DD strings order the same way as the dates
+	v.Check(req.EndDate >= req.StartDate, "end_date", "must not be before start_date")
+	for i, wd := range req.Weekdays {
+		v.Check(wd >= time.Sunday && wd <= time.Saturday, fmt.Sprintf("weekdays[%d]", i), "must be 0 (Sunday) to 6 (Saturday)")
+	}
+	return v.Err()
+}
+
+func RedeemRule(req *service.RedeemRuleRequest) error {
+	var v Validator
+	v.Required(req.Name, "name")
+	v.MaxLen(req.Name, maxNameLen, "name")
+	v.Check((req.ProductID == nil) != (req.ProductSize == nil), "product_id", "exactly one of product_id and product_size is required")
+	if req.ProductID != nil {
+		v.Check(*req.ProductID > 0, "product_id", "must be a positive id")
+	}
+	if req.ProductSize != nil {
+		v.Check(req.ProductSize.IsValid(), "product_size", "must be one of Small, Medium, Large")
+	}
+	v.Check(req.Points > 0, "points", "must be greater than 0")
+	return v.Err()
+}
+
+func TierRule(req *service.TierRuleRequest) error {
+	var v Validator
+	v.Check(req.MinSpend >= 0, "min_spend", "must not be negative")
+	v.Check(req.MultiplierPercent > 0 && req.MultiplierPercent <= maxMultiplierPercent,
+		"multiplier_percent", "must be between 1 and "+strconv.Itoa(maxMultiplierPercent))
+	return v.Err()
+}
+
+func Promotion(req *service.PromotionRequest) error {
+	var v Validator
+	v.Required(req.Name, "name")
+	v.MaxLen(req.Name, maxNameLen, "name")
+	if req.ProductID != nil {
+		v.Check(*req.ProductID > 0, "product_id", "must be a positive id")
+	}
+	if req.ProductType != nil {
+		v.MaxLen(*req.ProductType, maxTypeLen, "product_type")
+	}
+
+	switch req.Kind {
+	case domain.PromotionPercentage:
+		v.Check(req.Percent > 0 && req.Percent <= 100, "percent", "must be between 1 and 100")
+	case domain.PromotionFixed:
+		v.Check(req.Amount > 0, "amount", "must be greater than 0")
+	case domain.PromotionBuyXGetY:
+		v.Check(req.BuyQuantity > 0, "buy_quantity", "must be greater than 0")
+		v.Check(req.FreeQuantity > 0, "free_quantity", "must be greater than 0")
+	case domain.PromotionBundle:
+		v.Check(req.BundleQuantity > 1, "bundle_quantity", "must be at least 2")
+		v.Check(req.BundlePrice > 0, "bundle_price", "must be greater than 0")
+		v.Check(req.ProductType != nil && strings.TrimSpace(*req.ProductType) != "", "product_type", "is required for a bundle")
+		v.Check(req.ProductID == nil, "product_id", "must not be set for a bundle; bundles mix flavors of a type")
+	default:
+		v.Check(false, "kind", "must be one of percentage, fixed, buy_x_get_y, bundle")
+	}
+
+	if req.Code != nil {
+		v.Required(*req.Code, "code")
+		v.MaxLen(*req.Code, maxCodeLen, "code")
+		v.Check(req.Kind != domain.PromotionBundle, "code", "bundles cannot be coupons")
+	}
+	if req.UsageLimit != nil {
+		v.Check(req.Code != nil, "usage_limit", "only applies to coupons")
+		v.Check(*req.UsageLimit > 0, "usage_limit", "must be greater than 0")
+	}
+
+	v.Date(req.StartDate, "start_date", false)
+	v.Date(req.EndDate, "end_date", false)
+	v.Check(req.EndDate >= req.StartDate, "end_date", "must not be before start_date")
+	return v.Err()
+}
+
+func TaxRate(req *service.TaxRateRequest) error {
+	var v Validator
+	v.Required(req.Name, "name")
+	v.MaxLen(req.Name, maxNameLen, "name")
+	if req.ProductType != nil {
+		v.MaxLen(*req.ProductType, maxTypeLen, "product_type")
+	}
+	v.Check(req.BasisPoints >= 0 && req.BasisPoints <= 10000, "basis_points", "must be between 0 and 10000")
+	return v.Err()
+}
+
+func Store(req *service.StoreRequest) error {
+	var v Validator
+	v.Required(req.Code, "code")
+	v.MaxLen(strings.TrimSpace(req.Code), maxStoreCodeLen, "code")
+	v.Required(req.Name, "name")
+	v.MaxLen(req.Name, maxNameLen, "name")
+	v.MaxLen(req.Address, maxAddressLen, "address")
+	v.MaxLen(req.TaxID, maxTaxIDLen, "tax_id")
+	return v.Err()
+}
+
+func OpenShift(req *service.OpenShiftRequest) error {
+	var v Validator
+	v.Required(req.Till, "till")
+	v.MaxLen(req.Till, maxTillLen, "till")
+	v.Required(req.Cashier, "cashier")
+	v.MaxLen(req.Cashier, maxNameLen, "cashier")
+	v.Check(req.OpeningFloat >= 0, "opening_float", "must not be negative")
+	return v.Err()
+}
+
+func CloseShift(req *service.CloseShiftRequest) error {
+	var v Validator
+	v.Check(req.CountedCash >= 0, "counted_cash", "must not be negative")
+	return v.Err()
+}
+
+// batchDates checks the lot and dates of a batch being received
+func batchDates(v *Validator, req *service.BatchRequest) {
+	v.MaxLen(strings.TrimSpace(req.Lot), maxLotLen, "lot")
+	v.Date(req.ManufacturingDate, "manufacturing_date", false)
+	if req.ExpiryDate != nil {
+		v.Date(*req.ExpiryDate, "expiry_date", false)
+		v.Check(*req.ExpiryDate >= req.ManufacturingDate, "expiry_date", "must not be before manufacturing_date")
+	}
+}
+
+// payments checks each tender; whether they cover the total is only known at sale time
+func payments(v *Validator, reqs []service.PaymentRequest) {
+	for i, p := range reqs {
+		field := fmt.Sprintf("payments[%d]", i)
+		v.Check(p.Method.IsValid(), field+".method", "must be one of cash, card, qris, ewallet")
+		v.Check(p.Amount > 0, field+".amount", "must be greater than 0")
+		if p.Method.NeedsReference() {
+			v.Required(p.Reference, field+".reference")
+		}
+		v.MaxLen(p.Reference, maxReferenceLen, field+".reference")
+	}
+}
+
+// customerRef accepts either a registered customer_id or a walk-in customer_name
+func customerRef(v *Validator, id int64, name string) {
+	v.Check(id >= 0, "customer_id", "must be a positive id")
+	if id == 0 {
+		v.Check(strings.TrimSpace(name) != "", "customer_name", "is required when customer_id is not set")
+	}
+	v.MaxLen(name, maxNameLen, "customer_name")
+}
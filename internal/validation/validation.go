@@ -0,0 +1,66 @@
+package validation
+
+import (
+	"bsnack/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError describes why a single input field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors is every field error found in one payload. It unwraps to
+// domain.ErrValidation so the handler maps it to 400.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + " " + fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e Errors) Unwrap() error { return domain.ErrValidation }
+
+// Validator collects field errors instead of stopping at the first one
+type Validator struct {
+	errs Errors
+}
+
+// Check records message against field when ok is false
+func (v *Validator) Check(ok bool, field, message string) {
+	if !ok {
+		v.errs = append(v.errs, FieldError{Field: field, Message: message})
+	}
+}
+
+func (v *Validator) Required(value, field string) {
+	v.Check(strings.TrimSpace(value) != "", field, "is required")
+}
+
+func (v *Validator) MaxLen(value string, max int, field string) {
+	v.Check(len([]rune(value)) <= max, field, "must be at most "+strconv.Itoa(max)+" characters")
+}
+
+// Date accepts an empty value only when optional is true
+func (v *Validator) Date(value, field string, optional bool) {
+	if value == "" {
+		v.Check(optional, field, "is required")
+		return
+	}
+	_, err := time.Parse("2006-01-02", value)
+	v.Check(err == nil, field, "must be a date in YYYY-MM-DD format")
+}
+
+// Err returns nil when every check passed
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
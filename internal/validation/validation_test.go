@@ -0,0 +1,257 @@
+package validation_test
+
+import (
+	"bsnack/internal/domain"
+	"bsnack/internal/service"
+	"bsnack/internal/validation"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+	var errs validation.Errors
+	if !assert.ErrorAs(t, err, &errs) {
+		return nil
+	}
+	names := make([]string, len(errs))
+	for i, fe := range errs {
+		names[i] = fe.Field
+	}
+	return names
+}
+
+func TestProduct_Valid(t *testing.T) {
+	p := &domain.Product{
+		Name:              "Keripik Pangsit",
+		Type:              "Keripik Pangsit",
+		Flavor:            "Jagung Bakar",
+		Size:              domain.SizeSmall,
+		Price:             domain.NewMoney(10000),
+		Quantity:          0,
+		ManufacturingDate: "2025-10-22",
+	}
+	assert.NoError(t, validation.Product(p))
+}
+
+func TestProduct_ReportsAllFieldErrors(t *testing.T) {
+	p := &domain.Product{
+		Name:              "  ",
+		Flavor:            "Balado",
+		Size:              "Jumbo",
+		Price:             domain.NewMoney(-1),
+		Quantity:          -5,
+		ManufacturingDate: "22-10-2025",
+	}
+
+	err := validation.Product(p)
+
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	assert.Equal(t, []string{"name", "type", "size", "price", "quantity", "manufacturing_date"}, fields(t, err))
+}
+
+func TestProduct_MaxLength(t *testing.T) {
+	p := &domain.Product{
+		Name:              "Keripik",
+		Type:              "Keripik",
+		Flavor:            string(make([]rune, 51)),
+		Size:              domain.SizeLarge,
+		Price:             domain.NewMoney(1),
+		ManufacturingDate: "2025-10-22",
+	}
+	assert.Equal(t, []string{"flavor"}, fields(t, validation.Product(p)))
+}
+
+func TestPurchase(t *testing.T) {
+	assert.NoError(t, validation.Purchase(&service.PurchaseRequest{CustomerName: "Budi", ProductID: 1, Quantity: 1, ShiftID: 1}))
+	assert.NoError(t, validation.Purchase(&service.PurchaseRequest{CustomerName: "Budi", ProductID: 1, Quantity: 1, ShiftID: 1, TransactionDate: "2025-10-22"}))
+
+	err := validation.Purchase(&service.PurchaseRequest{Quantity: 0, TransactionDate: "yesterday"})
+	assert.Equal(t, []string{"customer_name", "product_id", "quantity", "shift_id", "transaction_date"}, fields(t, err))
+
+	// a registered customer needs no name
+	assert.NoError(t, validation.Purchase(&service.PurchaseRequest{CustomerID: 7, ProductID: 1, Quantity: 1, ShiftID: 1}))
+}
+
+func TestPurchase_Payments(t *testing.T) {
+	err := validation.Purchase(&service.PurchaseRequest{CustomerID: 7, ProductID: 1, Quantity: 1, ShiftID: 1, Payments: []service.PaymentRequest{
+		{Method: domain.PaymentCash, Amount: domain.NewMoney(50000)},
+		{Method: domain.PaymentQRIS, Amount: domain.NewMoney(10000)},
+		{Method: "cheque", Amount: 0},
+	}})
+	assert.Equal(t, []string{"payments[1].reference", "payments[2].method", "payments[2].amount"}, fields(t, err))
+}
+
+func TestCheckout(t *testing.T) {
+	err := validation.Checkout(&service.CheckoutRequest{
+		CustomerName: "Budi",
+		ShiftID:      1,
+		Items:        []service.OrderLineRequest{{ProductID: 1, Quantity: 1}, {ProductID: 0, Quantity: -1}},
+	})
+	assert.Equal(t, []string{"items[1].product_id", "items[1].quantity"}, fields(t, err))
+
+	err = validation.Checkout(&service.CheckoutRequest{CustomerName: "Budi", ShiftID: 1})
+	assert.Equal(t, []string{"items"}, fields(t, err))
+}
+
+func TestRedeem(t *testing.T) {
+	assert.NoError(t, validation.Redeem(&service.RedeemRequest{CustomerName: "Fery", ProductID: 1}))
+	assert.Equal(t, []string{"customer_name", "product_id"}, fields(t, validation.Redeem(&service.RedeemRequest{})))
+}
+
+func TestErrors_Message(t *testing.T) {
+	errs := validation.Errors{{Field: "name", Message: "is required"}, {Field: "price", Message: "must be greater than 0"}}
+	assert.Equal(t, "name is required; price must be greater than 0", errs.Error())
+}
+
+func TestCustomer(t *testing.T) {
+	phone, email := "0812-3456-7890", "Budi@Example.com"
+	assert.NoError(t, validation.Customer(&service.CustomerRequest{Name: "Budi", Phone: &phone, Email: &email}))
+	assert.NoError(t, validation.Customer(&service.CustomerRequest{Name: "Budi"}))
+
+	badPhone, badEmail := "12ab", "budi@"
+	err := validation.Customer(&service.CustomerRequest{Phone: &badPhone, Email: &badEmail})
+	assert.Equal(t, []string{"name", "phone", "email"}, fields(t, err))
+}
+
+func TestBonusRule(t *testing.T) {
+	valid := &service.BonusRuleRequest{
+		Name: "Double weekend", MultiplierPercent: 200, StartDate: "2025-12-01", EndDate: "2025-12-31",
+		Weekdays: []time.Weekday{time.Saturday, time.Sunday},
+	}
+	assert.NoError(t, validation.BonusRule(valid))
+
+	err := validation.BonusRule(&service.BonusRuleRequest{
+		Name: "Typo", MultiplierPercent: 2000, StartDate: "2025-12-31", EndDate: "2025-12-01",
+		Weekdays: []time.Weekday{7},
+	})
+	assert.Equal(t, []string{"multiplier_percent", "end_date", "weekdays[0]"}, fields(t, err))
+}
+
+func TestRedeemRule(t *testing.T) {
+	size := domain.SizeSmall
+	productID := int64(3)
+	assert.NoError(t, validation.RedeemRule(&service.RedeemRuleRequest{Name: "Small", ProductSize: &size, Points: 200}))
+
+	err := validation.RedeemRule(&service.RedeemRuleRequest{Name: "Both", ProductID: &productID, ProductSize: &size, Points: 0})
+	assert.Equal(t, []string{"product_id", "points"}, fields(t, err))
+}
+
+func TestTierRule(t *testing.T) {
+	assert.NoError(t, validation.TierRule(&service.TierRuleRequest{MinSpend: domain.NewMoney(1_000_000), MultiplierPercent: 125}))
+
+	err := validation.TierRule(&service.TierRuleRequest{MinSpend: -1, MultiplierPercent: 0})
+	assert.Equal(t, []string{"min_spend", "multiplier_percent"}, fields(t, err))
+}
+
+func TestPromotion(t *testing.T) {
+	productType := "Keripik Pangsit"
+	assert.NoError(t, validation.Promotion(&service.PromotionRequest{Name: "3 for 25k", Kind: domain.PromotionBundle,
+		ProductType: &productType, BundleQuantity: 3, BundlePrice: domain.NewMoney(25000),
+		StartDate: "2025-12-01", EndDate: "2025-12-31"}))
+
+	code := "HEMAT"
+	limit := 0
+	err := validation.Promotion(&service.PromotionRequest{Name: "Bundle coupon", Kind: domain.PromotionBundle,
+		BundleQuantity: 1, Code: &code, UsageLimit: &limit, StartDate: "2025-12-31", EndDate: "2025-12-01"})
+	assert.Equal(t, []string{"bundle_quantity", "bundle_price", "product_type", "code", "usage_limit", "end_date"}, fields(t, err))
+
+	err = validation.Promotion(&service.PromotionRequest{Name: "?", Kind: "mystery", StartDate: "2025-12-01", EndDate: "2025-12-01"})
+	assert.Equal(t, []string{"kind"}, fields(t, err))
+}
+
+func TestTaxRate(t *testing.T) {
+	assert.NoError(t, validation.TaxRate(&service.TaxRateRequest{Name: "PPN 11%", BasisPoints: 1100, Inclusive: true}))
+
+	err := validation.TaxRate(&service.TaxRateRequest{BasisPoints: 10001})
+	assert.Equal(t, []string{"name", "basis_points"}, fields(t, err))
+}
+
+func TestOpenShift(t *testing.T) {
+	assert.NoError(t, validation.OpenShift(&service.OpenShiftRequest{Till: "T1", Cashier: "Sari", OpeningFloat: domain.NewMoney(200000)}))
+
+	err := validation.OpenShift(&service.OpenShiftRequest{Till: " ", OpeningFloat: -1})
+	assert.Equal(t, []string{"till", "cashier", "opening_float"}, fields(t, err))
+
+	assert.Equal(t, []string{"counted_cash"}, fields(t, validation.CloseShift(&service.CloseShiftRequest{CountedCash: -1})))
+}
+
+func TestPurchase_ShiftRequired(t *testing.T) {
+	err := validation.Purchase(&service.PurchaseRequest{CustomerID: 7, ProductID: 1, Quantity: 1})
+	assert.Equal(t, []string{"shift_id"}, fields(t, err))
+}
+
+func TestRefund(t *testing.T) {
+	assert.NoError(t, validation.Refund(0, 1))
+	assert.Equal(t, []string{"quantity", "shift_id"}, fields(t, validation.Refund(-1, 0)))
+}
+
+func TestStore(t *testing.T) {
+	assert.NoError(t, validation.Store(&service.StoreRequest{Code: "bdg-01", Name: "BSNACK Bandung", TaxID: "01.234.567.8-901.000"}))
+
+	err := validation.Store(&service.StoreRequest{Code: strings.Repeat("X", 21), Name: " "})
+	assert.Equal(t, []string{"code", "name"}, fields(t, err))
+}
+
+func TestBatch(t *testing.T) {
+	expiry := "2026-06-01"
+	assert.NoError(t, validation.Batch(&service.BatchRequest{Lot: "BP-0412", ManufacturingDate: "2025-12-01", ExpiryDate: &expiry, Quantity: 24}))
+
+	early := "2025-11-30"
+	err := validation.Batch(&service.BatchRequest{Lot: strings.Repeat("L", 51), ManufacturingDate: "2025-12-01", ExpiryDate: &early})
+	assert.Equal(t, []string{"lot", "expiry_date", "quantity"}, fields(t, err))
+}
+
+func TestShelfLife(t *testing.T) {
+	assert.NoError(t, validation.ShelfLife(&service.ShelfLifeRequest{ProductType: "Keripik Pangsit", Days: 180}))
+
+	err := validation.ShelfLife(&service.ShelfLifeRequest{ProductType: " ", Days: 0})
+	assert.Equal(t, []string{"product_type", "days"}, fields(t, err))
+}
+
+func TestWriteOff(t *testing.T) {
+	assert.NoError(t, validation.WriteOff(&service.WriteOffRequest{Actor: "Sari"}))
+
+	zero := int64(0)
+	err := validation.WriteOff(&service.WriteOffRequest{StoreID: &zero, Reason: strings.Repeat("r", 256)})
+	assert.Equal(t, []string{"store_id", "reason", "actor"}, fields(t, err))
+}
+
+func TestStockMovement(t *testing.T) {
+	assert.NoError(t, validation.StockMovement(&service.MovementRequest{Kind: domain.MovementRestock, Actor: "Sari",
+		BatchRequest: service.BatchRequest{ManufacturingDate: "2025-12-01", Quantity: 24}}))
+	assert.NoError(t, validation.StockMovement(&service.MovementRequest{Kind: domain.MovementAdjustment, Reason: "damaged", Actor: "Sari",
+		BatchRequest: service.BatchRequest{Quantity: -2}}))
+
+	// stock added needs the dates of its batch
+	err := validation.StockMovement(&service.MovementRequest{Kind: domain.MovementAdjustment, Actor: "Sari",
+		BatchRequest: service.BatchRequest{Quantity: 2}})
+	assert.Equal(t, []string{"manufacturing_date", "reason"}, fields(t, err))
+
+	err = validation.StockMovement(&service.MovementRequest{Kind: domain.MovementRestock, BatchRequest: service.BatchRequest{Quantity: -2}})
+	assert.Equal(t, []string{"quantity", "actor"}, fields(t, err))
+
+	err = validation.StockMovement(&service.MovementRequest{Kind: domain.MovementSale, Reason: "?", Actor: "Sari"})
+	assert.Equal(t, []string{"kind", "quantity"}, fields(t, err))
+}
+
+func TestStocktakeCounts(t *testing.T) {
+	assert.NoError(t, validation.StocktakeCounts(&service.StocktakeCountsRequest{
+		Counts: []service.StocktakeCount{{ProductID: 1, Counted: 12}, {ProductID: 2, Counted: 0}}, Actor: "Sari"}))
+
+	err := validation.StocktakeCounts(&service.StocktakeCountsRequest{
+		Counts: []service.StocktakeCount{{ProductID: 1, Counted: 12}, {ProductID: 1, Counted: -1}}})
+	assert.Equal(t, []string{"counts[1].product_id", "counts[1].counted", "actor"}, fields(t, err))
+
+	assert.Equal(t, []string{"counts", "actor"}, fields(t, validation.StocktakeCounts(&service.StocktakeCountsRequest{})))
+}
+
+func TestReorderLevel(t *testing.T) {
+	assert.NoError(t, validation.ReorderLevel(&service.ReorderLevelRequest{ReorderPoint: 0, ReorderQuantity: 24}))
+
+	err := validation.ReorderLevel(&service.ReorderLevelRequest{ReorderPoint: -1})
+	assert.Equal(t, []string{"reorder_point", "reorder_quantity"}, fields(t, err))
+}
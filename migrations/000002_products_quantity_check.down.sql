@@ -0,0 +1 @@
+ALTER TABLE products DROP CONSTRAINT IF EXISTS chk_products_quantity_non_negative;
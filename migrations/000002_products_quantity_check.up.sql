@@ -0,0 +1,2 @@
+-- Guard against negative stock even if an UPDATE bypasses the repository
+ALTER TABLE products ADD CONSTRAINT chk_products_quantity_non_negative CHECK (quantity >= 0);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_order_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS order_id;
+DROP TABLE IF EXISTS orders;
@@ -0,0 +1,14 @@
+-- Order header; each line is stored as a transactions row pointing back here
+CREATE TABLE orders (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    customer_id INT NOT NULL REFERENCES customers(id),
+    total_quantity INT NOT NULL,
+    total_price NUMERIC(15, 2) NOT NULL,
+    points_earned INT NOT NULL DEFAULT 0,
+    order_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE transactions ADD COLUMN order_id UUID REFERENCES orders(id);
+
+CREATE INDEX idx_orders_date ON orders(order_date);
+CREATE INDEX idx_transactions_order_id ON transactions(order_id);
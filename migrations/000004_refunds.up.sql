@@ -0,0 +1,8 @@
+-- Refunds are stored as negative transactions linked to the sale they reverse
+ALTER TABLE transactions ADD COLUMN refund_of UUID REFERENCES transactions(id);
+ALTER TABLE transactions ADD COLUMN points_earned INT NOT NULL DEFAULT 0;
+
+-- Backfill points for standalone purchases; order lines keep their points on the order header
+UPDATE transactions SET points_earned = FLOOR(total_price / 1000) WHERE order_id IS NULL;
+
+CREATE INDEX idx_transactions_refund_of ON transactions(refund_of);
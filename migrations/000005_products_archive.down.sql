@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_products_active;
+ALTER TABLE products DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE products DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,5 @@
+-- Soft delete: archived products are hidden from the catalog and cannot be sold
+ALTER TABLE products ADD COLUMN archived_at TIMESTAMP;
+ALTER TABLE products ADD COLUMN updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX idx_products_active ON products(id) WHERE archived_at IS NULL;
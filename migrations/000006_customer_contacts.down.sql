@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_transactions_customer_id;
+DROP INDEX IF EXISTS idx_orders_customer_id;
+DROP INDEX IF EXISTS ux_customers_email;
+DROP INDEX IF EXISTS ux_customers_phone;
+
+ALTER TABLE customers DROP COLUMN IF EXISTS merged_into;
+ALTER TABLE customers DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE customers DROP COLUMN IF EXISTS email;
+ALTER TABLE customers DROP COLUMN IF EXISTS phone;
+
+-- fails if duplicate names were created while the constraint was absent
+ALTER TABLE customers ADD CONSTRAINT customers_name_key UNIQUE (name);
@@ -0,0 +1,13 @@
+-- Customers are identified by id; names may repeat
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS customers_name_key;
+
+ALTER TABLE customers ADD COLUMN phone VARCHAR(20);
+ALTER TABLE customers ADD COLUMN email VARCHAR(254);
+ALTER TABLE customers ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE customers ADD COLUMN merged_into INT REFERENCES customers(id);
+
+-- phone and email are unique lookup keys among active customers
+CREATE UNIQUE INDEX ux_customers_phone ON customers(phone) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX ux_customers_email ON customers(email) WHERE deleted_at IS NULL;
+CREATE INDEX idx_orders_customer_id ON orders(customer_id);
+CREATE INDEX idx_transactions_customer_id ON transactions(customer_id);
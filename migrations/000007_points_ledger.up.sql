@@ -0,0 +1,19 @@
+-- Every change to customers.points is recorded here; customers.points is the running balance
+CREATE TABLE points_ledger (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id INT NOT NULL REFERENCES customers(id),
+    entry_type VARCHAR(20) NOT NULL CHECK (entry_type IN ('earn', 'redeem', 'refund', 'adjustment')),
+    points INT NOT NULL,
+    transaction_id UUID REFERENCES transactions(id),
+    order_id UUID REFERENCES orders(id),
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_points_ledger_customer ON points_ledger(customer_id, created_at);
+
+-- Open the ledger with the balances accumulated before it existed
+INSERT INTO points_ledger (customer_id, entry_type, points, reason)
+SELECT id, 'adjustment', points, 'opening balance'
+FROM customers
+WHERE points <> 0;
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_points_ledger_open_lots;
+
+-- keep expired points deducted so the ledger still matches customers.points
+UPDATE points_ledger SET entry_type = 'adjustment', reason = 'points expired' WHERE entry_type = 'expire';
+
+ALTER TABLE points_ledger DROP CONSTRAINT points_ledger_entry_type_check;
+ALTER TABLE points_ledger ADD CONSTRAINT points_ledger_entry_type_check
+    CHECK (entry_type IN ('earn', 'redeem', 'refund', 'adjustment'));
+
+ALTER TABLE points_ledger
+    DROP COLUMN expires_at,
+    DROP COLUMN remaining;
@@ -0,0 +1,25 @@
+-- Positive entries become lots that negative entries consume oldest-expiry first
+ALTER TABLE points_ledger
+    ADD COLUMN remaining INT NOT NULL DEFAULT 0,
+    ADD COLUMN expires_at TIMESTAMP;
+
+ALTER TABLE points_ledger DROP CONSTRAINT points_ledger_entry_type_check;
+ALTER TABLE points_ledger ADD CONSTRAINT points_ledger_entry_type_check
+    CHECK (entry_type IN ('earn', 'redeem', 'refund', 'adjustment', 'expire'));
+
+-- Replay what has already been spent against the existing lots in order.
+-- Points earned before this migration keep no expiry date.
+UPDATE points_ledger l
+SET remaining = GREATEST(0, LEAST(l.points, lots.running - lots.spent))
+FROM (
+    SELECT p.id,
+           SUM(p.points) OVER (PARTITION BY p.customer_id ORDER BY p.created_at, p.id) AS running,
+           COALESCE((SELECT -SUM(n.points) FROM points_ledger n
+                     WHERE n.customer_id = p.customer_id AND n.points < 0), 0) AS spent
+    FROM points_ledger p
+    WHERE p.points > 0
+) lots
+WHERE l.id = lots.id;
+
+CREATE INDEX idx_points_ledger_open_lots ON points_ledger(customer_id, expires_at)
+    WHERE remaining > 0;
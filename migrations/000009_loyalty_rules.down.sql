@@ -0,0 +1,10 @@
+ALTER TABLE points_ledger DROP COLUMN redeem_rule_id;
+
+ALTER TABLE transactions
+    DROP COLUMN bonus_rule_id,
+    DROP COLUMN earn_rule_id,
+    DROP COLUMN point_units;
+
+DROP TABLE IF EXISTS loyalty_redeem_rules;
+DROP TABLE IF EXISTS loyalty_bonus_rules;
+DROP TABLE IF EXISTS loyalty_earn_rules;
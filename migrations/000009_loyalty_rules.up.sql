@@ -0,0 +1,62 @@
+-- Spend per point; a NULL product_type is the default for types without their own rule
+CREATE TABLE loyalty_earn_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    product_type VARCHAR(100),
+    spend_per_point NUMERIC(15, 2) NOT NULL CHECK (spend_per_point > 0),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uq_loyalty_earn_rules_type ON loyalty_earn_rules (COALESCE(product_type, '')) WHERE active;
+
+-- weekdays is a bitmask with bit 0 = Sunday; 0 means every day
+CREATE TABLE loyalty_bonus_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    product_type VARCHAR(100),
+    multiplier_percent INT NOT NULL CHECK (multiplier_percent > 0),
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    weekdays SMALLINT NOT NULL DEFAULT 0 CHECK (weekdays BETWEEN 0 AND 127),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date >= start_date)
+);
+
+-- A redemption cost is set either for one product or for a size
+CREATE TABLE loyalty_redeem_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    product_id INT REFERENCES products(id),
+    product_size VARCHAR(20),
+    points INT NOT NULL CHECK (points > 0),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((product_id IS NULL) <> (product_size IS NULL))
+);
+
+CREATE UNIQUE INDEX uq_loyalty_redeem_rules_product ON loyalty_redeem_rules (product_id) WHERE active AND product_id IS NOT NULL;
+CREATE UNIQUE INDEX uq_loyalty_redeem_rules_size ON loyalty_redeem_rules (product_size) WHERE active AND product_size IS NOT NULL;
+
+-- The values that used to be hardcoded
+INSERT INTO loyalty_earn_rules (name, spend_per_point) VALUES ('Standard', 1000);
+INSERT INTO loyalty_redeem_rules (name, product_size, points) VALUES
+    ('Small snacks', 'Small', 200),
+    ('Medium snacks', 'Medium', 300),
+    ('Large snacks', 'Large', 500);
+
+-- Sales record what they accrued (in millionths of a point) and under which rules
+ALTER TABLE transactions
+    ADD COLUMN point_units BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN earn_rule_id INT REFERENCES loyalty_earn_rules(id),
+    ADD COLUMN bonus_rule_id INT REFERENCES loyalty_bonus_rules(id);
+
+-- 1 point per Rp 1,000 is 1,000 units per rupiah
+UPDATE transactions SET point_units = TRUNC(total_price * 1000),
+    earn_rule_id = (SELECT id FROM loyalty_earn_rules WHERE product_type IS NULL);
+
+ALTER TABLE points_ledger ADD COLUMN redeem_rule_id INT REFERENCES loyalty_redeem_rules(id);
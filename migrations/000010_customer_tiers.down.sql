@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS customer_tier_events;
+
+ALTER TABLE transactions DROP COLUMN customer_tier;
+ALTER TABLE customers DROP COLUMN tier;
+
+DROP TABLE IF EXISTS loyalty_tiers;
@@ -0,0 +1,28 @@
+-- Tiers are earned by net spend over the last 12 months and multiply earned points
+CREATE TABLE loyalty_tiers (
+    tier VARCHAR(20) PRIMARY KEY CHECK (tier IN ('Bronze', 'Silver', 'Gold')),
+    min_spend NUMERIC(15, 2) NOT NULL CHECK (min_spend >= 0),
+    multiplier_percent INT NOT NULL CHECK (multiplier_percent > 0),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO loyalty_tiers (tier, min_spend, multiplier_percent) VALUES
+    ('Bronze', 0, 100),
+    ('Silver', 1000000, 125),
+    ('Gold', 5000000, 150);
+
+ALTER TABLE customers ADD COLUMN tier VARCHAR(20) NOT NULL DEFAULT 'Bronze' REFERENCES loyalty_tiers(tier);
+
+-- The tier a sale was earned at
+ALTER TABLE transactions ADD COLUMN customer_tier VARCHAR(20);
+
+CREATE TABLE customer_tier_events (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id INT NOT NULL REFERENCES customers(id),
+    from_tier VARCHAR(20) NOT NULL,
+    to_tier VARCHAR(20) NOT NULL,
+    rolling_spend NUMERIC(15, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_customer_tier_events_customer ON customer_tier_events(customer_id, created_at);
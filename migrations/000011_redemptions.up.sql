@@ -0,0 +1,15 @@
+-- One row per product handed out for points. Redemptions made before this table
+-- existed only left a ledger entry, which does not say which product was taken.
+CREATE TABLE redemptions (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id INT NOT NULL REFERENCES customers(id),
+    product_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    points_spent INT NOT NULL CHECK (points_spent > 0),
+    redeem_rule_id INT REFERENCES loyalty_redeem_rules(id),
+    points_entry_id BIGINT NOT NULL REFERENCES points_ledger(id),
+    redeemed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_redemptions_redeemed_at ON redemptions(redeemed_at);
+CREATE INDEX idx_redemptions_customer_id ON redemptions(customer_id);
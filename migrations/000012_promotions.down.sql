@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS transaction_discounts;
+
+ALTER TABLE orders
+    DROP COLUMN discount_total,
+    DROP COLUMN subtotal;
+
+ALTER TABLE transactions
+    DROP COLUMN discount_total,
+    DROP COLUMN subtotal;
+
+DROP TABLE IF EXISTS promotions;
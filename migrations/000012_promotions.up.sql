@@ -0,0 +1,52 @@
+-- Discounts applied at checkout. A promotion with a code is a coupon and only
+-- applies when the code is given.
+CREATE TABLE promotions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('percentage', 'fixed', 'buy_x_get_y', 'bundle')),
+    product_id INT REFERENCES products(id),
+    product_type VARCHAR(100),
+    percent INT NOT NULL DEFAULT 0 CHECK (percent BETWEEN 0 AND 100),
+    amount NUMERIC(15, 2) NOT NULL DEFAULT 0 CHECK (amount >= 0),
+    buy_quantity INT NOT NULL DEFAULT 0 CHECK (buy_quantity >= 0),
+    free_quantity INT NOT NULL DEFAULT 0 CHECK (free_quantity >= 0),
+    bundle_quantity INT NOT NULL DEFAULT 0 CHECK (bundle_quantity >= 0),
+    bundle_price NUMERIC(15, 2) NOT NULL DEFAULT 0 CHECK (bundle_price >= 0),
+    code VARCHAR(50),
+    usage_limit INT CHECK (usage_limit > 0),
+    usage_count INT NOT NULL DEFAULT 0,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date >= start_date)
+);
+
+CREATE UNIQUE INDEX uq_promotions_code ON promotions (code) WHERE active AND code IS NOT NULL;
+
+-- total_price stays what the customer paid: subtotal - discount_total
+ALTER TABLE transactions
+    ADD COLUMN subtotal NUMERIC(15, 2),
+    ADD COLUMN discount_total NUMERIC(15, 2) NOT NULL DEFAULT 0;
+UPDATE transactions SET subtotal = total_price;
+ALTER TABLE transactions ALTER COLUMN subtotal SET NOT NULL;
+
+ALTER TABLE orders
+    ADD COLUMN subtotal NUMERIC(15, 2),
+    ADD COLUMN discount_total NUMERIC(15, 2) NOT NULL DEFAULT 0;
+UPDATE orders SET subtotal = total_price;
+ALTER TABLE orders ALTER COLUMN subtotal SET NOT NULL;
+
+-- The breakdown of transactions.discount_total, copied so later edits to a promotion do not rewrite history
+CREATE TABLE transaction_discounts (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    promotion_id INT NOT NULL REFERENCES promotions(id),
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    code VARCHAR(50),
+    amount NUMERIC(15, 2) NOT NULL CHECK (amount > 0)
+);
+
+CREATE INDEX idx_transaction_discounts_transaction ON transaction_discounts(transaction_id);
@@ -0,0 +1,12 @@
+ALTER TABLE orders
+    DROP COLUMN tax_amount,
+    DROP COLUMN net_amount;
+
+ALTER TABLE transactions
+    DROP COLUMN tax_inclusive,
+    DROP COLUMN tax_basis_points,
+    DROP COLUMN tax_rate_id,
+    DROP COLUMN net_amount,
+    DROP COLUMN tax_amount;
+
+DROP TABLE IF EXISTS tax_rates;
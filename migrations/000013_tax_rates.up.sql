@@ -0,0 +1,33 @@
+-- PPN per product type; a NULL product_type is the default for types without their own rate
+CREATE TABLE tax_rates (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    product_type VARCHAR(100),
+    basis_points INT NOT NULL CHECK (basis_points BETWEEN 0 AND 10000),
+    inclusive BOOLEAN NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uq_tax_rates_type ON tax_rates (COALESCE(product_type, '')) WHERE active;
+
+-- Shelf prices already include PPN, so totals are unchanged
+INSERT INTO tax_rates (name, basis_points, inclusive) VALUES ('PPN 11%', 1100, TRUE);
+
+-- total_price is the gross amount paid: net_amount + tax_amount. Sales made before
+-- tax was tracked are recorded as untaxed.
+ALTER TABLE transactions
+    ADD COLUMN net_amount NUMERIC(15, 2),
+    ADD COLUMN tax_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN tax_rate_id INT REFERENCES tax_rates(id),
+    ADD COLUMN tax_basis_points INT NOT NULL DEFAULT 0,
+    ADD COLUMN tax_inclusive BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE transactions SET net_amount = total_price;
+ALTER TABLE transactions ALTER COLUMN net_amount SET NOT NULL;
+
+ALTER TABLE orders
+    ADD COLUMN net_amount NUMERIC(15, 2),
+    ADD COLUMN tax_amount NUMERIC(15, 2) NOT NULL DEFAULT 0;
+UPDATE orders SET net_amount = total_price;
+ALTER TABLE orders ALTER COLUMN net_amount SET NOT NULL;
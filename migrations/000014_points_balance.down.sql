@@ -0,0 +1,2 @@
+ALTER TABLE orders DROP COLUMN points_balance;
+ALTER TABLE transactions DROP COLUMN points_balance;
@@ -0,0 +1,4 @@
+-- The customer's points balance right after the sale, printed on receipts.
+-- Unknown for sales made before it was recorded.
+ALTER TABLE transactions ADD COLUMN points_balance INT;
+ALTER TABLE orders ADD COLUMN points_balance INT;
@@ -0,0 +1,23 @@
+-- Each tender against a purchase (transaction_id) or an order (order_id)
+CREATE TABLE payments (
+    id SERIAL PRIMARY KEY,
+    transaction_id UUID REFERENCES transactions(id),
+    order_id UUID REFERENCES orders(id),
+    method VARCHAR(20) NOT NULL,
+    amount NUMERIC(15, 2) NOT NULL,
+    tendered NUMERIC(15, 2) NOT NULL,
+    change_given NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    reference VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((transaction_id IS NULL) <> (order_id IS NULL))
+);
+
+CREATE INDEX idx_payments_transaction ON payments(transaction_id);
+CREATE INDEX idx_payments_order ON payments(order_id);
+
+-- Sales made before payments were recorded are taken as exact cash
+INSERT INTO payments (transaction_id, method, amount, tendered)
+SELECT id, 'cash', total_price, total_price FROM transactions WHERE order_id IS NULL OR refund_of IS NOT NULL;
+
+INSERT INTO payments (order_id, method, amount, tendered)
+SELECT id, 'cash', total_price, total_price FROM orders;
@@ -0,0 +1,4 @@
+ALTER TABLE orders DROP COLUMN shift_id;
+ALTER TABLE transactions DROP COLUMN shift_id;
+
+DROP TABLE IF EXISTS shifts;
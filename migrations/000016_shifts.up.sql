@@ -0,0 +1,19 @@
+-- A cashier's session on a till; a till has at most one open shift
+CREATE TABLE shifts (
+    id SERIAL PRIMARY KEY,
+    till VARCHAR(50) NOT NULL,
+    cashier VARCHAR(100) NOT NULL,
+    opening_float NUMERIC(15, 2) NOT NULL,
+    opened_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP,
+    expected_cash NUMERIC(15, 2),
+    counted_cash NUMERIC(15, 2)
+);
+
+CREATE UNIQUE INDEX uq_shifts_open_till ON shifts(till) WHERE closed_at IS NULL;
+
+ALTER TABLE transactions ADD COLUMN shift_id INT REFERENCES shifts(id);
+ALTER TABLE orders ADD COLUMN shift_id INT REFERENCES shifts(id);
+
+CREATE INDEX idx_transactions_shift ON transactions(shift_id);
+CREATE INDEX idx_orders_shift ON orders(shift_id);
@@ -0,0 +1,21 @@
+DROP INDEX IF EXISTS idx_redemptions_store;
+DROP INDEX IF EXISTS idx_orders_store;
+DROP INDEX IF EXISTS idx_transactions_store_date;
+
+DROP INDEX uq_shifts_open_till;
+CREATE UNIQUE INDEX uq_shifts_open_till ON shifts(till) WHERE closed_at IS NULL;
+
+ALTER TABLE shifts DROP COLUMN store_id;
+ALTER TABLE redemptions DROP COLUMN store_id;
+ALTER TABLE orders DROP COLUMN store_id;
+ALTER TABLE transactions DROP COLUMN store_id;
+
+-- stock from every store is folded back into the product row
+ALTER TABLE products ADD COLUMN quantity INT NOT NULL DEFAULT 0;
+UPDATE products p SET quantity = s.total
+FROM (SELECT product_id, SUM(quantity) AS total FROM store_stock GROUP BY product_id) s
+WHERE s.product_id = p.id;
+ALTER TABLE products ADD CONSTRAINT chk_products_quantity_non_negative CHECK (quantity >= 0);
+
+DROP TABLE IF EXISTS store_stock;
+DROP TABLE IF EXISTS stores;
@@ -0,0 +1,43 @@
+-- Outlets run on one deployment. Store 1 is the main store: it takes over the stock
+-- and sales recorded before stores existed, and sales sent without a store.
+CREATE TABLE stores (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    address VARCHAR(255) NOT NULL DEFAULT '',
+    tax_id VARCHAR(30) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO stores (id, code, name) VALUES (1, 'MAIN', 'Main Store');
+SELECT setval('stores_id_seq', 1);
+
+-- Stock moves from the product row to one row per store and product
+CREATE TABLE store_stock (
+    store_id INT NOT NULL REFERENCES stores(id),
+    product_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    PRIMARY KEY (store_id, product_id)
+);
+
+INSERT INTO store_stock (store_id, product_id, quantity) SELECT 1, id, quantity FROM products;
+ALTER TABLE products DROP COLUMN quantity;
+
+ALTER TABLE transactions ADD COLUMN store_id INT NOT NULL DEFAULT 1 REFERENCES stores(id);
+ALTER TABLE transactions ALTER COLUMN store_id DROP DEFAULT;
+ALTER TABLE orders ADD COLUMN store_id INT NOT NULL DEFAULT 1 REFERENCES stores(id);
+ALTER TABLE orders ALTER COLUMN store_id DROP DEFAULT;
+ALTER TABLE redemptions ADD COLUMN store_id INT NOT NULL DEFAULT 1 REFERENCES stores(id);
+ALTER TABLE redemptions ALTER COLUMN store_id DROP DEFAULT;
+ALTER TABLE shifts ADD COLUMN store_id INT NOT NULL DEFAULT 1 REFERENCES stores(id);
+ALTER TABLE shifts ALTER COLUMN store_id DROP DEFAULT;
+
+-- till names are only unique within a store
+DROP INDEX uq_shifts_open_till;
+CREATE UNIQUE INDEX uq_shifts_open_till ON shifts(store_id, till) WHERE closed_at IS NULL;
+
+CREATE INDEX idx_transactions_store_date ON transactions(store_id, transaction_date);
+CREATE INDEX idx_orders_store ON orders(store_id);
+CREATE INDEX idx_redemptions_store ON redemptions(store_id);
@@ -0,0 +1,21 @@
+-- each store's batches are folded back into one stock row, and the product takes the
+-- manufacturing date of its newest batch
+CREATE TABLE store_stock (
+    store_id INT NOT NULL REFERENCES stores(id),
+    product_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    PRIMARY KEY (store_id, product_id)
+);
+
+INSERT INTO store_stock (store_id, product_id, quantity)
+SELECT store_id, product_id, SUM(quantity) FROM stock_batches GROUP BY store_id, product_id;
+
+ALTER TABLE products ADD COLUMN manufacturing_date DATE;
+UPDATE products p SET manufacturing_date = b.newest
+FROM (SELECT product_id, MAX(manufacturing_date) AS newest FROM stock_batches GROUP BY product_id) b
+WHERE b.product_id = p.id;
+UPDATE products SET manufacturing_date = created_at::date WHERE manufacturing_date IS NULL;
+ALTER TABLE products ALTER COLUMN manufacturing_date SET NOT NULL;
+
+DROP TABLE IF EXISTS batch_allocations;
+DROP TABLE IF EXISTS stock_batches;
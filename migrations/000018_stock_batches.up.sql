@@ -0,0 +1,53 @@
+-- A product is now a catalog item; its stock is held in batches, one per production
+-- run received at a store. Sales take from the batch that expires first, then the oldest.
+CREATE TABLE stock_batches (
+    id SERIAL PRIMARY KEY,
+    store_id INT NOT NULL REFERENCES stores(id),
+    product_id INT NOT NULL REFERENCES products(id),
+    lot VARCHAR(50) NOT NULL,
+    manufacturing_date DATE NOT NULL,
+    expiry_date DATE,
+    quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (store_id, product_id, lot),
+    CHECK (expiry_date >= manufacturing_date)
+);
+
+CREATE INDEX idx_stock_batches_product ON stock_batches(product_id, store_id);
+
+-- the stock each store held becomes one batch made on the product's manufacturing date
+INSERT INTO stock_batches (store_id, product_id, lot, manufacturing_date, quantity)
+SELECT s.store_id, s.product_id, TO_CHAR(p.manufacturing_date, 'YYYYMMDD'), p.manufacturing_date, s.quantity
+FROM store_stock s
+JOIN products p ON s.product_id = p.id;
+
+-- The batches a sale, refund or redemption took stock from or returned it to. Refund
+-- rows carry negative quantities like the refund transaction itself.
+CREATE TABLE batch_allocations (
+    id BIGSERIAL PRIMARY KEY,
+    batch_id INT NOT NULL REFERENCES stock_batches(id),
+    transaction_id UUID REFERENCES transactions(id),
+    redemption_id BIGINT REFERENCES redemptions(id),
+    quantity INT NOT NULL CHECK (quantity <> 0),
+    CHECK ((transaction_id IS NULL) <> (redemption_id IS NULL))
+);
+
+CREATE INDEX idx_batch_allocations_batch ON batch_allocations(batch_id);
+CREATE INDEX idx_batch_allocations_transaction ON batch_allocations(transaction_id);
+CREATE INDEX idx_batch_allocations_redemption ON batch_allocations(redemption_id);
+
+-- earlier sales are traced to the batch their store's stock went into, so refunds of
+-- them know where to put stock back
+INSERT INTO batch_allocations (batch_id, transaction_id, quantity)
+SELECT b.id, t.id, t.quantity
+FROM transactions t
+JOIN stock_batches b ON b.store_id = t.store_id AND b.product_id = t.product_id
+WHERE t.quantity <> 0;
+
+INSERT INTO batch_allocations (batch_id, redemption_id, quantity)
+SELECT b.id, r.id, r.quantity
+FROM redemptions r
+JOIN stock_batches b ON b.store_id = r.store_id AND b.product_id = r.product_id;
+
+DROP TABLE store_stock;
+ALTER TABLE products DROP COLUMN manufacturing_date;
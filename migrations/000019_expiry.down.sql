@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS write_offs;
+DROP TABLE IF EXISTS shelf_lives;
@@ -0,0 +1,24 @@
+-- How long snacks of a product type keep. A batch received without an expiry date
+-- expires its type's shelf life after it was made.
+CREATE TABLE shelf_lives (
+    product_type VARCHAR(100) PRIMARY KEY,
+    days INT NOT NULL CHECK (days > 0),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Expired stock taken off the shelf. The batch's lot and expiry date are copied so the
+-- record stands even if the shelf life is changed later.
+CREATE TABLE write_offs (
+    id BIGSERIAL PRIMARY KEY,
+    batch_id INT NOT NULL REFERENCES stock_batches(id),
+    store_id INT NOT NULL REFERENCES stores(id),
+    product_id INT NOT NULL REFERENCES products(id),
+    lot VARCHAR(50) NOT NULL,
+    expiry_date DATE NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    reason VARCHAR(255) NOT NULL,
+    actor VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_write_offs_store_created ON write_offs(store_id, created_at);
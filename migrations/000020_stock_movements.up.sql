@@ -0,0 +1,21 @@
+-- Every change to the stock of a batch. The movements of a product at a store add up
+-- to the stock it holds there.
+CREATE TABLE stock_movements (
+    id BIGSERIAL PRIMARY KEY,
+    batch_id INT NOT NULL REFERENCES stock_batches(id),
+    store_id INT NOT NULL REFERENCES stores(id),
+    product_id INT NOT NULL REFERENCES products(id),
+    kind VARCHAR(20) NOT NULL
+        CHECK (kind IN ('opening', 'sale', 'redemption', 'refund', 'restock', 'adjustment', 'write_off')),
+    quantity INT NOT NULL CHECK (quantity <> 0),
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    actor VARCHAR(100) NOT NULL DEFAULT '',
+    reference VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_stock_movements_product ON stock_movements(product_id, store_id, id);
+
+-- the ledger opens with the stock already in each batch
+INSERT INTO stock_movements (batch_id, store_id, product_id, kind, quantity)
+SELECT id, store_id, product_id, 'opening', quantity FROM stock_batches WHERE quantity > 0 ORDER BY id;
@@ -0,0 +1,23 @@
+-- Physical counts of a store's shelves. A store has at most one stocktake open.
+CREATE TABLE stocktakes (
+    id BIGSERIAL PRIMARY KEY,
+    store_id INT NOT NULL REFERENCES stores(id),
+    opened_by VARCHAR(100) NOT NULL,
+    opened_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finalized_by VARCHAR(100) NOT NULL DEFAULT '',
+    finalized_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uq_stocktakes_open_store ON stocktakes(store_id) WHERE finalized_at IS NULL;
+
+-- The count of each product. system_quantity is the stock the store held when the
+-- product was counted.
+CREATE TABLE stocktake_counts (
+    stocktake_id BIGINT NOT NULL REFERENCES stocktakes(id),
+    product_id INT NOT NULL REFERENCES products(id),
+    counted INT NOT NULL CHECK (counted >= 0),
+    counted_by VARCHAR(100) NOT NULL,
+    counted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    system_quantity INT NOT NULL,
+    PRIMARY KEY (stocktake_id, product_id)
+);
@@ -0,0 +1,8 @@
+-- When and how much of a product to reorder. Each store's sellable stock is checked
+-- against the same level.
+CREATE TABLE reorder_levels (
+    product_id INT PRIMARY KEY REFERENCES products(id),
+    reorder_point INT NOT NULL CHECK (reorder_point >= 0),
+    reorder_quantity INT NOT NULL CHECK (reorder_quantity > 0),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,87 @@
+// Package pdf writes single-page PDF documents of monospaced text. It covers what
+// receipts need without a PDF library: one Courier font, one size, lines top to bottom.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	charWidth = 0.6 // Courier's advance width as a fraction of the font size
+	leading   = 1.2 // line height as a multiple of the font size
+)
+
+// Page is a page sized to fit Columns characters per line and every one of its Lines
+type Page struct {
+	FontSize float64 // in points
+	Margin   float64 // in points, on every side
+	Columns  int
+	Lines    []string
+}
+
+// Bytes renders the page as a complete PDF file
+func (p Page) Bytes() []byte {
+	lineHeight := p.FontSize * leading
+	width := 2*p.Margin + float64(p.Columns)*charWidth*p.FontSize
+	height := 2*p.Margin + float64(max(len(p.Lines), 1))*lineHeight
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "BT\n/F1 %s Tf\n%s TL\n%s %s Td\n",
+		num(p.FontSize), num(lineHeight), num(p.Margin), num(height-p.Margin-p.FontSize))
+	for i, line := range p.Lines {
+		if i > 0 {
+			content.WriteString("T*\n")
+		}
+		fmt.Fprintf(&content, "(%s) Tj\n", escape(line))
+	}
+	content.WriteString("ET")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+			num(width), num(height)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	// every cross-reference entry must be exactly 20 bytes
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// escape makes s a PDF literal string body; anything outside printable ASCII becomes '?'
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < ' ' || r > '~':
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func num(f float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", f), "0"), ".")
+}
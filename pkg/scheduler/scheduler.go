@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"bsnack/pkg/logger"
+	"context"
+	"time"
+)
+
+// Every runs job once per interval until ctx is cancelled. Failures are logged and the
+// job is retried on the next run, so a transient outage does not stop the schedule.
+func Every(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run(ctx, name, job)
+		}
+	}
+}
+
+// Daily runs job once a day at hour:minute local time until ctx is cancelled
+func Daily(ctx context.Context, name string, hour, minute int, job func(ctx context.Context) error) {
+	for {
+		now := time.Now()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			run(ctx, name, job)
+		}
+	}
+}
+
+func run(ctx context.Context, name string, job func(ctx context.Context) error) {
+	start := time.Now()
+	if err := job(ctx); err != nil {
+		logger.Error("scheduled job failed", "job", name, "error", err)
+		return
+	}
+	logger.Debug("scheduled job finished", "job", name, "duration", time.Since(start))
+}